@@ -66,6 +66,29 @@ func Zip(source string) (string, error) {
 	return target, err
 }
 
+// ZipFile archive the given file into a zip file next to it
+func ZipFile(source string) (string, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return "", err
+	} else if info.IsDir() {
+		return "", fmt.Errorf("the specified input is a directory: %s", source)
+	}
+	target := source + ".zip"
+	zipped, err := os.Create(target)
+	if err != nil {
+		return "", err
+	}
+	defer zipped.Close()
+	writer := zip.NewWriter(zipped)
+	err = zipFile(filepath.Dir(source), source, writer)
+	if err != nil {
+		writer.Close()
+		return "", err
+	}
+	return target, writer.Close()
+}
+
 func makeExt(index int) string {
 	return "." + strconv.Itoa(index)
 }
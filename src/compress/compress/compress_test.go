@@ -48,6 +48,21 @@ func TestZip(t *testing.T) {
 	makeZip(t)
 }
 
+func TestZipFile(t *testing.T) {
+	defer gotest.RemoveTestFolder(t)
+	root := makeFiles(t)
+	zipFile, err := ZipFile(filepath.Join(root, fileTest1))
+	gotest.Assert(t, err)
+	gotest.Check(t, zipFile == filepath.Join(root, fileTest1+".zip"))
+	_, err = ZipFile(root)
+	gotest.Check(t, err != nil)
+	err = os.Remove(filepath.Join(root, fileTest1))
+	gotest.Assert(t, err)
+	dst, err := Unzip(zipFile)
+	gotest.Assert(t, err)
+	gotest.CheckContent(t, filepath.Join(dst, fileTest1), "")
+}
+
 func TestUnzip(t *testing.T) {
 	defer gotest.RemoveTestFolder(t)
 	zipFile := makeZip(t)
@@ -1,6 +1,7 @@
 package algorithms
 
 import (
+	"crypto/sha256"
 	"testing"
 	"time"
 )
@@ -9,24 +10,32 @@ const (
 	alphabetical = "abcdefghijklmnopqrstuvwxyz"
 )
 
-func launchTestBruteForce(toFind string, cpu int) error {
+func launchTestBruteForce(toFind string, cpu int, delay time.Duration) (string, error) {
 	length := len(toFind)
 	return BruteForce(length, cpu, alphabetical, func(candidate string) bool {
-		time.Sleep(20 * time.Millisecond)
+		time.Sleep(delay)
 		return candidate == toFind
 	})
 }
 
 func TestBruteForce(t *testing.T) {
-	err := launchTestBruteForce("test", 1)
-	if err != nil {
-		t.Error("Test failed:", err.Error())
+	for _, cpu := range []int{1, 4} {
+		found, err := launchTestBruteForce("test", cpu, 0)
+		if err != nil {
+			t.Error("Test failed:", err.Error())
+		}
+		if found != "test" {
+			t.Errorf("expected to find %q with %d cpu, got %q", "test", cpu, found)
+		}
+	}
+	if _, err := launchTestBruteForce("TEST", 4, 0); err == nil {
+		t.Error("expected the brute force to fail outside of the charset")
 	}
 }
 
 func benchBruteForce(b *testing.B, cpu int) {
 	for n := 0; n < b.N; n++ {
-		launchTestBruteForce("zz", cpu)
+		launchTestBruteForce("zz", cpu, 20*time.Millisecond)
 	}
 }
 
@@ -34,3 +43,19 @@ func BenchmarkBruteForceCPU1(b *testing.B) { benchBruteForce(b, 1) }
 func BenchmarkBruteForceCPU2(b *testing.B) { benchBruteForce(b, 2) }
 func BenchmarkBruteForceCPU4(b *testing.B) { benchBruteForce(b, 4) }
 func BenchmarkBruteForceCPU8(b *testing.B) { benchBruteForce(b, 8) }
+
+// benchHashBruteForce measures the scaling of cpu-bound operands, the
+// whole keyspace of length 4 being hashed.
+func benchHashBruteForce(b *testing.B, cpu int) {
+	target := sha256.Sum256([]byte("none!"))
+	for n := 0; n < b.N; n++ {
+		BruteForce(4, cpu, alphabetical, func(candidate string) bool {
+			return sha256.Sum256([]byte(candidate)) == target
+		})
+	}
+}
+
+func BenchmarkBruteForceHashCPU1(b *testing.B) { benchHashBruteForce(b, 1) }
+func BenchmarkBruteForceHashCPU2(b *testing.B) { benchHashBruteForce(b, 2) }
+func BenchmarkBruteForceHashCPU4(b *testing.B) { benchHashBruteForce(b, 4) }
+func BenchmarkBruteForceHashCPU8(b *testing.B) { benchHashBruteForce(b, 8) }
@@ -1,111 +1,255 @@
 package algorithms
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"runtime"
+	"sort"
 	"sync"
+	"sync/atomic"
 )
 
+// ErrNotFound is returned by an attack which checked all its candidates
+// without match.
+var ErrNotFound = errors.New("failed to find a proper candidate")
+
+// worker checks its spans of the keyspace, its cursor being the index of
+// the next candidate to check.
+type worker struct {
+	spans  [][2]uint64
+	cursor uint64
+}
+
 type state struct {
-	charset   string
-	indexes   []int
-	candidate string
-	maxLength int
-	mutex     sync.Mutex
-	done      chan struct{}
-	quit      sync.WaitGroup
-}
-
-func replaceAt(str string, replacement string, index int) string {
-	return str[:index] + replacement + str[index+1:]
-}
-
-func makestate(charset string, maxLength int) *state {
-	return &state{
-		charset:   charset,
-		candidate: "",
-		indexes:   make([]int, maxLength),
-		maxLength: maxLength,
-		done:      make(chan struct{}),
+	keyspace *Keyspace
+	workers  []*worker
+	halted   int32
+	mutex    sync.Mutex
+	solution string
+	err      error
+	// cancel is closed once the brute force is cancelled, nil if it can't.
+	cancel <-chan struct{}
+	quit   sync.WaitGroup
+}
+
+func makestate(keyspace *Keyspace, spans [][2]uint64, cpu int) *state {
+	s := &state{
+		keyspace: keyspace,
+	}
+	for _, part := range keyspace.split(spans, cpu) {
+		w := &worker{spans: part}
+		if len(part) > 0 {
+			w.cursor = part[0][0]
+		}
+		s.workers = append(s.workers, w)
 	}
+	return s
 }
 
 func (s *state) stop() {
+	atomic.StoreInt32(&s.halted, 1)
+}
+
+func (s *state) stopped() bool {
+	return atomic.LoadInt32(&s.halted) != 0
+}
+
+func (s *state) found(candidate string) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	if s.done == nil {
-		return
+	if len(s.solution) == 0 {
+		s.solution = candidate
 	}
-	close(s.done)
-	s.done = nil
+	s.stop()
 }
 
-func (s *state) incremente(index int) {
-	if index >= s.maxLength {
-		s.candidate += "_invalid"
-		return
-	}
-	if s.indexes[index]+1 == len(s.charset) {
-		s.indexes[index] = 0
-		s.candidate = replaceAt(s.candidate, s.charset[0:1], index)
-		s.incremente(index + 1)
-		return
-	}
-	if index >= len(s.candidate) {
-		s.candidate += " "
-	} else {
-		s.indexes[index] += 1
+// fail stops the brute force on the first error of the operand.
+func (s *state) fail(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.err == nil {
+		s.err = err
 	}
-	at := s.indexes[index]
-	s.candidate = replaceAt(s.candidate, s.charset[at:at+1], index)
+	s.stop()
 }
 
-func (s *state) next() string {
+// result returns the matching candidate and the error of the operand.
+func (s *state) result() (string, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	if s.candidate == "" {
-		s.candidate = s.charset[0:1]
-		return s.candidate
+	return s.solution, s.err
+}
+
+// interrupted tells whether the brute force was stopped before its end
+// without match, its progress being worth saving.
+func (s *state) interrupted() bool {
+	solution, _ := s.result()
+	return s.stopped() && len(solution) == 0
+}
+
+// run checks the spans of the worker, the candidate failing to be checked
+// being left to check.
+func (s *state) run(w *worker, operand func([]byte) (bool, error)) {
+	for _, span := range w.spans {
+		it := s.keyspace.Iterate(span[0], span[1])
+		for it.Next() {
+			if s.stopped() {
+				return
+			}
+			select {
+			case <-s.cancel:
+				s.stop()
+				return
+			default:
+			}
+			candidate := it.Bytes()
+			match, err := operand(candidate)
+			if err != nil {
+				s.fail(err)
+				return
+			}
+			atomic.StoreUint64(&w.cursor, it.Index()+1)
+			if match {
+				s.found(string(candidate))
+				return
+			}
+		}
+	}
+}
+
+// remaining returns the spans which are not checked yet, sorted.
+func (s *state) remaining() [][2]uint64 {
+	var spans [][2]uint64
+	for _, w := range s.workers {
+		cursor := atomic.LoadUint64(&w.cursor)
+		for _, span := range w.spans {
+			if span[1] <= cursor {
+				continue
+			}
+			if span[0] < cursor {
+				span[0] = cursor
+			}
+			spans = append(spans, span)
+		}
 	}
-	s.incremente(0)
-	return s.candidate
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i][0] < spans[j][0]
+	})
+	return spans
+}
+
+// Status is the live status of a brute force.
+type Status struct {
+	// Size is the number of candidates of the keyspace.
+	Size uint64
+	// Tried is the number of candidates checked, before a resume included.
+	Tried uint64
+	// Workers are the numbers of candidates checked by every worker.
+	Workers []uint64
+	// Length is the length of the shortest candidates being checked.
+	Length int
+}
+
+// status returns the live status of the brute force.
+func (s *state) status() *Status {
+	status := &Status{
+		Size:    s.keyspace.Size(),
+		Tried:   s.keyspace.Size(),
+		Workers: make([]uint64, len(s.workers)),
+	}
+	for i, w := range s.workers {
+		cursor := atomic.LoadUint64(&w.cursor)
+		for _, span := range w.spans {
+			switch {
+			case span[1] <= cursor:
+				status.Workers[i] += span[1] - span[0]
+			case span[0] < cursor:
+				status.Workers[i] += cursor - span[0]
+				status.Tried -= span[1] - cursor
+			default:
+				status.Tried -= span[1] - span[0]
+			}
+			if span[1] > cursor && (status.Length == 0 || s.keyspace.length(max(cursor, span[0])) < status.Length) {
+				status.Length = s.keyspace.length(max(cursor, span[0]))
+			}
+		}
+	}
+	return status
+}
+
+// progress returns the position of the brute force, the candidates being
+// checked being part of the remaining ones.
+func (s *state) progress() *Progress {
+	return NewProgress(s.keyspace, s.remaining())
 }
 
 // BruteForce launch brute-force algorithm using given input and checks
-// if there is a match using the operand argument
-func BruteForce(maxLength, cpu int, charset string, operand func(string) bool) error {
-	runtime.GOMAXPROCS(cpu)
-	state := makestate(charset, maxLength)
+// if there is a match using the operand argument. It returns the matching
+// candidate.
+func BruteForce(maxLength, cpu int, charset string, operand func(string) bool) (string, error) {
+	return BruteForceWithCheckpoint(maxLength, cpu, charset, operand, nil)
+}
 
-	solution := ""
-	for i := 0; i < cpu; i++ {
-		state.quit.Add(1)
-		go func() {
-			for {
-				select {
-				case <-state.done:
-					state.quit.Done()
-					return
-				default:
-					candidate := state.next()
-					if len(candidate) > state.maxLength {
-						state.stop()
-						state.quit.Done()
-						return
-					}
-					if operand(candidate) {
-						solution = candidate
-						state.stop()
-						state.quit.Done()
-						return
-					}
-				}
+// BruteForceWithCheckpoint launch brute-force algorithm like BruteForce,
+// resuming from the checkpoint and saving its progress to it regularly
+// if not nil
+func BruteForceWithCheckpoint(maxLength, cpu int, charset string, operand func(string) bool, checkpoint *Checkpoint) (string, error) {
+	keyspace, err := NewKeyspace(charset, 1, maxLength)
+	if err != nil {
+		return "", err
+	}
+	return BruteForceKeyspace(keyspace, cpu, operand, checkpoint)
+}
+
+// BruteForceKeyspace launch brute-force algorithm over the keyspace, such
+// as the one of a mask, like BruteForceWithCheckpoint
+func BruteForceKeyspace(keyspace *Keyspace, cpu int, operand func(string) bool, checkpoint *Checkpoint) (string, error) {
+	state, err := execute(context.Background(), keyspace, cpu, func(candidate []byte) (bool, error) {
+		return operand(string(candidate)), nil
+	}, checkpoint)
+	if err != nil {
+		return "", err
+	}
+	if solution, _ := state.result(); len(solution) > 0 {
+		return solution, nil
+	}
+	if checkpoint != nil && checkpoint.stopped() {
+		return "", ErrStopped
+	}
+	return "", ErrNotFound
+}
+
+// execute checks the keyspace with the workers until a match, the end of
+// the keyspace, an error of the operand or the cancellation of the context,
+// resuming from the checkpoint if not nil.
+func execute(ctx context.Context, keyspace *Keyspace, workers int, operand func([]byte) (bool, error), checkpoint *Checkpoint) (*state, error) {
+	spans := [][2]uint64{{0, keyspace.Size()}}
+	if checkpoint != nil {
+		progress, err := checkpoint.load()
+		if err != nil {
+			return nil, err
+		}
+		if progress != nil {
+			if !progress.Matches(keyspace) {
+				return nil, fmt.Errorf("the checkpoint doesn't match the brute force parameters")
 			}
-		}()
+			spans = progress.Remaining
+		}
 	}
-	state.quit.Wait()
-	if len(solution) == 0 {
-		return fmt.Errorf("brute force failed to find a proper candidate")
+	state := makestate(keyspace, spans, workers)
+	state.cancel = ctx.Done()
+	if checkpoint != nil {
+		finish := checkpoint.start(state)
+		defer finish()
+	}
+
+	for _, w := range state.workers {
+		state.quit.Add(1)
+		go func(w *worker) {
+			defer state.quit.Done()
+			state.run(w, operand)
+		}(w)
 	}
-	return nil
+	state.quit.Wait()
+	return state, nil
 }
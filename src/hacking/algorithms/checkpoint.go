@@ -0,0 +1,273 @@
+package algorithms
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrStopped is returned by a brute force stopped with Checkpoint.Stop.
+var ErrStopped = errors.New("brute force stopped")
+
+// Progress is the position of a brute force, from which it can resume.
+type Progress struct {
+	Charset   string `json:"charset"`
+	MinLength int    `json:"min_length,omitempty"`
+	MaxLength int    `json:"max_length"`
+	// Charsets are the charsets of the positions of a mask keyspace.
+	Charsets []string `json:"charsets,omitempty"`
+	// Runes tells whether the charsets are made of Unicode characters.
+	Runes bool `json:"runes,omitempty"`
+	// Remaining are the index ranges of the candidates left to check,
+	// start included and end excluded.
+	Remaining [][2]uint64 `json:"remaining"`
+	// Tried is the number of candidates checked.
+	Tried uint64 `json:"tried"`
+}
+
+// NewProgress returns the progress of a brute force over the keyspace
+// with the remaining spans left to check.
+func NewProgress(k *Keyspace, remaining [][2]uint64) *Progress {
+	left := uint64(0)
+	for _, span := range remaining {
+		left += span[1] - span[0]
+	}
+	progress := &Progress{
+		Charset:   k.Charset,
+		MaxLength: k.MaxLength,
+		Remaining: remaining,
+		Tried:     k.Size() - left,
+	}
+	if k.MinLength > 1 {
+		progress.MinLength = k.MinLength
+	}
+	if len(k.Charset) == 0 {
+		progress.Charsets = k.Charsets()
+	}
+	progress.Runes = k.Runes()
+	return progress
+}
+
+// Keyspace returns the keyspace of the brute force.
+func (p *Progress) Keyspace() (*Keyspace, error) {
+	if len(p.Charset) > 0 && p.Runes {
+		return NewRuneKeyspace(p.Charset, max(p.MinLength, 1), p.MaxLength)
+	}
+	if len(p.Charset) > 0 {
+		return NewKeyspace(p.Charset, max(p.MinLength, 1), p.MaxLength)
+	}
+	if len(p.Charsets) != p.MaxLength {
+		return nil, fmt.Errorf("invalid progress charsets")
+	}
+	return newKeyspace(p.Charsets, max(p.MinLength, 1), p.Runes)
+}
+
+// Matches returns whether the progress is the one of a brute force over
+// the keyspace.
+func (p *Progress) Matches(k *Keyspace) bool {
+	if p.Charset != k.Charset || p.MaxLength != k.MaxLength || max(p.MinLength, 1) != k.MinLength ||
+		p.Runes != k.Runes() {
+		return false
+	}
+	if len(k.Charset) > 0 {
+		return true
+	}
+	if len(p.Charsets) != len(k.charsets) {
+		return false
+	}
+	for i, charset := range k.charsets {
+		if p.Charsets[i] != charset {
+			return false
+		}
+	}
+	return true
+}
+
+// Token returns the progress as a resume token.
+func (p *Progress) Token() string {
+	content, _ := json.Marshal(p)
+	return base64.RawURLEncoding.EncodeToString(content)
+}
+
+// ParseToken returns the progress of a resume token.
+func ParseToken(token string) (*Progress, error) {
+	content, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	progress := &Progress{}
+	return progress, json.Unmarshal(content, progress)
+}
+
+// LoadProgress loads the progress saved in a file, or returns nil if the
+// file doesn't exist.
+func LoadProgress(path string) (*Progress, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	progress := &Progress{}
+	return progress, json.Unmarshal(content, progress)
+}
+
+// Save saves the progress to a file atomically.
+func (p *Progress) Save(path string) error {
+	content, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	temporary := path + ".tmp"
+	file, err := os.OpenFile(temporary, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(content)
+	if err == nil {
+		// the content must be on disk before the rename, for a crash not
+		// to leave an empty checkpoint
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(temporary)
+		return err
+	}
+	return os.Rename(temporary, path)
+}
+
+// Checkpoint saves the progress of a brute force to a file regularly so
+// that it can resume from it after an interruption.
+type Checkpoint struct {
+	// Path is the checkpoint file, removed once the brute force is over.
+	Path string
+	// Interval is the duration between two saves.
+	Interval time.Duration
+	// Token is a resume token used instead of the checkpoint file, if set.
+	Token string
+
+	mutex   sync.Mutex
+	stop    chan struct{}
+	halted  bool
+	last    *Progress
+	running *state
+}
+
+// NewCheckpoint creates a checkpoint saved to the given file at the given
+// interval.
+func NewCheckpoint(path string, interval time.Duration) *Checkpoint {
+	return &Checkpoint{
+		Path:     path,
+		Interval: interval,
+	}
+}
+
+// Stop stops the brute force using the checkpoint after the candidates
+// being checked, saving its progress. It then returns ErrStopped.
+func (c *Checkpoint) Stop() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.halted = true
+	if c.running != nil {
+		c.running.stop()
+	}
+}
+
+func (c *Checkpoint) stopped() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.halted
+}
+
+// Progress returns the last progress saved, if any.
+func (c *Checkpoint) Progress() *Progress {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.last
+}
+
+// Status returns the live status of the brute force using the checkpoint,
+// nil if it isn't running.
+func (c *Checkpoint) Status() *Status {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.running == nil {
+		return nil
+	}
+	return c.running.status()
+}
+
+func (c *Checkpoint) load() (*Progress, error) {
+	if len(c.Token) > 0 {
+		return ParseToken(c.Token)
+	}
+	if len(c.Path) == 0 {
+		return nil, nil
+	}
+	return LoadProgress(c.Path)
+}
+
+func (c *Checkpoint) save(progress *Progress) error {
+	c.mutex.Lock()
+	c.last = progress
+	c.mutex.Unlock()
+	if len(c.Path) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0755); err != nil {
+		return err
+	}
+	return progress.Save(c.Path)
+}
+
+// start saves the progress of the state regularly and returns the function
+// to call once the brute force is over.
+func (c *Checkpoint) start(s *state) func() {
+	c.mutex.Lock()
+	c.running = s
+	halted := c.halted
+	c.stop = make(chan struct{})
+	c.mutex.Unlock()
+	if halted {
+		s.stop()
+	}
+	interval := c.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.stop:
+				return
+			case <-ticker.C:
+				c.save(s.progress())
+			}
+		}
+	}()
+	return func() {
+		close(c.stop)
+		<-done
+		c.mutex.Lock()
+		c.running = nil
+		c.mutex.Unlock()
+		if c.stopped() || s.interrupted() {
+			c.save(s.progress())
+		} else if len(c.Path) > 0 {
+			os.Remove(c.Path)
+		}
+	}
+}
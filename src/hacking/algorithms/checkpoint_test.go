@@ -0,0 +1,111 @@
+package algorithms
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func collect(t *testing.T, cpu int, checkpoint *Checkpoint, stopAfter int) (map[string]int, error) {
+	var mutex sync.Mutex
+	seen := make(map[string]int)
+	_, err := BruteForceWithCheckpoint(3, cpu, "abc", func(candidate string) bool {
+		mutex.Lock()
+		seen[candidate]++
+		count := len(seen)
+		mutex.Unlock()
+		if stopAfter > 0 && count == stopAfter {
+			checkpoint.Stop()
+		}
+		return false
+	}, checkpoint)
+	return seen, err
+}
+
+func TestBruteForceCheckpoint(t *testing.T) {
+	all, err := collect(t, 1, nil, 0)
+	if err == nil || len(all) == 0 {
+		t.Fatalf("unexpected brute force result: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "brute.checkpoint")
+	checkpoint := NewCheckpoint(path, time.Millisecond)
+	first, err := collect(t, 4, checkpoint, 10)
+	if err != ErrStopped {
+		t.Fatalf("expected the brute force to be stopped, got %v", err)
+	}
+	progress, err := LoadProgress(path)
+	if err != nil || progress == nil || progress.Tried < 10 || progress.Charset != "abc" {
+		t.Fatalf("unexpected progress %+v (%v)", progress, err)
+	}
+	token := checkpoint.Progress().Token()
+
+	second, err := collect(t, 4, NewCheckpoint(path, time.Millisecond), 0)
+	if err == nil || err == ErrStopped {
+		t.Fatalf("unexpected resumed brute force result: %v", err)
+	}
+	if len(second) >= len(all) {
+		t.Errorf("the brute force started over: %d candidates", len(second))
+	}
+	for candidate := range all {
+		if first[candidate] == 0 && second[candidate] == 0 {
+			t.Errorf("candidate %q skipped", candidate)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the checkpoint to be removed, got %v", err)
+	}
+
+	// a resume token works without the checkpoint file
+	resumed := &Checkpoint{Token: token}
+	third, _ := collect(t, 1, resumed, 0)
+	if len(third) != len(second) {
+		t.Errorf("unexpected candidates from the token: %d instead of %d", len(third), len(second))
+	}
+	mismatch := &Checkpoint{Token: token}
+	_, err = BruteForceWithCheckpoint(4, 1, "abc", func(string) bool { return false }, mismatch)
+	if err == nil || err == ErrStopped {
+		t.Errorf("expected a parameters mismatch error, got %v", err)
+	}
+}
+
+func TestProgressKeyspace(t *testing.T) {
+	mask, _ := NewMaskKeyspace("a?d?1", "xy")
+	charset, _ := NewKeyspace("abc", 2, 4)
+	for _, keyspace := range []*Keyspace{mask, charset} {
+		progress, err := ParseToken(NewProgress(keyspace, [][2]uint64{{3, 5}}).Token())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if progress.Tried != keyspace.Size()-2 {
+			t.Errorf("expected %d candidates tried, got %d", keyspace.Size()-2, progress.Tried)
+		}
+		k, err := progress.Keyspace()
+		if err != nil || !progress.Matches(k) || k.Size() != keyspace.Size() || k.At(4) != keyspace.At(4) {
+			t.Errorf("the keyspace of the progress doesn't match: %v", err)
+		}
+	}
+}
+
+func TestCheckpointStatus(t *testing.T) {
+	checkpoint := NewCheckpoint("", time.Minute)
+	if checkpoint.Status() != nil {
+		t.Errorf("expected no status before the brute force")
+	}
+	var status *Status
+	BruteForceWithCheckpoint(3, 1, "abc", func(candidate string) bool {
+		if candidate == "ab" {
+			status = checkpoint.Status()
+		}
+		return false
+	}, checkpoint)
+	// a, b, c and aa are checked
+	if status == nil || status.Size != 39 || status.Tried != 4 || len(status.Workers) != 1 ||
+		status.Workers[0] != 4 || status.Length != 2 {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if checkpoint.Status() != nil {
+		t.Errorf("expected no status after the brute force")
+	}
+}
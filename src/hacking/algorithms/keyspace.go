@@ -0,0 +1,377 @@
+package algorithms
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// Keyspace numbers the strings of MinLength to MaxLength characters,
+// every position having its own charset: the shortest come first and the
+// strings of a given length are in charset order, the last character
+// varying the fastest.
+type Keyspace struct {
+	// Charset is the charset of all the positions, empty for the masks.
+	Charset   string
+	MinLength int
+	MaxLength int
+
+	// charsets are the charsets of the positions
+	charsets []string
+	// symbols are the characters of the charsets of the positions when
+	// they are Unicode ones rather than bytes, nil otherwise
+	symbols [][]string
+	// offsets[i] is the index of the first string of MinLength+i characters
+	offsets []uint64
+}
+
+// NewKeyspace creates the keyspace of the strings from min to max
+// characters over the charset, which must not contain duplicates.
+func NewKeyspace(charset string, min, max int) (*Keyspace, error) {
+	if min <= 0 || max < min {
+		return nil, fmt.Errorf("invalid length range %d-%d", min, max)
+	}
+	charsets := make([]string, max)
+	for i := range charsets {
+		charsets[i] = charset
+	}
+	keyspace, err := newKeyspace(charsets, min, false)
+	if err != nil {
+		return nil, err
+	}
+	keyspace.Charset = charset
+	return keyspace, nil
+}
+
+// NewRuneKeyspace creates the keyspace of the strings from min to max
+// characters over the Unicode characters of the charset, which must not
+// contain duplicates.
+func NewRuneKeyspace(charset string, min, max int) (*Keyspace, error) {
+	if min <= 0 || max < min {
+		return nil, fmt.Errorf("invalid length range %d-%d", min, max)
+	}
+	charsets := make([]string, max)
+	for i := range charsets {
+		charsets[i] = charset
+	}
+	keyspace, err := newKeyspace(charsets, min, true)
+	if err != nil {
+		return nil, err
+	}
+	keyspace.Charset = charset
+	return keyspace, nil
+}
+
+// newKeyspace creates the keyspace of the charsets of the positions, made
+// of Unicode characters if runes is true, of bytes otherwise.
+func newKeyspace(charsets []string, min int, runes bool) (*Keyspace, error) {
+	max := len(charsets)
+	if min <= 0 || max < min {
+		return nil, fmt.Errorf("invalid length range %d-%d", min, max)
+	}
+	var symbols [][]string
+	if runes {
+		symbols = make([][]string, max)
+	}
+	offsets := make([]uint64, max-min+2)
+	count := uint64(1)
+	for length := 1; length <= max; length++ {
+		charset := charsets[length-1]
+		if len(charset) == 0 {
+			return nil, fmt.Errorf("the charset must not be empty")
+		}
+		if runes {
+			if !utf8.ValidString(charset) {
+				return nil, fmt.Errorf("the charset %q is not valid UTF-8", charset)
+			}
+			for i, r := range charset {
+				if strings.ContainsRune(charset[i+utf8.RuneLen(r):], r) {
+					return nil, fmt.Errorf("the charset contains %q twice", r)
+				}
+				symbols[length-1] = append(symbols[length-1], string(r))
+			}
+		} else {
+			for i := 0; i < len(charset); i++ {
+				if strings.IndexByte(charset[i+1:], charset[i]) >= 0 {
+					return nil, fmt.Errorf("the charset contains %q twice", charset[i])
+				}
+			}
+		}
+		base := uint64(len(charset))
+		if runes {
+			base = uint64(len(symbols[length-1]))
+		}
+		if count > math.MaxUint64/base {
+			return nil, fmt.Errorf("keyspace too large for length %d", length)
+		}
+		count *= base
+		if length < min {
+			continue
+		}
+		i := length - min
+		if offsets[i] > math.MaxUint64-count {
+			return nil, fmt.Errorf("keyspace too large for length %d", length)
+		}
+		offsets[i+1] = offsets[i] + count
+	}
+	keyspace := &Keyspace{
+		MinLength: min,
+		MaxLength: max,
+		charsets:  charsets,
+		offsets:   offsets,
+	}
+	// ASCII characters are single bytes
+	for _, charset := range charsets {
+		for i := 0; i < len(charset); i++ {
+			if charset[i] >= utf8.RuneSelf {
+				keyspace.symbols = symbols
+				return keyspace, nil
+			}
+		}
+	}
+	return keyspace, nil
+}
+
+// Charsets returns the charsets of the positions.
+func (k *Keyspace) Charsets() []string {
+	return append([]string(nil), k.charsets...)
+}
+
+// Runes returns whether the keyspace is made of Unicode characters rather
+// than bytes, its lengths being in characters.
+func (k *Keyspace) Runes() bool {
+	return k.symbols != nil
+}
+
+// base returns the number of characters of the charset of the position.
+func (k *Keyspace) base(position int) int {
+	if k.symbols != nil {
+		return len(k.symbols[position])
+	}
+	return len(k.charsets[position])
+}
+
+// Size returns the number of strings in the keyspace.
+func (k *Keyspace) Size() uint64 {
+	return k.offsets[len(k.offsets)-1]
+}
+
+// bounds returns the index range of the strings of the given length.
+func (k *Keyspace) bounds(length int) (uint64, uint64) {
+	return k.offsets[length-k.MinLength], k.offsets[length-k.MinLength+1]
+}
+
+// length returns the length of the string at the given index.
+func (k *Keyspace) length(index uint64) int {
+	length := k.MinLength
+	for length < k.MaxLength && index >= k.offsets[length-k.MinLength+1] {
+		length++
+	}
+	return length
+}
+
+// decode fills the digits of the string at the given index and returns
+// its length.
+func (k *Keyspace) decode(index uint64, digits []int) int {
+	length := k.length(index)
+	rest := index - k.offsets[length-k.MinLength]
+	for position := length - 1; position >= 0; position-- {
+		base := uint64(k.base(position))
+		digits[position] = int(rest % base)
+		rest /= base
+	}
+	return length
+}
+
+// At returns the string at the given index, which must be lower than
+// Size.
+func (k *Keyspace) At(index uint64) string {
+	if index >= k.Size() {
+		panic(fmt.Sprintf("keyspace index %d out of range", index))
+	}
+	digits := make([]int, k.MaxLength)
+	length := k.decode(index, digits)
+	return string(k.fill(nil, digits[:length]))
+}
+
+// fill appends the string of the digits to the buffer.
+func (k *Keyspace) fill(buffer []byte, digits []int) []byte {
+	for position, digit := range digits {
+		if k.symbols != nil {
+			buffer = append(buffer, k.symbols[position][digit]...)
+		} else {
+			buffer = append(buffer, k.charsets[position][digit])
+		}
+	}
+	return buffer
+}
+
+// Index returns the index of a string of the keyspace.
+func (k *Keyspace) Index(candidate string) (uint64, error) {
+	var characters []string
+	if k.symbols != nil {
+		for _, r := range candidate {
+			characters = append(characters, string(r))
+		}
+	} else {
+		for i := 0; i < len(candidate); i++ {
+			characters = append(characters, candidate[i:i+1])
+		}
+	}
+	if len(characters) < k.MinLength || len(characters) > k.MaxLength {
+		return 0, fmt.Errorf("%q is out of the keyspace lengths", candidate)
+	}
+	index := uint64(0)
+	for i, character := range characters {
+		digit := -1
+		if k.symbols != nil {
+			for d, symbol := range k.symbols[i] {
+				if symbol == character {
+					digit = d
+					break
+				}
+			}
+		} else {
+			digit = strings.IndexByte(k.charsets[i], character[0])
+		}
+		if digit < 0 {
+			return 0, fmt.Errorf("%q is out of the keyspace charset", candidate)
+		}
+		index = index*uint64(k.base(i)) + uint64(digit)
+	}
+	from, _ := k.bounds(len(characters))
+	return from + index, nil
+}
+
+// split cuts the spans at the length boundaries and divides every piece
+// between the workers, so that they all check the short candidates first.
+func (k *Keyspace) split(spans [][2]uint64, workers int) [][][2]uint64 {
+	parts := make([][][2]uint64, workers)
+	for _, span := range spans {
+		for length := k.MinLength; length <= k.MaxLength; length++ {
+			first, last := k.bounds(length)
+			from, to := span[0], span[1]
+			if from < first {
+				from = first
+			}
+			if to > last {
+				to = last
+			}
+			if from >= to {
+				continue
+			}
+			count := to - from
+			chunk, rest := count/uint64(workers), count%uint64(workers)
+			for w := 0; w < workers; w++ {
+				size := chunk
+				if uint64(w) < rest {
+					size++
+				}
+				if size > 0 {
+					parts[w] = append(parts[w], [2]uint64{from, from + size})
+				}
+				from += size
+			}
+		}
+	}
+	return parts
+}
+
+// Iterator enumerates a range of a keyspace with a mixed-radix counter.
+type Iterator struct {
+	keyspace *Keyspace
+	index    uint64
+	to       uint64
+	started  bool
+	length   int
+	digits   []int
+	buffer   []byte
+}
+
+// Iterate returns an iterator over the strings from index from
+// (included) to index to (excluded).
+func (k *Keyspace) Iterate(from, to uint64) *Iterator {
+	if to > k.Size() {
+		to = k.Size()
+	}
+	it := &Iterator{
+		keyspace: k,
+		index:    from,
+		to:       to,
+		digits:   make([]int, k.MaxLength),
+		buffer:   make([]byte, k.MaxLength),
+	}
+	if k.symbols != nil {
+		it.buffer = make([]byte, 0, k.MaxLength*utf8.UTFMax)
+	}
+	if from < to {
+		it.reset()
+	}
+	return it
+}
+
+func (it *Iterator) reset() {
+	it.length = it.keyspace.decode(it.index, it.digits)
+	it.buffer = it.keyspace.fill(it.buffer[:0], it.digits[:it.length])
+}
+
+// Next moves to the next string and returns false at the end of the
+// range.
+func (it *Iterator) Next() bool {
+	if !it.started {
+		it.started = true
+		return it.index < it.to
+	}
+	if it.index >= it.to {
+		return false
+	}
+	it.index++
+	if it.index >= it.to {
+		return false
+	}
+	if it.keyspace.symbols != nil {
+		// the characters have various sizes
+		for position := it.length - 1; position >= 0; position-- {
+			it.digits[position]++
+			if it.digits[position] < it.keyspace.base(position) {
+				it.buffer = it.keyspace.fill(it.buffer[:0], it.digits[:it.length])
+				return true
+			}
+			it.digits[position] = 0
+		}
+		it.reset()
+		return true
+	}
+	for position := it.length - 1; position >= 0; position-- {
+		charset := it.keyspace.charsets[position]
+		it.digits[position]++
+		if it.digits[position] < len(charset) {
+			it.buffer[position] = charset[it.digits[position]]
+			return true
+		}
+		it.digits[position] = 0
+		it.buffer[position] = charset[0]
+	}
+	// all the strings of the length are done
+	it.reset()
+	return true
+}
+
+// Index returns the index of the current string.
+func (it *Iterator) Index() uint64 {
+	return it.index
+}
+
+// Candidate returns the current string.
+func (it *Iterator) Candidate() string {
+	return string(it.Bytes())
+}
+
+// Bytes returns the current string, valid until the next call to Next.
+func (it *Iterator) Bytes() []byte {
+	if it.keyspace.symbols != nil {
+		return it.buffer
+	}
+	return it.buffer[:it.length]
+}
@@ -0,0 +1,172 @@
+package algorithms
+
+import (
+	"testing"
+)
+
+func TestKeyspace(t *testing.T) {
+	keyspace, err := NewKeyspace("abc", 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyspace.Size() != 9+27 {
+		t.Fatalf("unexpected keyspace size %d", keyspace.Size())
+	}
+	expected := []string{"aa", "ab", "ac", "ba", "bb", "bc", "ca", "cb", "cc", "aaa", "aab"}
+	it := keyspace.Iterate(0, keyspace.Size())
+	seen := make(map[string]bool)
+	for it.Next() {
+		candidate := it.Candidate()
+		index := it.Index()
+		if index < uint64(len(expected)) && candidate != expected[index] {
+			t.Errorf("expected %q at %d, got %q", expected[index], index, candidate)
+		}
+		if seen[candidate] {
+			t.Errorf("%q enumerated twice", candidate)
+		}
+		seen[candidate] = true
+		if at := keyspace.At(index); at != candidate {
+			t.Errorf("expected %q at %d, got %q", candidate, index, at)
+		}
+		if i, err := keyspace.Index(candidate); err != nil || i != index {
+			t.Errorf("expected index %d for %q, got %d (%v)", index, candidate, i, err)
+		}
+	}
+	if uint64(len(seen)) != keyspace.Size() || it.Index() != keyspace.Size() {
+		t.Errorf("enumerated %d strings instead of %d", len(seen), keyspace.Size())
+	}
+
+	// iterating a range crossing lengths starts at its index
+	it = keyspace.Iterate(7, 11)
+	var ranged []string
+	for it.Next() {
+		ranged = append(ranged, it.Candidate())
+	}
+	if len(ranged) != 4 || ranged[0] != "cb" || ranged[2] != "aaa" || ranged[3] != "aab" {
+		t.Errorf("unexpected range %v", ranged)
+	}
+
+	// the workers get every string exactly once
+	count := uint64(0)
+	for _, part := range keyspace.split([][2]uint64{{0, keyspace.Size()}}, 4) {
+		for _, span := range part {
+			count += span[1] - span[0]
+		}
+	}
+	if count != keyspace.Size() {
+		t.Errorf("the split covers %d strings instead of %d", count, keyspace.Size())
+	}
+
+	if _, err := keyspace.Index("abcd"); err == nil {
+		t.Error("expected an error for a too long string")
+	}
+	if _, err := keyspace.Index("ad"); err == nil {
+		t.Error("expected an error for a string out of the charset")
+	}
+	if _, err := NewKeyspace("abca", 1, 2); err == nil {
+		t.Error("expected an error for a charset with duplicates")
+	}
+	if _, err := NewKeyspace("abc", 3, 2); err == nil {
+		t.Error("expected an error for an invalid length range")
+	}
+	if _, err := NewKeyspace("0123456789", 1, 25); err == nil {
+		t.Error("expected an error for a too large keyspace")
+	}
+}
+
+func TestMaskKeyspace(t *testing.T) {
+	keyspace, err := NewMaskKeyspace("?u?l?l?d?d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyspace.Size() != 26*26*26*10*10 || keyspace.MinLength != 5 || keyspace.MaxLength != 5 {
+		t.Fatalf("unexpected keyspace %d %d-%d", keyspace.Size(), keyspace.MinLength, keyspace.MaxLength)
+	}
+	if first, last := keyspace.At(0), keyspace.At(keyspace.Size()-1); first != "Aaa00" || last != "Zzz99" {
+		t.Errorf("unexpected bounds %q-%q", first, last)
+	}
+	if index, err := keyspace.Index("Abc42"); err != nil || keyspace.At(index) != "Abc42" {
+		t.Errorf("unexpected index %d (%v)", index, err)
+	}
+
+	keyspace, err = NewMaskKeyspace("x??-?1?2", "ab", "?d?d0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var all []string
+	for it := keyspace.Iterate(0, keyspace.Size()); it.Next(); {
+		all = append(all, it.Candidate())
+	}
+	if len(all) != 20 || all[0] != "x?-a0" || all[1] != "x?-a1" || all[19] != "x?-b9" {
+		t.Errorf("unexpected candidates %v", all)
+	}
+
+	for _, invalid := range [][]string{{"?"}, {"?x"}, {"?1"}, {"?1", "?z"}, {""}, {"?2", "a", ""}} {
+		if _, err := NewMaskKeyspace(invalid[0], invalid[1:]...); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+
+	// a mask brute force resumes only with the same mask
+	keyspace, _ = NewMaskKeyspace("?d?d")
+	found, err := BruteForceKeyspace(keyspace, 4, func(candidate string) bool {
+		return candidate == "42"
+	}, nil)
+	if err != nil || found != "42" {
+		t.Errorf("expected to find 42, got %q (%v)", found, err)
+	}
+	progress := makestate(keyspace, [][2]uint64{{0, keyspace.Size()}}, 1).progress()
+	other, _ := NewMaskKeyspace("?d?l")
+	if !progress.Matches(keyspace) || progress.Matches(other) {
+		t.Error("unexpected progress matching")
+	}
+}
+
+func TestRuneKeyspace(t *testing.T) {
+	keyspace, err := NewRuneKeyspace("aé€", 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !keyspace.Runes() || keyspace.Size() != 3+9 {
+		t.Fatalf("unexpected keyspace of %d strings", keyspace.Size())
+	}
+	expected := []string{"a", "é", "€", "aa", "aé", "a€", "éa"}
+	it := keyspace.Iterate(0, keyspace.Size())
+	for it.Next() {
+		candidate := it.Candidate()
+		index := it.Index()
+		if index < uint64(len(expected)) && candidate != expected[index] {
+			t.Errorf("expected %q at %d, got %q", expected[index], index, candidate)
+		}
+		if at := keyspace.At(index); at != candidate {
+			t.Errorf("expected %q at %d, got %q", candidate, index, at)
+		}
+		if i, err := keyspace.Index(candidate); err != nil || i != index {
+			t.Errorf("expected index %d for %q, got %d (%v)", index, candidate, i, err)
+		}
+	}
+	if candidate := keyspace.At(keyspace.Size() - 1); candidate != "€€" {
+		t.Errorf("expected the last string to be €€, got %q", candidate)
+	}
+	if _, err := keyspace.Index("aéa"); err == nil {
+		t.Error("expected an error for a too long string")
+	}
+
+	// ASCII charsets are plain byte keyspaces
+	if keyspace, _ := NewRuneKeyspace("abc", 1, 2); keyspace.Runes() {
+		t.Error("expected an ASCII keyspace not to be made of runes")
+	}
+	if _, err := NewRuneKeyspace("aéé", 1, 2); err == nil {
+		t.Error("expected an error for a charset with duplicates")
+	}
+	if _, err := NewRuneKeyspace("a\xff", 1, 2); err == nil {
+		t.Error("expected an error for an invalid charset")
+	}
+
+	// the progress of a rune keyspace resumes it
+	progress := NewProgress(keyspace, [][2]uint64{{5, 12}})
+	resumed, err := progress.Keyspace()
+	if err != nil || !resumed.Runes() || !progress.Matches(keyspace) {
+		t.Errorf("expected the progress to match the keyspace (%v)", err)
+	}
+}
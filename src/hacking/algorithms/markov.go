@@ -0,0 +1,271 @@
+package algorithms
+
+import (
+	"container/heap"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+const (
+	// markovPrior is the weight of the position independent probabilities
+	// in the position dependent ones.
+	markovPrior = 1
+	// markovSmoothing is the count added to every transition, so that the
+	// candidates unseen in the corpus are generated too.
+	markovSmoothing = 0.01
+)
+
+// transition is a next character, or the end of the candidate, and its
+// cost, -log2 of its probability.
+type transition struct {
+	symbol int
+	cost   float64
+}
+
+// Markov is a per-position Markov chain of the characters of passwords:
+// the probability of a character depends on its position and on the
+// previous one. Trained on a password corpus, it generates the candidates
+// most likely first.
+type Markov struct {
+	MinLength int
+	MaxLength int
+	// alphabet are the characters of the corpus, sorted, and symbols their
+	// index, the end of a candidate being len(alphabet).
+	alphabet []byte
+	symbols  [256]int
+	// transitions are the sorted transitions of every position and previous
+	// character, the start being len(alphabet).
+	transitions [][][]transition
+	words       uint64
+}
+
+// TrainMarkov trains a model generating candidates of minLength to
+// maxLength characters on a corpus, one password per line.
+func TrainMarkov(r io.Reader, minLength, maxLength int) (*Markov, error) {
+	if minLength <= 0 || maxLength < minLength {
+		return nil, fmt.Errorf("invalid length range [%d, %d]", minLength, maxLength)
+	}
+	m := &Markov{MinLength: minLength, MaxLength: maxLength}
+	var corpus []string
+	var seen [256]bool
+	_, err := ReadWords(r, func(word string) bool {
+		corpus = append(corpus, word)
+		for i := 0; i < len(word); i++ {
+			seen[word[i]] = true
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	for c := range seen {
+		if seen[c] {
+			m.symbols[c] = len(m.alphabet)
+			m.alphabet = append(m.alphabet, byte(c))
+		}
+	}
+	if len(m.alphabet) == 0 {
+		return nil, fmt.Errorf("empty training corpus")
+	}
+	m.words = uint64(len(corpus))
+
+	// counts of the position dependent and independent transitions
+	size := len(m.alphabet) + 1
+	counts := make([][][]float64, maxLength)
+	for i := range counts {
+		counts[i] = make([][]float64, size)
+	}
+	global := make([][]float64, size)
+	for i := range global {
+		global[i] = make([]float64, size)
+	}
+	for _, word := range corpus {
+		previous := len(m.alphabet)
+		for i := 0; i <= len(word) && i < maxLength; i++ {
+			symbol := len(m.alphabet)
+			if i < len(word) {
+				symbol = m.symbols[word[i]]
+			}
+			if counts[i][previous] == nil {
+				counts[i][previous] = make([]float64, size)
+			}
+			counts[i][previous][symbol]++
+			global[previous][symbol]++
+			previous = symbol
+		}
+	}
+
+	m.transitions = make([][][]transition, maxLength+1)
+	for i := 0; i <= maxLength; i++ {
+		m.transitions[i] = make([][]transition, size)
+		for previous := 0; previous < size; previous++ {
+			// the start is the only previous character of the first one
+			if (i == 0) != (previous == len(m.alphabet)) {
+				continue
+			}
+			if i == maxLength {
+				m.transitions[i][previous] = []transition{{len(m.alphabet), 0}}
+				continue
+			}
+			m.transitions[i][previous] = m.distribution(i, counts[i][previous], global[previous])
+		}
+	}
+	return m, nil
+}
+
+// distribution returns the sorted transitions of a position from the
+// counts of the position and the position independent ones.
+func (m *Markov) distribution(position int, counts, global []float64) []transition {
+	size := len(global)
+	total, globalTotal := 0.0, 0.0
+	for symbol := range global {
+		if counts != nil {
+			total += counts[symbol]
+		}
+		globalTotal += global[symbol]
+	}
+	probabilities := make([]float64, size)
+	sum := 0.0
+	for symbol := range global {
+		if symbol == len(m.alphabet) && position < m.MinLength {
+			continue
+		}
+		p := (global[symbol] + markovSmoothing) / (globalTotal + markovSmoothing*float64(size))
+		if counts != nil {
+			p = (counts[symbol] + markovPrior*p) / (total + markovPrior)
+		}
+		probabilities[symbol] = p
+		sum += p
+	}
+	var transitions []transition
+	for symbol, p := range probabilities {
+		if p > 0 {
+			transitions = append(transitions, transition{symbol, -math.Log2(p / sum)})
+		}
+	}
+	sort.SliceStable(transitions, func(i, j int) bool {
+		return transitions[i].cost < transitions[j].cost
+	})
+	return transitions
+}
+
+// LoadMarkov trains a model on a corpus file.
+func LoadMarkov(path string, minLength, maxLength int) (*Markov, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return TrainMarkov(file, minLength, maxLength)
+}
+
+// Alphabet returns the characters of the candidates.
+func (m *Markov) Alphabet() string {
+	return string(m.alphabet)
+}
+
+// Words returns the number of passwords of the training corpus.
+func (m *Markov) Words() uint64 {
+	return m.words
+}
+
+// Probability returns the probability of the candidate.
+func (m *Markov) Probability(candidate string) float64 {
+	if len(candidate) < m.MinLength || len(candidate) > m.MaxLength {
+		return 0
+	}
+	cost := 0.0
+	previous := len(m.alphabet)
+	for i := 0; i <= len(candidate); i++ {
+		symbol := len(m.alphabet)
+		if i < len(candidate) {
+			if symbol = m.symbols[candidate[i]]; m.alphabet[symbol] != candidate[i] {
+				return 0
+			}
+		}
+		found := false
+		for _, t := range m.transitions[i][previous] {
+			if t.symbol == symbol {
+				cost += t.cost
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0
+		}
+		previous = symbol
+	}
+	return math.Exp2(-cost)
+}
+
+// markovNode is a transition to expand: the candidate prefix, its cost,
+// and the rank of the transition among the ones of the prefix.
+type markovNode struct {
+	prefix      string
+	prefixCost  float64
+	transitions []transition
+	rank        int
+}
+
+func (n *markovNode) cost() float64 {
+	return n.prefixCost + n.transitions[n.rank].cost
+}
+
+type markovQueue []*markovNode
+
+func (q markovQueue) Len() int            { return len(q) }
+func (q markovQueue) Less(i, j int) bool  { return q[i].cost() < q[j].cost() }
+func (q markovQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *markovQueue) Push(x interface{}) { *q = append(*q, x.(*markovNode)) }
+func (q *markovQueue) Pop() interface{} {
+	old := *q
+	n := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return n
+}
+
+// Generate calls emit on the candidates by decreasing probability, at most
+// limit of them if not 0, until emit returns false. It returns false if
+// emit did.
+//
+// The prefixes are expanded best first, a prefix only queuing its most
+// likely transition and a transition its next sibling when expanded, so
+// that the queue grows by one node per candidate at most.
+func (m *Markov) Generate(limit uint64, emit func(string) bool) bool {
+	queue := &markovQueue{{transitions: m.transitions[0][len(m.alphabet)]}}
+	for count := uint64(0); queue.Len() > 0 && (limit == 0 || count < limit); {
+		node := heap.Pop(queue).(*markovNode)
+		t := node.transitions[node.rank]
+		if node.rank+1 < len(node.transitions) {
+			heap.Push(queue, &markovNode{node.prefix, node.prefixCost, node.transitions, node.rank + 1})
+		}
+		if t.symbol == len(m.alphabet) {
+			if !emit(node.prefix) {
+				return false
+			}
+			count++
+			continue
+		}
+		prefix := node.prefix + string(m.alphabet[t.symbol])
+		heap.Push(queue, &markovNode{prefix, node.prefixCost + t.cost, m.transitions[len(prefix)][t.symbol], 0})
+	}
+	return true
+}
+
+// MarkovAttack checks the candidates of the model by decreasing
+// probability, at most limit of them if not 0, with the operand using cpu
+// workers. It returns the matching candidate, or ErrNotFound.
+func MarkovAttack(m *Markov, limit uint64, cpu int, operand func(string) bool) (string, error) {
+	solution, err := stream(cpu, operand, func(emit func(string) bool) error {
+		m.Generate(limit, emit)
+		return nil
+	})
+	if len(solution) > 0 || err != nil {
+		return solution, err
+	}
+	return "", ErrNotFound
+}
@@ -0,0 +1,97 @@
+package algorithms
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const markovCorpus = `password
+password1
+passw0rd
+dragon
+dragon1
+monkey
+letmein
+abc123
+password
+123456
+`
+
+func TestMarkov(t *testing.T) {
+	if _, err := TrainMarkov(strings.NewReader(""), 1, 4); err == nil {
+		t.Errorf("expected an error with an empty corpus")
+	}
+	if _, err := TrainMarkov(strings.NewReader(markovCorpus), 3, 2); err == nil {
+		t.Errorf("expected an error with an invalid length range")
+	}
+
+	// all the candidates of a small alphabet, by decreasing probability
+	m, err := TrainMarkov(strings.NewReader("ab\naab\nb\nab\n"), 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Alphabet() != "ab" || m.Words() != 4 {
+		t.Errorf("unexpected alphabet %q or words %d", m.Alphabet(), m.Words())
+	}
+	var candidates []string
+	m.Generate(0, func(candidate string) bool {
+		candidates = append(candidates, candidate)
+		return true
+	})
+	if len(candidates) != 2+4+8+16 {
+		t.Fatalf("expected 30 candidates, got %d", len(candidates))
+	}
+	if candidates[0] != "ab" {
+		t.Errorf("expected ab first, got %v", candidates)
+	}
+	seen := make(map[string]bool)
+	total, last := 0.0, 1.0
+	for _, candidate := range candidates {
+		p := m.Probability(candidate)
+		if seen[candidate] || p > last*(1+1e-9) {
+			t.Errorf("%q is a duplicate or out of order: %g after %g", candidate, p, last)
+		}
+		seen[candidate] = true
+		total += p
+		last = p
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("expected the probabilities to sum to 1, got %g", total)
+	}
+	if m.Probability("abc") != 0 || m.Probability("") != 0 || m.Probability("aaaaa") != 0 {
+		t.Errorf("expected a null probability out of the alphabet or lengths")
+	}
+
+	m, err = TrainMarkov(strings.NewReader(markovCorpus), 6, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	m.Generate(3, func(candidate string) bool {
+		if count == 0 && candidate != "password" {
+			t.Errorf("expected password first, got %q", candidate)
+		}
+		count++
+		return true
+	})
+	if count != 3 {
+		t.Errorf("expected 3 candidates with a limit, got %d", count)
+	}
+	if m.Generate(0, func(candidate string) bool { return candidate != "passw0rd1" }) {
+		t.Errorf("expected the generation to stop")
+	}
+
+	// unseen candidates come after the likely ones but are generated
+	for _, toFind := range []string{"dragon", "monkey1", "passwor"} {
+		found, err := MarkovAttack(m, 100000, 2, func(candidate string) bool {
+			return candidate == toFind
+		})
+		if err != nil || found != toFind {
+			t.Errorf("expected %q, got %q (%v)", toFind, found, err)
+		}
+	}
+	if _, err := MarkovAttack(m, 100, 2, func(string) bool { return false }); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound without match, got %v", err)
+	}
+}
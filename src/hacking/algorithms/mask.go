@@ -0,0 +1,121 @@
+package algorithms
+
+import (
+	"fmt"
+)
+
+// ASCIICharset is the printable ASCII characters, in the order of their
+// codes.
+const ASCIICharset = " !\"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~"
+
+// MaskCharsets are the built-in charsets of the masks.
+var MaskCharsets = map[byte]string{
+	'l': "abcdefghijklmnopqrstuvwxyz",
+	'u': "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	'd': "0123456789",
+	'h': "0123456789abcdef",
+	'H': "0123456789ABCDEF",
+	's': " !\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~",
+	'a': "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 !\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~",
+	'b': bytesCharset(),
+}
+
+func bytesCharset() string {
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	return string(all)
+}
+
+// ExpandCharset expands the built-in charsets of a custom charset, such as
+// "?l?d_", dropping the duplicate characters.
+func ExpandCharset(custom string) (string, error) {
+	var expanded []byte
+	seen := make(map[byte]bool)
+	add := func(charset string) {
+		for i := 0; i < len(charset); i++ {
+			if !seen[charset[i]] {
+				seen[charset[i]] = true
+				expanded = append(expanded, charset[i])
+			}
+		}
+	}
+	for i := 0; i < len(custom); i++ {
+		if custom[i] != '?' {
+			add(custom[i : i+1])
+			continue
+		}
+		i++
+		if i == len(custom) {
+			return "", fmt.Errorf("custom charset %q ends with '?'", custom)
+		}
+		if custom[i] == '?' {
+			add("?")
+			continue
+		}
+		charset, ok := MaskCharsets[custom[i]]
+		if !ok {
+			return "", fmt.Errorf("unknown charset ?%c in custom charset %q", custom[i], custom)
+		}
+		add(charset)
+	}
+	if len(expanded) == 0 {
+		return "", fmt.Errorf("empty custom charset")
+	}
+	return string(expanded), nil
+}
+
+// NewMaskKeyspace creates the keyspace of a mask: every position is a
+// built-in charset (?l lowercase, ?u uppercase, ?d digits, ?h and ?H
+// hexadecimal, ?s special, ?a all of them, ?b all bytes), a custom charset
+// ?1 to ?4 among the given ones, a literal '?' (??) or a literal character.
+// The custom charsets may use the built-in ones, like "?l?d".
+func NewMaskKeyspace(mask string, custom ...string) (*Keyspace, error) {
+	if len(custom) > 4 {
+		return nil, fmt.Errorf("at most 4 custom charsets are supported")
+	}
+	customs := make([]string, len(custom))
+	for i, charset := range custom {
+		if len(charset) == 0 {
+			continue
+		}
+		expanded, err := ExpandCharset(charset)
+		if err != nil {
+			return nil, err
+		}
+		customs[i] = expanded
+	}
+	var charsets []string
+	for i := 0; i < len(mask); i++ {
+		if mask[i] != '?' {
+			charsets = append(charsets, mask[i:i+1])
+			continue
+		}
+		i++
+		if i == len(mask) {
+			return nil, fmt.Errorf("mask %q ends with '?'", mask)
+		}
+		c := mask[i]
+		switch {
+		case c == '?':
+			charsets = append(charsets, "?")
+		case c >= '1' && c <= '4':
+			n := int(c - '1')
+			if n >= len(customs) || len(customs[n]) == 0 {
+				return nil, fmt.Errorf("custom charset ?%c is not defined", c)
+			}
+			charsets = append(charsets, customs[n])
+		default:
+			charset, ok := MaskCharsets[c]
+			if !ok {
+				return nil, fmt.Errorf("unknown charset ?%c in mask %q", c, mask)
+			}
+			charsets = append(charsets, charset)
+		}
+	}
+	if len(charsets) == 0 {
+		return nil, fmt.Errorf("empty mask")
+	}
+	return newKeyspace(charsets, len(charsets), false)
+}
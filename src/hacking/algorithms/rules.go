@@ -0,0 +1,301 @@
+package algorithms
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultRules are the mangling rules used when none are given: the word
+// itself, case changes, reversal, common appends and leetspeak.
+var DefaultRules = []string{
+	":",
+	"l",
+	"u",
+	"c",
+	"C",
+	"t",
+	"r",
+	"d",
+	"$1",
+	"$!",
+	"$1$2$3",
+	"c$1",
+	"c$!",
+	"^1",
+	"sa@",
+	"se3",
+	"so0",
+	"si1",
+	"ss$",
+	"sa@se3si1so0ss$",
+	"csa@se3si1so0",
+}
+
+// operation is a single mangling function of a rule.
+type operation func(word []rune) []rune
+
+// Rule is a list of mangling functions in the hashcat/John rule syntax:
+//
+//	:     do nothing           l     lowercase
+//	u     uppercase            c     capitalize
+//	C     invert capitalize    t     toggle the case
+//	TN    toggle at N          r     reverse
+//	d     duplicate            f     reflect
+//	{     rotate left          }     rotate right
+//	$X    append X             ^X    prepend X
+//	[     delete first         ]     delete last
+//	DN    delete at N          sXY   replace X with Y
+//	@X    purge X
+//
+// Positions N are 0-9 then A-Z for 10-35.
+type Rule struct {
+	text       string
+	operations []operation
+}
+
+func position(c byte) (int, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0'), true
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10, true
+	}
+	return 0, false
+}
+
+func mapRunes(word []rune, mapping func(rune) rune) []rune {
+	for i, r := range word {
+		word[i] = mapping(r)
+	}
+	return word
+}
+
+func toggle(r rune) rune {
+	if unicode.IsUpper(r) {
+		return unicode.ToLower(r)
+	}
+	return unicode.ToUpper(r)
+}
+
+// ParseRule parses a mangling rule, spaces between functions being ignored.
+func ParseRule(text string) (*Rule, error) {
+	rule := &Rule{text: text}
+	for i := 0; i < len(text); i++ {
+		// arguments returns the n bytes following the function
+		arguments := func(n int) (string, error) {
+			if i+n >= len(text) {
+				return "", fmt.Errorf("missing argument for %q in rule %q", text[i], text)
+			}
+			value := text[i+1 : i+1+n]
+			i += n
+			return value, nil
+		}
+		// character returns the UTF-8 character following the function
+		character := func() (rune, error) {
+			if i+1 >= len(text) {
+				return 0, fmt.Errorf("missing argument for %q in rule %q", text[i], text)
+			}
+			c, size := utf8.DecodeRuneInString(text[i+1:])
+			if c == utf8.RuneError && size == 1 {
+				return 0, fmt.Errorf("invalid character in rule %q", text)
+			}
+			i += size
+			return c, nil
+		}
+		var op operation
+		switch text[i] {
+		case ' ', '\t', ':':
+			continue
+		case 'l':
+			op = func(word []rune) []rune { return mapRunes(word, unicode.ToLower) }
+		case 'u':
+			op = func(word []rune) []rune { return mapRunes(word, unicode.ToUpper) }
+		case 'c', 'C':
+			first, rest := unicode.ToUpper, unicode.ToLower
+			if text[i] == 'C' {
+				first, rest = rest, first
+			}
+			op = func(word []rune) []rune {
+				mapRunes(word, rest)
+				if len(word) > 0 {
+					word[0] = first(word[0])
+				}
+				return word
+			}
+		case 't':
+			op = func(word []rune) []rune { return mapRunes(word, toggle) }
+		case 'r':
+			op = func(word []rune) []rune {
+				for i, j := 0, len(word)-1; i < j; i, j = i+1, j-1 {
+					word[i], word[j] = word[j], word[i]
+				}
+				return word
+			}
+		case 'd':
+			op = func(word []rune) []rune { return append(word, word...) }
+		case 'f':
+			op = func(word []rune) []rune {
+				for i := len(word) - 1; i >= 0; i-- {
+					word = append(word, word[i])
+				}
+				return word
+			}
+		case '{':
+			op = func(word []rune) []rune {
+				if len(word) > 0 {
+					word = append(word[1:], word[0])
+				}
+				return word
+			}
+		case '}':
+			op = func(word []rune) []rune {
+				if len(word) > 0 {
+					word = append([]rune{word[len(word)-1]}, word[:len(word)-1]...)
+				}
+				return word
+			}
+		case '[':
+			op = func(word []rune) []rune {
+				if len(word) > 0 {
+					word = word[1:]
+				}
+				return word
+			}
+		case ']':
+			op = func(word []rune) []rune {
+				if len(word) > 0 {
+					word = word[:len(word)-1]
+				}
+				return word
+			}
+		case '$', '^':
+			prepend := text[i] == '^'
+			c, err := character()
+			if err != nil {
+				return nil, err
+			}
+			op = func(word []rune) []rune {
+				if prepend {
+					return append([]rune{c}, word...)
+				}
+				return append(word, c)
+			}
+		case 'T', 'D':
+			remove := text[i] == 'D'
+			value, err := arguments(1)
+			if err != nil {
+				return nil, err
+			}
+			n, ok := position(value[0])
+			if !ok {
+				return nil, fmt.Errorf("invalid position %q in rule %q", value, text)
+			}
+			op = func(word []rune) []rune {
+				if n >= len(word) {
+					return word
+				}
+				if remove {
+					return append(word[:n], word[n+1:]...)
+				}
+				word[n] = toggle(word[n])
+				return word
+			}
+		case 's':
+			from, err := character()
+			if err != nil {
+				return nil, err
+			}
+			to, err := character()
+			if err != nil {
+				return nil, err
+			}
+			op = func(word []rune) []rune {
+				return mapRunes(word, func(r rune) rune {
+					if r == from {
+						return to
+					}
+					return r
+				})
+			}
+		case '@':
+			c, err := character()
+			if err != nil {
+				return nil, err
+			}
+			op = func(word []rune) []rune {
+				kept := word[:0]
+				for _, r := range word {
+					if r != c {
+						kept = append(kept, r)
+					}
+				}
+				return kept
+			}
+		default:
+			return nil, fmt.Errorf("unknown function %q in rule %q", text[i], text)
+		}
+		rule.operations = append(rule.operations, op)
+	}
+	return rule, nil
+}
+
+// String returns the rule text.
+func (r *Rule) String() string {
+	return r.text
+}
+
+// Apply returns the word mangled by the rule.
+func (r *Rule) Apply(word string) string {
+	runes := []rune(word)
+	for _, op := range r.operations {
+		runes = op(runes)
+	}
+	return string(runes)
+}
+
+// ParseRules parses the given rules.
+func ParseRules(texts []string) ([]*Rule, error) {
+	rules := make([]*Rule, 0, len(texts))
+	for _, text := range texts {
+		rule, err := ParseRule(text)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ReadRules reads one rule per line, skipping the empty lines and the
+// comments starting with '#'.
+func ReadRules(r io.Reader) ([]*Rule, error) {
+	var rules []*Rule
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(strings.TrimSpace(line)) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := ParseRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", number, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// LoadRules reads the rules of a file.
+func LoadRules(path string) ([]*Rule, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadRules(file)
+}
@@ -0,0 +1,63 @@
+package algorithms
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	for _, test := range []struct {
+		rule     string
+		word     string
+		expected string
+	}{
+		{":", "Password", "Password"},
+		{"l", "PassWord", "password"},
+		{"u", "password", "PASSWORD"},
+		{"c", "pASSWORD", "Password"},
+		{"C", "password", "pASSWORD"},
+		{"t", "PassWord", "pASSwORD"},
+		{"T0T2", "password", "PaSsword"},
+		{"r", "abc", "cba"},
+		{"d", "abc", "abcabc"},
+		{"f", "abc", "abccba"},
+		{"{", "abc", "bca"},
+		{"}", "abc", "cab"},
+		{"$1$2$3", "abc", "abc123"},
+		{"^1^2", "abc", "21abc"},
+		{"[", "abc", "bc"},
+		{"]", "abc", "ab"},
+		{"D1", "abc", "ac"},
+		{"D9", "abc", "abc"},
+		{"sa@ se3 so0", "awesome", "@w3s0m3"},
+		{"@s", "password", "paword"},
+		{"c $!", "hello", "Hello!"},
+		{"$é^à", "caf", "àcafé"},
+		{"sée @ç", "garçon été", "garon ete"},
+	} {
+		rule, err := ParseRule(test.rule)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", test.rule, err)
+			continue
+		}
+		if result := rule.Apply(test.word); result != test.expected {
+			t.Errorf("%q on %q: expected %q, got %q", test.rule, test.word, test.expected, result)
+		}
+	}
+	for _, invalid := range []string{"$", "sa", "Tx", "X", "$\xe9"} {
+		if _, err := ParseRule(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+	if _, err := ParseRules(DefaultRules); err != nil {
+		t.Errorf("invalid default rules: %v", err)
+	}
+	rules, err := ReadRules(strings.NewReader("# comment\n\nc\nr\n"))
+	if err != nil || len(rules) != 2 || rules[1].String() != "r" {
+		t.Errorf("unexpected rules %v (%v)", rules, err)
+	}
+	_, err = ReadRules(strings.NewReader("c\nsa\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("expected an error at line 2, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// Options are the parameters of Search.
+type Options struct {
+	// Keyspace is the keyspace to search, such as the one of a mask. If
+	// nil, it is the one of the Unicode characters of Charset from
+	// MinLength, 1 by default, to MaxLength characters.
+	Keyspace  *Keyspace
+	Charset   string
+	MinLength int
+	MaxLength int
+	// Workers is the number of goroutines checking the candidates,
+	// GOMAXPROCS by default.
+	Workers int
+	// Checkpoint is resumed from and saved to, if not nil.
+	Checkpoint *Checkpoint
+}
+
+// Result is the outcome of Search.
+type Result struct {
+	// Found tells whether a candidate matched.
+	Found bool
+	Match string
+	// Tried is the number of candidates checked, before a resume excluded.
+	Tried uint64
+	// Workers are the numbers of candidates checked by every worker.
+	Workers  []uint64
+	Duration time.Duration
+}
+
+// keyspace returns the keyspace of the options.
+func (o *Options) keyspace() (*Keyspace, error) {
+	if o.Keyspace != nil {
+		return o.Keyspace, nil
+	}
+	if len(o.Charset) == 0 {
+		return nil, fmt.Errorf("no keyspace nor charset")
+	}
+	return NewRuneKeyspace(o.Charset, max(o.MinLength, 1), o.MaxLength)
+}
+
+// Search checks the candidates of the keyspace of the options with the
+// operand until one matches. The candidate is only valid during the call.
+//
+// The result is returned with the first error of the operand, the error of
+// the context once cancelled, or ErrStopped if the checkpoint is stopped,
+// the progress being saved to the checkpoint if any. Found is false if no
+// candidate matched.
+func Search(ctx context.Context, operand func(candidate []byte) (bool, error), options Options) (*Result, error) {
+	keyspace, err := options.keyspace()
+	if err != nil {
+		return nil, err
+	}
+	workers := options.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	start := time.Now()
+	state, err := execute(ctx, keyspace, workers, operand, options.Checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{
+		Workers:  state.status().Workers,
+		Duration: time.Since(start),
+	}
+	for _, tried := range result.Workers {
+		result.Tried += tried
+	}
+	solution, err := state.result()
+	switch {
+	case len(solution) > 0:
+		result.Found, result.Match = true, solution
+	case err != nil:
+		return result, err
+	case ctx.Err() != nil:
+		return result, ctx.Err()
+	case options.Checkpoint != nil && options.Checkpoint.stopped():
+		return result, ErrStopped
+	}
+	return result, nil
+}
@@ -0,0 +1,90 @@
+package algorithms
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	var mutex sync.Mutex
+	seen := make(map[string]bool)
+	result, err := Search(context.Background(), func(candidate []byte) (bool, error) {
+		mutex.Lock()
+		seen[string(candidate)] = true
+		mutex.Unlock()
+		return string(candidate) == "éa€", nil
+	}, Options{Charset: "aé€", MinLength: 2, MaxLength: 3, Workers: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Found || result.Match != "éa€" {
+		t.Errorf("expected éa€, got %+v", result)
+	}
+	if seen["a"] || seen["é"] || seen["€"] {
+		t.Error("expected the candidates from 2 characters")
+	}
+	if len(result.Workers) != 3 || result.Tried == 0 || result.Tried > 9+27 {
+		t.Errorf("unexpected statistics %+v", result)
+	}
+
+	// every candidate is checked without match
+	keyspace, _ := NewMaskKeyspace("?d?d")
+	result, err = Search(context.Background(), func([]byte) (bool, error) {
+		return false, nil
+	}, Options{Keyspace: keyspace, Workers: 2})
+	if err != nil || result.Found || result.Tried != 100 {
+		t.Errorf("expected 100 candidates without match, got %+v (%v)", result, err)
+	}
+	if _, err := Search(context.Background(), nil, Options{}); err == nil {
+		t.Error("expected an error without keyspace")
+	}
+}
+
+func TestSearchErrors(t *testing.T) {
+	keyspace, _ := NewMaskKeyspace("?d?d?d")
+	failure := errors.New("unreachable")
+	result, err := Search(context.Background(), func(candidate []byte) (bool, error) {
+		if string(candidate) == "042" {
+			return false, failure
+		}
+		return false, nil
+	}, Options{Keyspace: keyspace, Workers: 1})
+	if err != failure || result.Found || result.Tried != 42 {
+		t.Errorf("expected the operand error after 42 candidates, got %+v (%v)", result, err)
+	}
+
+	// a cancelled search saves its progress, resumed by the next one
+	path := filepath.Join(t.TempDir(), "search.checkpoint")
+	ctx, cancel := context.WithCancel(context.Background())
+	var mutex sync.Mutex
+	seen := make(map[string]int)
+	check := func(candidate []byte) (bool, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		seen[string(candidate)]++
+		if len(seen) == 300 {
+			cancel()
+		}
+		return false, nil
+	}
+	result, err = Search(ctx, check, Options{Keyspace: keyspace, Workers: 2, Checkpoint: NewCheckpoint(path, 0)})
+	if err != context.Canceled || result.Tried >= keyspace.Size() {
+		t.Fatalf("expected the search to be cancelled, got %+v (%v)", result, err)
+	}
+	first := result.Tried
+	result, err = Search(context.Background(), check, Options{Keyspace: keyspace, Workers: 2, Checkpoint: NewCheckpoint(path, 0)})
+	if err != nil || result.Found || first+result.Tried != keyspace.Size() {
+		t.Errorf("expected the resumed search to check the rest, got %+v (%v)", result, err)
+	}
+	if uint64(len(seen)) != keyspace.Size() {
+		t.Errorf("expected %d candidates, got %d", keyspace.Size(), len(seen))
+	}
+	for candidate, count := range seen {
+		if count != 1 {
+			t.Errorf("%q checked %d times", candidate, count)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package algorithms
+
+import (
+	"math"
+	"os"
+	"strings"
+)
+
+// maskClasses are the charsets of the masks of the passwords, by order of
+// preference.
+var maskClasses = []byte{'l', 'u', 'd', 's'}
+
+// Strength is how hard a password is to guess under the attack models of
+// the package.
+type Strength struct {
+	Password string
+	// Mask is the mask of the character classes of the password, ?b
+	// standing for the bytes out of ?l, ?u, ?d and ?s.
+	Mask string
+	// Entropy is the number of bits of a random password of the same
+	// length over the union of its classes.
+	Entropy float64
+	// BruteForce is the number of candidates checked by a brute force over
+	// a charset until the password, from 1 character, +Inf if the password
+	// is out of the charset.
+	BruteForce float64
+	// MaskGuesses is the number of candidates checked by the attack of
+	// Mask until the password.
+	MaskGuesses float64
+	// Dictionary is the number of candidates checked by a dictionary
+	// attack until the password, 0 if it doesn't produce it.
+	Dictionary uint64
+}
+
+// PasswordMask returns the mask of the character classes of the password.
+func PasswordMask(password string) string {
+	var mask strings.Builder
+	for i := 0; i < len(password); i++ {
+		class := byte('b')
+		for _, c := range maskClasses {
+			if strings.IndexByte(MaskCharsets[c], password[i]) >= 0 {
+				class = c
+				break
+			}
+		}
+		mask.WriteByte('?')
+		mask.WriteByte(class)
+	}
+	return mask.String()
+}
+
+// rank returns the number of candidates of the keyspace of the charsets
+// from min characters up to the password included, as a float not to
+// overflow, +Inf if it is out of the keyspace.
+func rank(charsets []string, min int, password string) float64 {
+	if len(password) < min || len(password) > len(charsets) {
+		return math.Inf(1)
+	}
+	shorter := 0.0
+	for length := min; length < len(password); length++ {
+		count := 1.0
+		for _, charset := range charsets[:length] {
+			count *= float64(len(charset))
+		}
+		shorter += count
+	}
+	index := 0.0
+	for i := 0; i < len(password); i++ {
+		digit := strings.IndexByte(charsets[i], password[i])
+		if digit < 0 {
+			return math.Inf(1)
+		}
+		index = index*float64(len(charsets[i])) + float64(digit)
+	}
+	return shorter + index + 1
+}
+
+// NewStrength measures the password under the brute force over the
+// charset and the attack of its own mask, the one of an attacker who knows
+// its shape. Dictionary is left to DictionaryGuesses.
+func NewStrength(password, charset string) *Strength {
+	s := &Strength{
+		Password: password,
+		Mask:     PasswordMask(password),
+	}
+	charsets := make([]string, len(password))
+	for i := range charsets {
+		charsets[i] = charset
+	}
+	s.BruteForce = rank(charsets, 1, password)
+	pool := 0
+	used := make(map[byte]bool)
+	for i := 0; i < len(password); i++ {
+		class := s.Mask[2*i+1]
+		charsets[i] = MaskCharsets[class]
+		if !used[class] {
+			used[class] = true
+			pool += len(MaskCharsets[class])
+		}
+	}
+	s.MaskGuesses = rank(charsets, len(password), password)
+	if pool > 0 {
+		s.Entropy = float64(len(password)) * math.Log2(float64(pool))
+	}
+	return s
+}
+
+// DictionaryGuesses returns the number of candidates the dictionary attack
+// of the files and rules checks until each of the passwords it produces,
+// in the order of Dictionary, stopping after limit candidates unless 0.
+func DictionaryGuesses(paths []string, rules []*Rule, passwords []string, limit uint64) (map[string]uint64, error) {
+	pending := make(map[string]bool, len(passwords))
+	for _, password := range passwords {
+		pending[password] = true
+	}
+	guesses := make(map[string]uint64)
+	count := uint64(0)
+	emit := func(candidate string) bool {
+		count++
+		if pending[candidate] {
+			delete(pending, candidate)
+			guesses[candidate] = count
+		}
+		return len(pending) > 0 && (limit == 0 || count < limit)
+	}
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		more, err := ReadWords(file, func(word string) bool {
+			return Mangle(word, rules, emit)
+		})
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			break
+		}
+	}
+	return guesses, nil
+}
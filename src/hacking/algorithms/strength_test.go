@@ -0,0 +1,61 @@
+package algorithms
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStrength(t *testing.T) {
+	if mask := PasswordMask("Ab1!é"); mask != "?u?l?d?s?b?b" {
+		t.Errorf("unexpected mask %q", mask)
+	}
+
+	// the guesses are the ones of the brute force and mask keyspaces
+	keyspace, _ := NewKeyspace("abc1", 1, 4)
+	masked, _ := NewMaskKeyspace("?l?l?d")
+	for _, password := range []string{"a", "cb1", "1111"} {
+		s := NewStrength(password, "abc1")
+		index, _ := keyspace.Index(password)
+		if s.BruteForce != float64(index+1) {
+			t.Errorf("%q: expected %d brute force guesses, got %g", password, index+1, s.BruteForce)
+		}
+	}
+	s := NewStrength("cb1", "abc1")
+	index, _ := masked.Index("cb1")
+	if s.Mask != "?l?l?d" || s.MaskGuesses != float64(index+1) {
+		t.Errorf("expected %d guesses of ?l?l?d, got %q %g", index+1, s.Mask, s.MaskGuesses)
+	}
+	if math.Abs(s.Entropy-3*math.Log2(36)) > 1e-9 {
+		t.Errorf("unexpected entropy %g", s.Entropy)
+	}
+	if s := NewStrength("abd", "abc1"); !math.IsInf(s.BruteForce, 1) {
+		t.Errorf("expected a password out of the charset never to be guessed, got %g", s.BruteForce)
+	}
+	if s := NewStrength("Tr0ub4dor&3xyzXYZ", MaskCharsets['a']); s.BruteForce < 1e30 || s.Entropy < 100 {
+		t.Errorf("expected a long password to be strong, got %+v", s)
+	}
+}
+
+func TestDictionaryGuesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte("hello\nsecret\nadmin\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rules, _ := ParseRules([]string{":", "c", "$1"})
+	guesses, err := DictionaryGuesses([]string{path}, rules, []string{"Secret", "admin1", "other"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(guesses) != 2 || guesses["Secret"] != 5 || guesses["admin1"] != 9 {
+		t.Errorf("unexpected guesses %v", guesses)
+	}
+	guesses, _ = DictionaryGuesses([]string{path}, rules, []string{"Secret", "admin1"}, 6)
+	if len(guesses) != 1 {
+		t.Errorf("expected the limit to stop the attack, got %v", guesses)
+	}
+	if _, err := DictionaryGuesses([]string{path + ".missing"}, rules, []string{"x"}, 0); err == nil {
+		t.Error("expected an error for a missing wordlist")
+	}
+}
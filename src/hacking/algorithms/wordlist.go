@@ -0,0 +1,115 @@
+package algorithms
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Mangle calls emit on every distinct mutation of the word by the rules,
+// or on the word itself if there are no rules, until emit returns false.
+// It returns false if emit did.
+func Mangle(word string, rules []*Rule, emit func(string) bool) bool {
+	if len(rules) == 0 {
+		return emit(word)
+	}
+	seen := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		candidate := rule.Apply(word)
+		if len(candidate) == 0 || seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+		if !emit(candidate) {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadWords calls emit on every word of the reader, one per line, until
+// emit returns false. It returns false if emit did.
+func ReadWords(r io.Reader, emit func(string) bool) (bool, error) {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		word := strings.TrimRight(line, "\r\n")
+		if len(word) > 0 && !emit(word) {
+			return false, nil
+		}
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+// Dictionary launch a dictionary attack: the words of the files are
+// streamed, mangled by every rule and checked by the operand using cpu
+// workers. It returns the matching candidate, or ErrNotFound.
+func Dictionary(paths []string, rules []*Rule, cpu int, operand func(string) bool) (string, error) {
+	solution, err := stream(cpu, operand, func(emit func(string) bool) error {
+		for _, path := range paths {
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			more, err := ReadWords(file, func(word string) bool {
+				return Mangle(word, rules, emit)
+			})
+			file.Close()
+			if err != nil || !more {
+				return err
+			}
+		}
+		return nil
+	})
+	if len(solution) > 0 || err != nil {
+		return solution, err
+	}
+	return "", ErrNotFound
+}
+
+// stream checks the candidates emitted by generate with the operand using
+// cpu workers, until one matches. It returns the matching candidate, if
+// any, or the error of generate.
+func stream(cpu int, operand func(string) bool, generate func(emit func(string) bool) error) (string, error) {
+	candidates := make(chan string, 1024*cpu)
+	done := make(chan struct{})
+	var once sync.Once
+	var solution string
+	var quit sync.WaitGroup
+	for i := 0; i < cpu; i++ {
+		quit.Add(1)
+		go func() {
+			defer quit.Done()
+			for candidate := range candidates {
+				if operand(candidate) {
+					once.Do(func() {
+						solution = candidate
+						close(done)
+					})
+					return
+				}
+			}
+		}()
+	}
+	err := generate(func(candidate string) bool {
+		select {
+		case candidates <- candidate:
+			return true
+		case <-done:
+			return false
+		}
+	})
+	close(candidates)
+	quit.Wait()
+	if len(solution) > 0 {
+		return solution, nil
+	}
+	return "", err
+}
@@ -0,0 +1,57 @@
+package algorithms
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestDictionary(t *testing.T) {
+	directory := t.TempDir()
+	first := filepath.Join(directory, "first.txt")
+	second := filepath.Join(directory, "second.txt")
+	if err := os.WriteFile(first, []byte("hello\r\nworld\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte("secret\nadmin"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := ParseRules([]string{":", "c", "c$1", "l"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mutex sync.Mutex
+	seen := make(map[string]int)
+	_, err = Dictionary([]string{first, second}, rules, 4, func(candidate string) bool {
+		mutex.Lock()
+		seen[candidate]++
+		mutex.Unlock()
+		return false
+	})
+	if err == nil {
+		t.Error("expected the dictionary attack to fail")
+	}
+	for _, expected := range []string{"hello", "Hello", "Hello1", "world", "Secret", "admin", "Admin1"} {
+		if seen[expected] != 1 {
+			t.Errorf("expected %q once, got %d", expected, seen[expected])
+		}
+	}
+	if len(seen) != 12 {
+		t.Errorf("expected 12 candidates, got %d: %v", len(seen), seen)
+	}
+
+	found, err := Dictionary([]string{first, second}, rules, 4, func(candidate string) bool {
+		return candidate == "Secret1"
+	})
+	if err != nil || found != "Secret1" {
+		t.Errorf("expected to find Secret1, got %q (%v)", found, err)
+	}
+	if _, err := Dictionary([]string{first}, nil, 1, func(string) bool { return false }); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound without match, got %v", err)
+	}
+	if _, err := Dictionary([]string{filepath.Join(directory, "missing")}, nil, 1, func(string) bool { return false }); err == nil || err == ErrNotFound {
+		t.Error("expected an error for a missing wordlist")
+	}
+}
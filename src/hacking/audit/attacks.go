@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"hacking/algorithms"
+	"hacking/hashes"
+	"log"
+	"sync/atomic"
+)
+
+// attacks are the built-in attacks of the audit, each checking at most
+// budget candidates.
+type attacks struct {
+	wordlists []string
+	rules     []*algorithms.Rule
+	charset   string
+	masks     []string
+	keyspaces []*algorithms.Keyspace
+	budget    uint64
+	cpu       int
+}
+
+// measured is an audited account.
+type measured struct {
+	*account
+	strength *algorithms.Strength
+	// falls are the attacks finding the password within the budget.
+	falls []string
+}
+
+// bruteKeyspace returns the keyspace of the brute force over the charset,
+// long enough for the budget.
+func (a *attacks) bruteKeyspace() (*algorithms.Keyspace, error) {
+	keyspace, err := algorithms.NewKeyspace(a.charset, 1, 1)
+	for length := 2; err == nil && keyspace.Size() < a.budget; length++ {
+		next, e := algorithms.NewKeyspace(a.charset, 1, length)
+		if e != nil {
+			// too large, the budget is the whole keyspace
+			break
+		}
+		keyspace = next
+	}
+	return keyspace, err
+}
+
+// search checks the first candidates of the keyspace within the budget
+// with the operand.
+func (a *attacks) search(keyspace *algorithms.Keyspace, operand func(string) bool) error {
+	end := min(a.budget, keyspace.Size())
+	checkpoint := algorithms.NewCheckpoint("", 0)
+	checkpoint.Token = algorithms.NewProgress(keyspace, [][2]uint64{{0, end}}).Token()
+	_, err := algorithms.Search(context.Background(), func(candidate []byte) (bool, error) {
+		return operand(string(candidate)), nil
+	}, algorithms.Options{Keyspace: keyspace, Workers: a.cpu, Checkpoint: checkpoint})
+	return err
+}
+
+// crack runs the attacks against the targets until they are all cracked.
+func (a *attacks) crack(targets *hashes.Targets) {
+	check := func(candidate string) bool {
+		cracked, err := targets.Check(candidate)
+		if err != nil {
+			log.Println("potfile:", err)
+		}
+		for _, target := range cracked {
+			log.Println("cracked", target.Hash)
+		}
+		return targets.Remaining() == 0
+	}
+	if targets.Remaining() > 0 && len(a.wordlists) > 0 {
+		log.Println("running dictionary attack...")
+		tried := uint64(0)
+		_, err := algorithms.Dictionary(a.wordlists, a.rules, a.cpu, func(candidate string) bool {
+			if atomic.AddUint64(&tried, 1) > a.budget {
+				return true
+			}
+			return check(candidate)
+		})
+		// not finding a candidate is the end of the attack
+		if err != nil && err != algorithms.ErrNotFound {
+			log.Println(err)
+		}
+	}
+	if targets.Remaining() > 0 {
+		log.Println("running brute force...")
+		keyspace, err := a.bruteKeyspace()
+		if err == nil {
+			err = a.search(keyspace, check)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	for i, mask := range a.masks {
+		if targets.Remaining() == 0 {
+			break
+		}
+		log.Println("running mask attack", mask)
+		if err := a.search(a.keyspaces[i], check); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// measure measures the known passwords of the accounts and finds the
+// attacks they fall to.
+func (a *attacks) measure(accounts []*account) ([]*measured, error) {
+	var passwords []string
+	for _, acc := range accounts {
+		if acc.known {
+			passwords = append(passwords, acc.password)
+		}
+	}
+	var guesses map[string]uint64
+	if len(a.wordlists) > 0 {
+		var err error
+		guesses, err = algorithms.DictionaryGuesses(a.wordlists, a.rules, passwords, a.budget)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var results []*measured
+	for _, acc := range accounts {
+		m := &measured{account: acc}
+		results = append(results, m)
+		if !acc.known {
+			continue
+		}
+		m.strength = algorithms.NewStrength(acc.password, a.charset)
+		m.strength.Dictionary = guesses[acc.password]
+		if m.strength.Dictionary > 0 {
+			m.falls = append(m.falls, "dictionary")
+		}
+		if m.strength.BruteForce <= float64(a.budget) {
+			m.falls = append(m.falls, "brute force")
+		}
+		for i, keyspace := range a.keyspaces {
+			if index, err := keyspace.Index(acc.password); err == nil && index < a.budget {
+				m.falls = append(m.falls, a.masks[i])
+			}
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"hacking/algorithms"
+	"hacking/hashes"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+)
+
+const (
+	// commonMasks are shapes of passwords meeting the usual policies.
+	commonMasks = "?u?l?l?l?l?l?d?d,?u?l?l?l?l?l?l?d,?u?l?l?l?l?l?d?s,?l?l?l?l?l?l?d?d,?d?d?d?d?d?d?d?d"
+)
+
+func main() {
+	flag.Usage = func() {
+		// http://patorjk.com/software/taag/#p=display&f=Big
+		fmt.Fprintf(os.Stderr, ""+
+			`audit [OPTIONS]
+
+-------------------------------
+                    _ _ _
+     /\            | (_) |
+    /  \  _   _  __| |_| |_
+   / /\ \| | | |/ _`+"`"+` | | __|
+  / ____ \ |_| | (_| | | |_
+ /_/    \_\__,_|\__,_|_|\__|
+
+-------------------------------
+
+Usage:
+
+  audit -passwords passwords.txt -w rockyou.txt
+
+measures the passwords of passwords.txt (one per line): the entropy of
+their length and character classes, and the candidates checked until
+them by a brute force over -s, by the attack of their own mask and by a
+dictionary attack over -w with the rules of -r (default rules if none).
+A password falls when the dictionary attack, the brute force or one of
+the masks of -m finds it within -budget candidates.
+
+  audit -hashes hashes.txt -w rockyou.txt -budget 1000000
+
+runs these attacks against the hashes of hashes.txt ([user:]hash per
+line, the types of the brute command), each within 1 million candidates,
+then measures the passwords cracked. The passwords of -potfile are
+measured without cracking.
+
+The report is written to stdout, the passwords being hidden unless -show
+is set.
+
+Options:
+`)
+		flag.PrintDefaults()
+	}
+	passwordsPath := flag.String("passwords", "", "file of the passwords to audit, one per line")
+	hashesPath := flag.String("hashes", "", "file of the hashes to audit, one per line")
+	kind := flag.String("type", "auto", "hash type: auto, md5, sha1, sha256, sha512, ntlm, bcrypt, pbkdf2 or argon2")
+	potfilePath := flag.String("potfile", "", "file of the cracked hashes, empty to disable")
+	wordlists := flag.String("w", "", "comma-separated wordlist files of the dictionary attack")
+	rulesPath := flag.String("r", "", "mangling rules file of the dictionary attack, default rules if empty")
+	charset := flag.String("s", algorithms.ASCIICharset, "charset of the brute force, default to ascii")
+	masks := flag.String("m", commonMasks, "comma-separated masks of the mask attacks, empty to disable")
+	budget := flag.Uint64("budget", 100000000, "number of candidates of every attack")
+	cpu := flag.Int("c", runtime.NumCPU(), "number of cpu used to crack the hashes")
+	show := flag.Bool("show", false, "show the passwords in the report")
+	flag.Parse()
+	if *cpu <= 0 {
+		*cpu = 1
+	}
+	if *budget == 0 {
+		log.Fatalf("the budget (-budget) must be strictly positive")
+	}
+
+	a := &attacks{
+		charset: *charset,
+		budget:  *budget,
+		cpu:     *cpu,
+		rules:   makeRules(*rulesPath),
+	}
+	if len(*wordlists) > 0 {
+		a.wordlists = strings.Split(*wordlists, ",")
+		for _, path := range a.wordlists {
+			if _, err := os.Stat(path); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+	if len(*masks) > 0 {
+		for _, mask := range strings.Split(*masks, ",") {
+			keyspace, err := algorithms.NewMaskKeyspace(mask)
+			if err != nil {
+				log.Fatal(err)
+			}
+			a.masks = append(a.masks, mask)
+			a.keyspaces = append(a.keyspaces, keyspace)
+		}
+	}
+	log.Println("wordlists (-w)", *wordlists)
+	log.Println("rules (-r)", len(a.rules))
+	log.Println("charset (-s)", *charset)
+	log.Println("masks (-m)", *masks)
+	log.Println("budget (-budget)", *budget, "candidates per attack")
+
+	var accounts []*account
+	if len(*passwordsPath) > 0 {
+		accounts = readPasswords(*passwordsPath)
+	} else if len(*hashesPath) > 0 {
+		accounts = crackHashes(*hashesPath, *kind, *potfilePath, a)
+	} else {
+		log.Fatalf("nothing to audit, -passwords or -hashes is needed")
+	}
+	measured, err := a.measure(accounts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	report(os.Stdout, measured, a, *show)
+}
+
+// account is a password to audit, unknown if its hash isn't cracked.
+type account struct {
+	name     string
+	password string
+	known    bool
+}
+
+func readPasswords(path string) []*account {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+	var accounts []*account
+	_, err = algorithms.ReadWords(file, func(password string) bool {
+		accounts = append(accounts, &account{
+			name:     fmt.Sprintf("#%d", len(accounts)+1),
+			password: password,
+			known:    true,
+		})
+		return true
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("passwords (-passwords)", path, len(accounts))
+	return accounts
+}
+
+// crackHashes returns the accounts of the hashes of the file, the ones
+// cracked by the attacks or found in the potfile being known.
+func crackHashes(path, kind, potfilePath string, a *attacks) []*account {
+	k, err := hashes.ParseKind(kind)
+	if err != nil {
+		log.Fatal(err)
+	}
+	targets, err := hashes.LoadTargets(path, k)
+	if err != nil {
+		log.Fatal(err)
+	}
+	total := len(targets.Targets())
+	log.Println("hashes (-hashes)", path, total)
+	if len(potfilePath) > 0 {
+		potfile, err := hashes.OpenPotfile(potfilePath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer potfile.Close()
+		targets.SetPotfile(potfile)
+		log.Println("potfile (-potfile)", potfilePath, total-targets.Remaining(), "already cracked")
+	}
+	log.Println("cpu (-c)", a.cpu)
+	a.crack(targets)
+	log.Println("cracked", total-targets.Remaining(), "of", total)
+
+	var accounts []*account
+	for _, target := range targets.Targets() {
+		name := target.User
+		if len(name) == 0 {
+			name = target.Hash.String()
+		}
+		password, known := target.Password()
+		accounts = append(accounts, &account{name: name, password: password, known: known})
+	}
+	return accounts
+}
+
+func makeRules(path string) []*algorithms.Rule {
+	rules, err := algorithms.ParseRules(algorithms.DefaultRules)
+	if len(path) > 0 {
+		rules, err = algorithms.LoadRules(path)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	return rules
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// formatGuesses formats a number of candidates, - if never reached.
+func formatGuesses(guesses float64) string {
+	if guesses <= 0 || math.IsInf(guesses, 1) {
+		return "-"
+	}
+	if guesses < 1e6 {
+		return fmt.Sprintf("%.0f", guesses)
+	}
+	return fmt.Sprintf("%.2g", guesses)
+}
+
+// report writes a line per account and the summary of the audit.
+func report(w io.Writer, results []*measured, a *attacks, show bool) {
+	table := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	header := "ACCOUNT\tLENGTH\tMASK\tENTROPY\tBRUTE FORCE\tOWN MASK\tDICTIONARY\tFALLS TO"
+	if show {
+		header = "ACCOUNT\tPASSWORD\tLENGTH\tMASK\tENTROPY\tBRUTE FORCE\tOWN MASK\tDICTIONARY\tFALLS TO"
+	}
+	fmt.Fprintln(table, header)
+	var entropies []float64
+	fallen, unknown := 0, 0
+	falls := make(map[string]int)
+	for _, m := range results {
+		fmt.Fprint(table, m.name, "\t")
+		if show {
+			fmt.Fprint(table, m.password, "\t")
+		}
+		if m.strength == nil {
+			unknown++
+			fmt.Fprintln(table, "-\t-\t-\t-\t-\t-\tnot cracked")
+			continue
+		}
+		s := m.strength
+		entropies = append(entropies, s.Entropy)
+		attacks := "none"
+		if len(m.falls) > 0 {
+			fallen++
+			attacks = strings.Join(m.falls, ", ")
+			for _, attack := range m.falls {
+				falls[attack]++
+			}
+		}
+		fmt.Fprintf(table, "%d\t%s\t%.1f bits\t%s\t%s\t%s\t%s\n", len(s.Password), s.Mask, s.Entropy,
+			formatGuesses(s.BruteForce), formatGuesses(s.MaskGuesses), formatGuesses(float64(s.Dictionary)), attacks)
+	}
+	table.Flush()
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%d passwords audited, %d fall within %d candidates per attack\n", len(results), fallen, a.budget)
+	if unknown > 0 {
+		fmt.Fprintf(w, "%d hashes not cracked\n", unknown)
+	}
+	names := append([]string{"dictionary", "brute force"}, a.masks...)
+	for _, name := range names {
+		if falls[name] > 0 {
+			fmt.Fprintf(w, "  %s: %d\n", name, falls[name])
+		}
+	}
+	if len(entropies) > 0 {
+		sort.Float64s(entropies)
+		fmt.Fprintf(w, "entropy: min %.1f bits, median %.1f bits, max %.1f bits\n",
+			entropies[0], entropies[len(entropies)/2], entropies[len(entropies)-1])
+	}
+}
@@ -4,13 +4,14 @@ import (
 	"flag"
 	"fmt"
 	"hacking/algorithms"
+	"hacking/login"
 	"log"
 	"os"
+	"os/signal"
+	"regexp"
 	"runtime"
-)
-
-const (
-	asciiCharset = " !\"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~."
+	"strings"
+	"time"
 )
 
 func main() {
@@ -38,32 +39,292 @@ starts "brute.exe"
  - using 8 cpu
  - using a charset composed with the "abcd" characters.
 
+The status (candidates per second per worker, hashes per second, part of
+the keyspace done, ETA, current length) is logged every 10 seconds (-stats), on Enter and
+on SIGUSR1, and written as JSON lines to -stats-json for dashboards.
+Without anything to crack, the candidates are only counted, or written
+to stdout with -stdout.
+
+The progress is saved every minute to brute.checkpoint (-checkpoint)
+and the brute force resumes automatically from it. On Ctrl+C, the
+progress is saved and a resume token (-resume) is printed.
+
+  brute -m "?u?l?l?l?l?l?l?d?d"
+
+brute forces the passwords made of an uppercase letter, 6 lowercase
+letters and 2 digits. Masks use ?l, ?u, ?d, ?h, ?H, ?s, ?a and ?b, the
+custom charsets ?1 to ?4 (-1 to -4, such as -1 "?l?d") and literals.
+
+  brute -mode dict -w words.txt,names.txt -r rules.txt
+
+starts a dictionary attack over the words of words.txt and names.txt,
+mangled by the hashcat-style rules of rules.txt (default rules if none).
+
+  brute -m "?a?a?a?a?a?a?a" -serve :8080
+  brute -connect http://coordinator:8080 -hashes hashes.txt
+
+spreads the brute force over several machines: the coordinator hands
+out units of the keyspace (-unit candidates each) to the workers, which
+check them with their operand, hashes here. The unit of a worker silent
+for -timeout is handed out again from its last reported progress.
+
+  brute -mode markov -train rockyou.txt -l 10 -limit 100000000
+
+tries the 100 million passwords of up to 10 characters most likely
+according to a per-position Markov chain trained on rockyou.txt, by
+decreasing probability.
+
+  brute -m "?l?l?l?l?d" -hashes hashes.txt
+
+cracks the hashes of hashes.txt ([user:]hash per line, MD5, SHA-1,
+SHA-256, SHA-512, NTLM with -type ntlm, bcrypt, PBKDF2 or Argon2),
+saving the passwords found to brute.potfile (-potfile).
+
+  brute -mode dict -w words.txt -zip archive.zip
+
+recovers the password of a ZipCrypto or AES encrypted zip archive.
+
+  brute -m "?a?a?a?a?a" -pdf document.pdf
+
+recovers the user or owner password (-owner for the owner password
+only) of a PDF document encrypted with RC4 or AES, revisions 2 to 6.
+
+  brute -mode dict -w words.txt -url http://localhost:8080/login -user admin
+        -form "user=^USER^&pass=^PASS^" -csrf csrf_token -failure "invalid"
+
+tries the passwords against the login form of your own application,
+fetching the CSRF token of the form first. Without -form, they are sent
+with basic authentication. A login succeeds when the -status, -redirect,
+-success and -failure conditions set all hold, a form needing -failure,
+-success or -redirect, and by default on a 2xx status with basic
+authentication. Throttled requests (429, 5xx) are retried with a backoff.
+
 Options:
 `)
 		flag.PrintDefaults()
 	}
 	length := flag.Int("l", 8, "password length")
 	cpu := flag.Int("c", runtime.NumCPU(), "number of cpu (max cap set by your machine)")
-	charset := flag.String("s", asciiCharset, "charset, default to ascii")
+	charset := flag.String("s", algorithms.ASCIICharset, "charset, default to ascii")
+	checkpointPath := flag.String("checkpoint", "brute.checkpoint", "checkpoint file, empty to disable")
+	interval := flag.Duration("interval", time.Minute, "interval between two checkpoints")
+	resume := flag.String("resume", "", "resume token, used instead of the checkpoint file")
+	mask := flag.String("m", "", "mask of the passwords, used instead of -l and -s")
+	var customs [4]*string
+	for i := range customs {
+		customs[i] = flag.String(fmt.Sprint(i+1), "", fmt.Sprintf("custom charset ?%d of the mask", i+1))
+	}
+	mode := flag.String("mode", "brute", "attack mode: brute (brute force), dict (dictionary) or markov")
+	wordlists := flag.String("w", "", "comma-separated wordlist files for the dict mode")
+	rulesPath := flag.String("r", "", "mangling rules file for the dict mode, default rules if empty")
+	trainPath := flag.String("train", "", "password corpus training the model of the markov mode")
+	limit := flag.Uint64("limit", 0, "maximum number of candidates of the markov mode, unlimited if 0")
+	serve := flag.String("serve", "", "address to coordinate the brute force from, such as :8080")
+	connect := flag.String("connect", "", "URL of the coordinator to work for, the keyspace being its own")
+	unitSize := flag.Uint64("unit", 100000000, "number of candidates of the units of the coordinator")
+	timeout := flag.Duration("timeout", time.Minute, "duration after which the unit of a silent worker is reassigned")
+	hashesPath := flag.String("hashes", "", "file of the hashes to crack, one per line")
+	kind := flag.String("type", "auto", "hash type: auto, md5, sha1, sha256, sha512, ntlm, bcrypt, pbkdf2 or argon2")
+	potfilePath := flag.String("potfile", "brute.potfile", "file of the cracked hashes, empty to disable")
+	zipPath := flag.String("zip", "", "encrypted zip archive (ZipCrypto or AES) to crack instead of hashes")
+	pdfPath := flag.String("pdf", "", "encrypted PDF document to crack instead of hashes")
+	ownerOnly := flag.Bool("owner", false, "only check the owner password of the PDF document")
+	loginURL := flag.String("url", "", "HTTP login URL to attack instead of hashes, on authorized targets only")
+	username := flag.String("user", "admin", "username of the HTTP login")
+	form := flag.String("form", "", "URL-encoded login form, ^USER^ and ^PASS^ being replaced, basic authentication if empty")
+	csrfField := flag.String("csrf", "", "name of the CSRF token input of the login form")
+	csrfURL := flag.String("csrf-url", "", "page of the CSRF token, the login URL if empty")
+	status := flag.Int("status", 0, "HTTP status of a successful login")
+	redirect := flag.String("redirect", "", "part of the redirect location of a successful login")
+	success := flag.String("success", "", "regexp matching the body of a successful login")
+	failure := flag.String("failure", "", "regexp matching the body of a failed login")
+	rate := flag.Float64("rate", 0, "maximum number of HTTP requests per second, unlimited if 0")
+	stdout := flag.Bool("stdout", false, "write the candidates to stdout when there is nothing to crack")
+	statsInterval := flag.Duration("stats", 10*time.Second, "interval between two status reports, 0 for on demand only")
+	statsJSON := flag.String("stats-json", "", "file the status reports are written to as JSON lines, - for stdout")
 	flag.Parse()
-	if *length <= 0 {
+	if *cpu <= 0 {
+		*cpu = 1
+	}
+	t := candidates(*stdout)
+	if len(*hashesPath) > 0 {
+		if t = crack(*hashesPath, *kind, *potfilePath); t == nil {
+			return
+		}
+	} else if len(*zipPath) > 0 {
+		t = crackZip(*zipPath)
+	} else if len(*pdfPath) > 0 {
+		t = crackPDF(*pdfPath, *ownerOnly)
+	} else if len(*loginURL) > 0 {
+		l := login.NewLogin(*loginURL, *username)
+		l.Form = *form
+		l.CSRFField = *csrfField
+		l.CSRFURL = *csrfURL
+		l.Status = *status
+		l.Redirect = *redirect
+		l.Rate = *rate
+		l.Success = makeRegexp("-success", *success)
+		l.Failure = makeRegexp("-failure", *failure)
+		if err := l.Check(); err != nil {
+			log.Fatalf("%v (-failure, -success or -redirect)", err)
+		}
+		t = crackLogin(l)
+	}
+	if t.close != nil {
+		defer t.close()
+	}
+	r := &reporter{interval: *statsInterval, status: t.status, hashes: t.hashes}
+	if *statsJSON == "-" {
+		r.json = os.Stdout
+	} else if len(*statsJSON) > 0 {
+		file, err := os.Create(*statsJSON)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		r.json = file
+	}
+	operand := r.count(t.operand)
+	if len(*connect) > 0 {
+		work(*connect, *cpu, operand, r)
+		return
+	}
+	switch *mode {
+	case "brute":
+		var keyspace *algorithms.Keyspace
+		if len(*mask) > 0 {
+			custom := make([]string, len(customs))
+			for i, c := range customs {
+				custom[i] = *c
+			}
+			var err error
+			keyspace, err = algorithms.NewMaskKeyspace(*mask, custom...)
+			if err != nil {
+				log.Fatal(err)
+			}
+			log.Println("mask (-m)", *mask)
+		} else {
+			keyspace = makeKeyspace(*length, *charset)
+		}
+		if len(*serve) > 0 {
+			coordinate(keyspace, *serve, *unitSize, *timeout, r)
+			return
+		}
+		bruteForce(keyspace, *cpu, *checkpointPath, *interval, *resume, operand, r)
+	case "dict":
+		dictionary(*wordlists, *rulesPath, *cpu, operand, r)
+	case "markov":
+		markov(*trainPath, *length, *limit, *cpu, operand, r)
+	default:
+		log.Fatalf("unknown mode (-mode) %q", *mode)
+	}
+}
+
+func report(found string, err error) {
+	if err != nil {
+		log.Println(err.Error())
+	}
+	if len(found) > 0 {
+		log.Println("found:", found)
+	}
+}
+
+func dictionary(wordlists, rulesPath string, cpu int, operand func(string) bool, r *reporter) {
+	if len(wordlists) == 0 {
+		log.Fatalf("the dict mode needs at least one wordlist (-w)")
+	}
+	rules, err := algorithms.ParseRules(algorithms.DefaultRules)
+	if len(rulesPath) > 0 {
+		rules, err = algorithms.LoadRules(rulesPath)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("mode (-mode) dict")
+	log.Println("cpu (-c)", cpu)
+	log.Println("wordlists (-w)", wordlists)
+	log.Println("rules (-r)", len(rules))
+	log.Println("running dictionary attack...")
+	defer r.watch(nil, 0)()
+	report(algorithms.Dictionary(strings.Split(wordlists, ","), rules, cpu, operand))
+}
+
+func markov(trainPath string, length int, limit uint64, cpu int, operand func(string) bool, r *reporter) {
+	if len(trainPath) == 0 {
+		log.Fatalf("the markov mode needs a training corpus (-train)")
+	}
+	model, err := algorithms.LoadMarkov(trainPath, 1, length)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("mode (-mode) markov")
+	log.Println("cpu (-c)", cpu)
+	log.Println("corpus (-train)", trainPath, model.Words(), "passwords")
+	log.Println("length (-l)", length)
+	log.Println("limit (-limit)", limit)
+	log.Println("running markov attack...")
+	defer r.watch(nil, limit)()
+	report(algorithms.MarkovAttack(model, limit, cpu, operand))
+}
+
+func makeRegexp(name, expr string) *regexp.Regexp {
+	if len(expr) == 0 {
+		return nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		log.Fatalf("%s: %s", name, err)
+	}
+	return re
+}
+
+func makeKeyspace(length int, charset string) *algorithms.Keyspace {
+	if length <= 0 {
 		log.Fatalf("password length (-l) must be strictly positive")
 	}
-	if len(*charset) <= 0 {
+	if len(charset) <= 0 {
 		log.Fatalf("charset (-s) must contain at least one character")
 	}
-	if *cpu <= 0 {
-		*cpu = 1
+	log.Println("length (-l)", length)
+	log.Println("charset (-s)", charset)
+	keyspace, err := algorithms.NewKeyspace(charset, 1, length)
+	if err != nil {
+		log.Fatal(err)
 	}
-	log.Println("length (-l)", *length)
-	log.Println("cpu (-c)", *cpu)
-	log.Println("charset (-s)", *charset)
+	return keyspace
+}
+
+func bruteForce(keyspace *algorithms.Keyspace, cpu int, checkpointPath string, interval time.Duration, resume string, operand func(string) bool, r *reporter) {
+	log.Println("cpu (-c)", cpu)
+	log.Println("candidates", keyspace.Size())
+	checkpoint := algorithms.NewCheckpoint(checkpointPath, interval)
+	checkpoint.Token = resume
+	if len(resume) > 0 {
+		log.Println("resuming from token (-resume)")
+	} else if progress, err := algorithms.LoadProgress(checkpointPath); err == nil && progress != nil {
+		if progress.Matches(keyspace) {
+			log.Printf("resuming from %s after %d candidates", checkpointPath, progress.Tried)
+		} else {
+			log.Printf("%s is for other parameters, starting from the beginning", checkpointPath)
+			if err := os.Remove(checkpointPath); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		log.Println("stopping brute force...")
+		checkpoint.Stop()
+	}()
 	log.Println("running brute force...")
-	err := algorithms.BruteForce(*length, *cpu, *charset, func(candidate string) bool {
-		log.Println(candidate)
-		return false
-	})
-	if err != nil {
-		log.Println(err.Error())
+	defer r.watch(checkpoint.Status, keyspace.Size())()
+	found, err := algorithms.BruteForceKeyspace(keyspace, cpu, operand, checkpoint)
+	if err == algorithms.ErrStopped {
+		progress := checkpoint.Progress()
+		log.Printf("stopped after %d candidates, resume token (-resume): %s", progress.Tried, progress.Token())
+		return
 	}
+	report(found, err)
 }
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hacking/algorithms"
+	"hacking/distributed"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// linger is how long the coordinator keeps answering once the brute force
+// is over, so that the workers learn it at their next heartbeat.
+const linger = 15 * time.Second
+
+// coordinate hands out the keyspace to the workers in units of size
+// candidates on the address and reports the matching candidate.
+func coordinate(keyspace *algorithms.Keyspace, addr string, size uint64, timeout time.Duration, r *reporter) {
+	c := distributed.NewCoordinator(keyspace, size)
+	c.Timeout = timeout
+	_, _, total, _ := c.Status()
+	log.Println("coordinator (-serve)", addr)
+	log.Println("candidates", keyspace.Size())
+	log.Println("units (-unit)", total, "of", size, "candidates")
+	log.Println("worker timeout (-timeout)", timeout)
+	server := &http.Server{Addr: addr, Handler: c}
+	go func() {
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		log.Println("stopping the workers...")
+		c.Stop()
+	}()
+	r.status = func() string {
+		_, done, total, workers := c.Status()
+		return fmt.Sprintf("%d/%d units, %d workers", done, total, workers)
+	}
+	stop := r.watch(func() *algorithms.Status {
+		tried, _, _, _ := c.Status()
+		return &algorithms.Status{Size: keyspace.Size(), Tried: tried}
+	}, keyspace.Size())
+	log.Println("waiting for workers...")
+	found, err := c.Wait()
+	report(found, err)
+	stop()
+	log.Println("waiting for the workers to stop...")
+	time.Sleep(linger)
+	server.Shutdown(context.Background())
+}
+
+// work checks the units of the coordinator at the URL with the operand.
+func work(url string, cpu int, operand func(string) bool, r *reporter) {
+	w := distributed.NewWorker(url, cpu, operand)
+	log.Println("coordinator (-connect)", url)
+	log.Println("worker", w.Name)
+	log.Println("cpu (-c)", cpu)
+	stop := r.watch(nil, 0)
+	found, err := w.Run()
+	log.Println("units checked", w.Units())
+	report(found, err)
+	stop()
+}
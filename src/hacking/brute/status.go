@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"hacking/algorithms"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// statusLine is a line of the JSON status stream.
+type statusLine struct {
+	Time    time.Time `json:"time"`
+	Elapsed float64   `json:"elapsed"`
+	Tried   uint64    `json:"tried"`
+	Rate    float64   `json:"rate"`
+	Workers []float64 `json:"workers,omitempty"`
+	Size    uint64    `json:"size,omitempty"`
+	Percent float64   `json:"percent,omitempty"`
+	ETA     float64   `json:"eta,omitempty"`
+	Length  int       `json:"length,omitempty"`
+	Status  string    `json:"status,omitempty"`
+	Final   bool      `json:"final,omitempty"`
+
+	// Hashes and HashRate are the hashes computed and their speed, in
+	// hashes per second, when cracking hashes.
+	Hashes   uint64  `json:"hashes,omitempty"`
+	HashRate float64 `json:"hash_rate,omitempty"`
+}
+
+// reporter logs the status of an attack regularly, on demand, and as JSON
+// lines if json isn't nil: the candidates checked, the speed of every
+// worker, the hashes per second, the part of the keyspace done, the ETA
+// and the current length.
+type reporter struct {
+	interval time.Duration
+	json     io.Writer
+	// status is the status of the target, such as the cracked hashes.
+	status func() string
+	// hashes returns the number of hashes computed, if any.
+	hashes func() uint64
+	// live is the live status of the attack, if known, size being its
+	// number of candidates otherwise, 0 if unknown.
+	live func() *algorithms.Status
+	size uint64
+
+	tried       uint64
+	start       time.Time
+	mutex       sync.Mutex
+	lastTime    time.Time
+	lastDone    uint64
+	lastWorkers []uint64
+	lastHashes  uint64
+}
+
+// count returns the operand counting the candidates checked.
+func (r *reporter) count(operand func(string) bool) func(string) bool {
+	return func(candidate string) bool {
+		atomic.AddUint64(&r.tried, 1)
+		return operand(candidate)
+	}
+}
+
+// watch reports the status of the attack until the returned function is
+// called, which reports the final status.
+func (r *reporter) watch(live func() *algorithms.Status, size uint64) func() {
+	r.live, r.size = live, size
+	r.start = time.Now()
+	r.lastTime = r.start
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	demand := make(chan os.Signal, 1)
+	if signals := statusSignals(); len(signals) > 0 {
+		signal.Notify(demand, signals...)
+	}
+	// Enter prints the status when run from a terminal
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		go func() {
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				select {
+				case demand <- os.Interrupt:
+				default:
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(done)
+		var tick <-chan time.Time
+		if r.interval > 0 {
+			ticker := time.NewTicker(r.interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for {
+			select {
+			case <-stop:
+				return
+			case <-tick:
+				r.report(false)
+			case <-demand:
+				r.report(false)
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+		signal.Stop(demand)
+		r.report(true)
+	}
+}
+
+// report logs the status, the speeds being the ones since the previous
+// report, or since the start for the final one.
+func (r *reporter) report(final bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	now := time.Now()
+	line := statusLine{
+		Time:    now,
+		Elapsed: now.Sub(r.start).Seconds(),
+		Tried:   atomic.LoadUint64(&r.tried),
+		Size:    r.size,
+		Final:   final,
+	}
+	// done is the number of candidates checked since the start, the ones
+	// before a resume excluded
+	done := line.Tried
+	var live *algorithms.Status
+	if r.live != nil {
+		live = r.live()
+	}
+	var workers []uint64
+	if live != nil {
+		line.Tried, line.Size, line.Length = live.Tried, live.Size, live.Length
+		done, workers = live.Tried, live.Workers
+		if len(workers) > 0 {
+			done = 0
+			for _, tried := range workers {
+				done += tried
+			}
+		}
+	}
+	if r.hashes != nil {
+		line.Hashes = r.hashes()
+	}
+	since, last, lastWorkers, lastHashes := r.lastTime, r.lastDone, r.lastWorkers, r.lastHashes
+	if final || done < last {
+		since, last, lastWorkers, lastHashes = r.start, 0, nil, 0
+	}
+	if elapsed := now.Sub(since).Seconds(); elapsed > 0 {
+		line.Rate = float64(done-last) / elapsed
+		line.HashRate = float64(line.Hashes-lastHashes) / elapsed
+		for i, tried := range workers {
+			if i < len(lastWorkers) {
+				tried -= lastWorkers[i]
+			}
+			line.Workers = append(line.Workers, float64(tried)/elapsed)
+		}
+	}
+	if line.Size > 0 {
+		line.Percent = 100 * float64(line.Tried) / float64(line.Size)
+		if line.Rate > 0 && line.Tried < line.Size && !final {
+			line.ETA = float64(line.Size-line.Tried) / line.Rate
+		}
+	}
+	if r.status != nil {
+		line.Status = r.status()
+	}
+	r.lastTime, r.lastDone, r.lastWorkers, r.lastHashes = now, done, workers, line.Hashes
+
+	text := fmt.Sprintf("%d candidates in %s, %.0f c/s", line.Tried, now.Sub(r.start).Round(time.Second), line.Rate)
+	if len(line.Workers) > 1 {
+		rates := make([]string, len(line.Workers))
+		for i, rate := range line.Workers {
+			rates[i] = fmt.Sprintf("%.0f", rate)
+		}
+		text += fmt.Sprintf(" (workers %s)", strings.Join(rates, " "))
+	}
+	if r.hashes != nil {
+		text += fmt.Sprintf(", %.0f H/s", line.HashRate)
+	}
+	if line.Size > 0 {
+		text += fmt.Sprintf(", %.2f%% done", line.Percent)
+	}
+	if line.ETA > 0 {
+		text += ", ETA " + formatETA(line.ETA)
+	}
+	if line.Length > 0 {
+		text += fmt.Sprintf(", length %d", line.Length)
+	}
+	if len(line.Status) > 0 {
+		text += ", " + line.Status
+	}
+	log.Println(text)
+	if r.json != nil {
+		content, _ := json.Marshal(line)
+		r.json.Write(append(content, '\n'))
+	}
+}
+
+// formatETA formats a duration in seconds, the ones over a century being
+// approximated.
+func formatETA(seconds float64) string {
+	const century = 100 * 365 * 24 * 3600
+	if seconds >= century {
+		return fmt.Sprintf("%.3g centuries", seconds/century)
+	}
+	return (time.Duration(seconds) * time.Second).Round(time.Second).String()
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// statusSignals returns the signals printing the status.
+func statusSignals() []os.Signal {
+	return []os.Signal{syscall.SIGUSR1}
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// statusSignals returns the signals printing the status, none on Windows
+// where Enter does it.
+func statusSignals() []os.Signal {
+	return nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"hacking/encrypted"
+	"hacking/hashes"
+	"hacking/login"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// target checks the candidates of an attack.
+type target struct {
+	operand func(string) bool
+	// status describes what is cracked, nil if there is nothing to crack.
+	status func() string
+	// hashes returns the number of hashes computed, nil if not hashes.
+	hashes func() uint64
+	// close is called once the attack is over, if not nil.
+	close func()
+}
+
+// candidates returns the target writing the candidates to stdout, or only
+// counting them if stdout is false.
+func candidates(stdout bool) *target {
+	if !stdout {
+		return &target{operand: func(string) bool { return false }}
+	}
+	var mutex sync.Mutex
+	output := bufio.NewWriter(os.Stdout)
+	return &target{
+		operand: func(candidate string) bool {
+			mutex.Lock()
+			output.WriteString(candidate + "\n")
+			mutex.Unlock()
+			return false
+		},
+		close: func() {
+			mutex.Lock()
+			output.Flush()
+			mutex.Unlock()
+		},
+	}
+}
+
+// crack returns the target checking the candidates against the hashes of
+// the file, or nil if all the hashes are already in the potfile.
+func crack(path, kind, potfilePath string) *target {
+	k, err := hashes.ParseKind(kind)
+	if err != nil {
+		log.Fatal(err)
+	}
+	targets, err := hashes.LoadTargets(path, k)
+	if err != nil {
+		log.Fatal(err)
+	}
+	total := len(targets.Targets())
+	log.Println("hashes (-hashes)", path, total)
+	if len(potfilePath) > 0 {
+		potfile, err := hashes.OpenPotfile(potfilePath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		targets.SetPotfile(potfile)
+		log.Println("potfile (-potfile)", potfilePath, total-targets.Remaining(), "already cracked")
+	}
+	if targets.Remaining() == 0 {
+		log.Println("all the hashes are already cracked")
+		return nil
+	}
+
+	operand := func(candidate string) bool {
+		cracked, err := targets.Check(candidate)
+		if err != nil {
+			log.Println("potfile:", err)
+		}
+		for _, target := range cracked {
+			if len(target.User) > 0 {
+				log.Printf("cracked %s (%s): %s", target.Hash, target.User, candidate)
+			} else {
+				log.Printf("cracked %s: %s", target.Hash, candidate)
+			}
+		}
+		return len(cracked) > 0 && targets.Remaining() == 0
+	}
+	return &target{
+		operand: operand,
+		status: func() string {
+			return fmt.Sprintf("%d/%d cracked", total-targets.Remaining(), total)
+		},
+		hashes: targets.Hashes,
+	}
+}
+
+// crackZip returns the target checking the candidates against an
+// encrypted archive.
+func crackZip(path string) *target {
+	archive, err := encrypted.OpenZip(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("zip (-zip)", path)
+	t := crackFile(path, func(candidate string) string {
+		if archive.Verify(candidate) {
+			return "password"
+		}
+		return ""
+	})
+	t.close = func() {
+		archive.Close()
+	}
+	return t
+}
+
+// crackPDF returns the target checking the candidates against the user
+// and owner passwords of an encrypted PDF document, or only its owner
+// password.
+func crackPDF(path string, ownerOnly bool) *target {
+	pdf, err := encrypted.OpenPDF(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("pdf (-pdf)", path, "revision", pdf.Revision())
+	log.Println("owner only (-owner)", ownerOnly)
+	return crackFile(path, func(candidate string) string {
+		if !ownerOnly && pdf.VerifyUser(candidate) {
+			return "user password"
+		}
+		if pdf.VerifyOwner(candidate) {
+			return "owner password"
+		}
+		return ""
+	})
+}
+
+// crackLogin returns the target checking the candidates against an HTTP
+// login, which reports the failing requests once the attack is over.
+func crackLogin(l *login.Login) *target {
+	log.Println("login (-url, -user)", l)
+	if len(l.Form) > 0 {
+		log.Println("form (-form)", l.Form)
+	} else {
+		log.Println("basic authentication")
+	}
+	if l.Rate > 0 {
+		log.Println("rate (-rate)", l.Rate, "requests per second")
+	}
+	t := crackFile(l.String(), func(candidate string) string {
+		if l.Verify(candidate) {
+			return "password"
+		}
+		return ""
+	})
+	t.close = func() {
+		if errors, err := l.Errors(); errors > 0 {
+			log.Printf("%d candidates not checked after %d requests, last error: %s", errors, l.Requests(), err)
+		}
+	}
+	return t
+}
+
+// crackFile returns the target checking the candidates against an
+// encrypted file with verify, which names the password found if any.
+func crackFile(path string, verify func(string) string) *target {
+	found := int32(0)
+	return &target{
+		operand: func(candidate string) bool {
+			password := verify(candidate)
+			if len(password) == 0 {
+				return false
+			}
+			atomic.StoreInt32(&found, 1)
+			log.Printf("cracked %s %s: %s", path, password, candidate)
+			return true
+		},
+		status: func() string {
+			if atomic.LoadInt32(&found) != 0 {
+				return "cracked"
+			}
+			return "not cracked"
+		},
+	}
+}
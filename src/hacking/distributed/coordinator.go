@@ -0,0 +1,246 @@
+// Package distributed spreads a brute force over several machines: a
+// coordinator splits the keyspace into work units handed out over HTTP to
+// workers, which report their progress and the matching candidate.
+//
+// The protocol is made of JSON requests posted to the coordinator:
+//
+//	/work       a worker asks for a unit: 200 with the unit, 204 if none
+//	            is available yet, 410 once the brute force is over
+//	/heartbeat  a worker reports the spans of its unit left to check: 200
+//	            to go on, 409 if the unit was reassigned, 410 once over
+//	/result     a worker reports its unit checked, with the matching
+//	            candidate if any: 200 to go on, 409 if the unit was
+//	            reassigned, 400 if the candidate isn't in the unit, 410
+//	            once over
+package distributed
+
+import (
+	"encoding/json"
+	"fmt"
+	"hacking/algorithms"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// request is the body of the requests of the workers.
+type request struct {
+	Worker string `json:"worker"`
+	Unit   int    `json:"unit"`
+	// Remaining are the spans of the unit left to check, with /heartbeat.
+	Remaining [][2]uint64 `json:"remaining,omitempty"`
+	// Solution is the matching candidate, with /result.
+	Solution string `json:"solution,omitempty"`
+}
+
+// response is the body of the responses of the coordinator.
+type response struct {
+	Unit int `json:"unit,omitempty"`
+	// Token is the resume token of the unit.
+	Token string `json:"token,omitempty"`
+	// Solution is the matching candidate once the brute force is over.
+	Solution string `json:"solution,omitempty"`
+}
+
+// unit is a part of the keyspace checked by a worker.
+type unit struct {
+	// start and end are the indexes of the candidates of the unit, end
+	// excluded.
+	start     uint64
+	end       uint64
+	remaining [][2]uint64
+	worker    string
+	deadline  time.Time
+	done      bool
+}
+
+// Coordinator hands out the units of a keyspace to the workers. It is an
+// http.Handler.
+type Coordinator struct {
+	// Timeout is the duration after which the unit of a worker which
+	// didn't report is handed out again.
+	Timeout time.Duration
+
+	keyspace *algorithms.Keyspace
+	mutex    sync.Mutex
+	units    []*unit
+	workers  map[string]time.Time
+	solution string
+	over     bool
+	finished chan struct{}
+}
+
+// NewCoordinator creates a coordinator splitting the keyspace into units of
+// size candidates, reassigning the units of the workers silent for a
+// minute.
+func NewCoordinator(keyspace *algorithms.Keyspace, size uint64) *Coordinator {
+	if size == 0 {
+		size = 1
+	}
+	c := &Coordinator{
+		Timeout:  time.Minute,
+		keyspace: keyspace,
+		workers:  make(map[string]time.Time),
+		finished: make(chan struct{}),
+	}
+	for start := uint64(0); start < keyspace.Size(); {
+		end := keyspace.Size()
+		if end-start > size {
+			end = start + size
+		}
+		c.units = append(c.units, &unit{start: start, end: end, remaining: [][2]uint64{{start, end}}})
+		start = end
+	}
+	if len(c.units) == 0 {
+		c.finish("")
+	}
+	return c
+}
+
+// finish ends the brute force, the mutex being held.
+func (c *Coordinator) finish(solution string) {
+	if c.over {
+		return
+	}
+	c.over = true
+	c.solution = solution
+	close(c.finished)
+}
+
+// Wait waits for the end of the brute force and returns the matching
+// candidate, or algorithms.ErrNotFound.
+func (c *Coordinator) Wait() (string, error) {
+	<-c.finished
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(c.solution) == 0 {
+		return "", algorithms.ErrNotFound
+	}
+	return c.solution, nil
+}
+
+// Stop ends the brute force, the workers stopping at their next request.
+func (c *Coordinator) Stop() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.finish("")
+}
+
+// Status returns the number of candidates checked, of units checked out of
+// the total and of workers which reported within the timeout.
+func (c *Coordinator) Status() (tried uint64, done, total, workers int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	left := uint64(0)
+	for _, u := range c.units {
+		if u.done {
+			done++
+			continue
+		}
+		for _, span := range u.remaining {
+			left += span[1] - span[0]
+		}
+	}
+	now := time.Now()
+	for _, seen := range c.workers {
+		if now.Sub(seen) < c.Timeout {
+			workers++
+		}
+	}
+	return c.keyspace.Size() - left, done, len(c.units), workers
+}
+
+// assign returns a unit for the worker: an unassigned one or one whose
+// worker is silent, -1 if there is none.
+func (c *Coordinator) assign(worker string) int {
+	now := time.Now()
+	for i, u := range c.units {
+		if u.done || (len(u.worker) > 0 && now.Before(u.deadline)) {
+			continue
+		}
+		u.worker = worker
+		u.deadline = now.Add(c.Timeout)
+		return i
+	}
+	return -1
+}
+
+func (c *Coordinator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Worker) == 0 {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.workers[req.Worker] = time.Now()
+	if c.over {
+		reply(w, http.StatusGone, &response{Solution: c.solution})
+		return
+	}
+	if r.URL.Path == "/work" {
+		i := c.assign(req.Worker)
+		if i < 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		progress := algorithms.NewProgress(c.keyspace, c.units[i].remaining)
+		reply(w, http.StatusOK, &response{Unit: i + 1, Token: progress.Token()})
+		return
+	}
+	i := req.Unit - 1
+	if i < 0 || i >= len(c.units) {
+		http.Error(w, fmt.Sprintf("unknown unit %d", req.Unit), http.StatusBadRequest)
+		return
+	}
+	u := c.units[i]
+	switch r.URL.Path {
+	case "/heartbeat":
+		if u.done || u.worker != req.Worker {
+			reply(w, http.StatusConflict, &response{})
+			return
+		}
+		if req.Remaining != nil {
+			u.remaining = req.Remaining
+		}
+		u.deadline = time.Now().Add(c.Timeout)
+		reply(w, http.StatusOK, &response{})
+	case "/result":
+		if u.done || u.worker != req.Worker {
+			reply(w, http.StatusConflict, &response{})
+			return
+		}
+		if len(req.Solution) > 0 {
+			index, err := c.keyspace.Index(req.Solution)
+			if err != nil || index < u.start || index >= u.end {
+				http.Error(w, fmt.Sprintf("solution not in unit %d", req.Unit), http.StatusBadRequest)
+				return
+			}
+			c.finish(req.Solution)
+			reply(w, http.StatusGone, &response{Solution: c.solution})
+			return
+		}
+		u.done = true
+		u.remaining = nil
+		for _, u := range c.units {
+			if !u.done {
+				reply(w, http.StatusOK, &response{})
+				return
+			}
+		}
+		c.finish("")
+		reply(w, http.StatusGone, &response{})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func reply(w http.ResponseWriter, status int, body *response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
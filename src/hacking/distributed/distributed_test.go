@@ -0,0 +1,183 @@
+package distributed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hacking/algorithms"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// launch runs the workers on the coordinator and returns what they found.
+func launch(t *testing.T, c *Coordinator, workers int, operand func(string) bool) []string {
+	server := httptest.NewServer(c)
+	t.Cleanup(server.Close)
+	results := make([]string, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := NewWorker(server.URL, 2, operand)
+			w.Name = fmt.Sprint("worker", i)
+			w.Heartbeat = 20 * time.Millisecond
+			w.Poll = 10 * time.Millisecond
+			found, err := w.Run()
+			if err != nil && err != algorithms.ErrNotFound {
+				t.Errorf("worker %d: %v", i, err)
+			}
+			results[i] = found
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+// post sends a request to the coordinator like a worker.
+func post(t *testing.T, c *Coordinator, path string, req *request) (int, *response) {
+	body, _ := json.Marshal(req)
+	recorder := httptest.NewRecorder()
+	c.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body)))
+	res := &response{}
+	json.NewDecoder(recorder.Body).Decode(res)
+	return recorder.Code, res
+}
+
+func TestDistributed(t *testing.T) {
+	keyspace, _ := algorithms.NewMaskKeyspace("?l?l?d")
+	c := NewCoordinator(keyspace, 500)
+	var checked uint64
+	results := launch(t, c, 3, func(candidate string) bool {
+		atomic.AddUint64(&checked, 1)
+		return candidate == "qz7"
+	})
+	found, err := c.Wait()
+	if err != nil || found != "qz7" {
+		t.Errorf("expected qz7, got %q (%v)", found, err)
+	}
+	for i, result := range results {
+		if result != "qz7" {
+			t.Errorf("worker %d: expected qz7, got %q", i, result)
+		}
+	}
+	if checked >= keyspace.Size() {
+		t.Errorf("expected the workers to stop early, %d candidates checked", checked)
+	}
+
+	// every candidate is checked once without match
+	c = NewCoordinator(keyspace, 700)
+	var mutex sync.Mutex
+	seen := make(map[string]int)
+	launch(t, c, 4, func(candidate string) bool {
+		mutex.Lock()
+		seen[candidate]++
+		mutex.Unlock()
+		return false
+	})
+	if _, err := c.Wait(); err != algorithms.ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if uint64(len(seen)) != keyspace.Size() {
+		t.Errorf("expected %d candidates, got %d", keyspace.Size(), len(seen))
+	}
+	for candidate, count := range seen {
+		if count != 1 {
+			t.Errorf("%q checked %d times", candidate, count)
+		}
+	}
+	tried, done, total, _ := c.Status()
+	if tried != keyspace.Size() || done != total || total != 10 {
+		t.Errorf("unexpected status %d, %d/%d", tried, done, total)
+	}
+}
+
+func TestDeadWorker(t *testing.T) {
+	keyspace, _ := algorithms.NewMaskKeyspace("?d?d?d")
+	c := NewCoordinator(keyspace, 400)
+	c.Timeout = 100 * time.Millisecond
+
+	// a worker dies with the first unit, another one after reporting the
+	// first half of the second unit
+	status, res := post(t, c, "/work", &request{Worker: "dead"})
+	if status != http.StatusOK || res.Unit != 1 {
+		t.Fatalf("expected the unit 1, got %d %+v", status, res)
+	}
+	_, res = post(t, c, "/work", &request{Worker: "partial"})
+	status, _ = post(t, c, "/heartbeat", &request{Worker: "partial", Unit: res.Unit, Remaining: [][2]uint64{{600, 800}}})
+	if status != http.StatusOK {
+		t.Fatalf("expected the heartbeat to be accepted, got %d", status)
+	}
+	if tried, _, _, workers := c.Status(); tried != 200 || workers != 2 {
+		t.Errorf("expected 200 candidates and 2 workers, got %d and %d", tried, workers)
+	}
+
+	var mutex sync.Mutex
+	seen := make(map[string]bool)
+	results := launch(t, c, 2, func(candidate string) bool {
+		mutex.Lock()
+		seen[candidate] = true
+		mutex.Unlock()
+		return candidate == "123"
+	})
+	if results[0] != "123" || results[1] != "123" {
+		t.Errorf("expected the workers to find 123, got %v", results)
+	}
+	if seen["500"] {
+		t.Errorf("the reported half of the unit 2 was checked again")
+	}
+
+	// the dead workers are told so
+	if status, _ := post(t, c, "/heartbeat", &request{Worker: "dead", Unit: 1}); status != http.StatusGone {
+		t.Errorf("expected 410, got %d", status)
+	}
+}
+
+func TestReassignedUnit(t *testing.T) {
+	keyspace, _ := algorithms.NewMaskKeyspace("?d?d")
+	c := NewCoordinator(keyspace, 100)
+	c.Timeout = 50 * time.Millisecond
+	post(t, c, "/work", &request{Worker: "slow"})
+	if status, _ := post(t, c, "/work", &request{Worker: "other"}); status != http.StatusNoContent {
+		t.Errorf("expected no unit available, got %d", status)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if status, res := post(t, c, "/work", &request{Worker: "other"}); status != http.StatusOK || res.Unit != 1 {
+		t.Errorf("expected the unit 1 to be reassigned, got %d %+v", status, res)
+	}
+	if status, _ := post(t, c, "/heartbeat", &request{Worker: "slow", Unit: 1}); status != http.StatusConflict {
+		t.Errorf("expected 409 for the previous worker, got %d", status)
+	}
+	if status, _ := post(t, c, "/result", &request{Worker: "slow", Unit: 1, Solution: "42"}); status != http.StatusConflict {
+		t.Errorf("expected 409 for the result of the previous worker, got %d", status)
+	}
+	if status, _ := post(t, c, "/result", &request{Worker: "other", Unit: 1, Solution: "4a"}); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for a solution out of the unit, got %d", status)
+	}
+	if status, _ := post(t, c, "/result", &request{Worker: "other", Unit: 1}); status != http.StatusGone {
+		t.Errorf("expected the brute force to be over, got %d", status)
+	}
+}
+
+func TestUnreportedMatch(t *testing.T) {
+	keyspace, _ := algorithms.NewMaskKeyspace("?d?d")
+	c := NewCoordinator(keyspace, 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/result" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		c.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	w := NewWorker(server.URL, 2, func(candidate string) bool { return candidate == "42" })
+	w.Poll = time.Millisecond
+	found, err := w.Run()
+	if found != "42" || err == nil {
+		t.Errorf("expected the match to be returned with the error, got %q (%v)", found, err)
+	}
+}
@@ -0,0 +1,194 @@
+package distributed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hacking/algorithms"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Worker checks the units handed out by a coordinator.
+type Worker struct {
+	// URL is the URL of the coordinator.
+	URL string
+	// Name identifies the worker, the host name and the process id by
+	// default.
+	Name string
+	// CPU is the number of workers checking a unit.
+	CPU int
+	// Heartbeat is the interval between two progress reports, shorter
+	// than the timeout of the coordinator.
+	Heartbeat time.Duration
+	// Poll is the interval between two requests when no unit is available.
+	Poll time.Duration
+	// Retries is the number of consecutive failing requests tolerated.
+	Retries int
+	// Client sends the requests, http.DefaultClient if nil.
+	Client *http.Client
+
+	operand func(string) bool
+	units   uint64
+}
+
+// NewWorker creates a worker of the coordinator checking the candidates
+// with the operand using cpu workers.
+func NewWorker(url string, cpu int, operand func(string) bool) *Worker {
+	host, _ := os.Hostname()
+	return &Worker{
+		URL:       strings.TrimRight(url, "/"),
+		Name:      fmt.Sprintf("%s-%d", host, os.Getpid()),
+		CPU:       cpu,
+		Heartbeat: 10 * time.Second,
+		Poll:      time.Second,
+		Retries:   5,
+		operand:   operand,
+	}
+}
+
+// Units returns the number of units checked by the worker.
+func (w *Worker) Units() uint64 {
+	return atomic.LoadUint64(&w.units)
+}
+
+// post sends the request to the coordinator and decodes its response.
+func (w *Worker) post(path string, req *request) (int, *response, error) {
+	req.Worker = w.Name
+	body, err := json.Marshal(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var resp *http.Response
+	for i := 0; ; i++ {
+		resp, err = client.Post(w.URL+path, "application/json", bytes.NewReader(body))
+		if err == nil || i >= w.Retries {
+			break
+		}
+		time.Sleep(w.Poll)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	res := &response{}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusConflict, http.StatusGone:
+		if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+			return 0, nil, err
+		}
+	case http.StatusNoContent:
+	default:
+		return 0, nil, fmt.Errorf("%s%s: %s", w.URL, path, resp.Status)
+	}
+	return resp.StatusCode, res, nil
+}
+
+// Run checks units until the brute force is over and returns the matching
+// candidate, found by this worker or another one, or
+// algorithms.ErrNotFound. A match which couldn't be reported to the
+// coordinator is returned with the error.
+func (w *Worker) Run() (string, error) {
+	for {
+		status, res, err := w.post("/work", &request{})
+		if err != nil {
+			return "", err
+		}
+		switch status {
+		case http.StatusGone:
+			if len(res.Solution) == 0 {
+				return "", algorithms.ErrNotFound
+			}
+			return res.Solution, nil
+		case http.StatusNoContent:
+			time.Sleep(w.Poll)
+			continue
+		}
+		found, complete, err := w.check(res.Unit, res.Token)
+		if err != nil {
+			return "", err
+		}
+		if !complete {
+			continue
+		}
+		unit := res.Unit
+		status, res, err = w.post("/result", &request{Unit: unit, Solution: found})
+		if err != nil && len(found) > 0 {
+			return found, fmt.Errorf("failed to report the match of unit %d: %v", unit, err)
+		}
+		if err != nil {
+			return "", err
+		}
+		if status == http.StatusConflict {
+			if len(found) > 0 {
+				return found, fmt.Errorf("unit %d was reassigned before its match was reported", unit)
+			}
+			continue
+		}
+		atomic.AddUint64(&w.units, 1)
+		if status == http.StatusGone {
+			if len(res.Solution) == 0 {
+				return "", algorithms.ErrNotFound
+			}
+			return res.Solution, nil
+		}
+	}
+}
+
+// check brute forces the unit, reporting its progress regularly, and
+// returns the matching candidate if any. It stops early if the coordinator
+// asks so, the unit not being complete.
+func (w *Worker) check(id int, token string) (string, bool, error) {
+	progress, err := algorithms.ParseToken(token)
+	if err != nil {
+		return "", false, err
+	}
+	keyspace, err := progress.Keyspace()
+	if err != nil {
+		return "", false, err
+	}
+	checkpoint := algorithms.NewCheckpoint("", w.Heartbeat)
+	checkpoint.Token = token
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(w.Heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				req := &request{Unit: id}
+				if p := checkpoint.Progress(); p != nil {
+					req.Remaining = p.Remaining
+				}
+				// the coordinator is retried at the next heartbeat
+				if status, _, err := w.post("/heartbeat", req); err == nil && status != http.StatusOK {
+					checkpoint.Stop()
+					return
+				}
+			}
+		}
+	}()
+	found, err := algorithms.BruteForceKeyspace(keyspace, w.CPU, w.operand, checkpoint)
+	close(stop)
+	<-done
+	switch err {
+	case nil:
+		return found, true, nil
+	case algorithms.ErrNotFound:
+		return "", true, nil
+	case algorithms.ErrStopped:
+		return "", false, nil
+	}
+	return "", false, err
+}
@@ -0,0 +1,492 @@
+package encrypted
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rc4"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// pdfPadding pads the passwords of the revisions 2 to 4.
+var pdfPadding = []byte{
+	0x28, 0xbf, 0x4e, 0x5e, 0x4e, 0x75, 0x8a, 0x41, 0x64, 0x00, 0x4e, 0x56, 0xff, 0xfa, 0x01, 0x08,
+	0x2e, 0x2e, 0x00, 0xb6, 0xd0, 0x68, 0x3e, 0x80, 0x2f, 0x0c, 0xa9, 0xfe, 0x64, 0x53, 0x69, 0x7a,
+}
+
+// pdfRef is an indirect object reference.
+type pdfRef struct {
+	number, generation int
+}
+
+// pdfName is a name object, without its '/'.
+type pdfName string
+
+// pdfParser parses the PDF objects of a buffer.
+type pdfParser struct {
+	data     []byte
+	position int
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return isPDFSpace(c) || bytes.IndexByte([]byte("()<>[]{}/%"), c) >= 0
+}
+
+func (p *pdfParser) skip() {
+	for p.position < len(p.data) {
+		c := p.data[p.position]
+		if c == '%' {
+			for p.position < len(p.data) && p.data[p.position] != '\n' && p.data[p.position] != '\r' {
+				p.position++
+			}
+			continue
+		}
+		if !isPDFSpace(c) {
+			return
+		}
+		p.position++
+	}
+}
+
+func (p *pdfParser) token() string {
+	start := p.position
+	for p.position < len(p.data) && !isPDFDelimiter(p.data[p.position]) {
+		p.position++
+	}
+	return string(p.data[start:p.position])
+}
+
+func (p *pdfParser) literal() ([]byte, error) {
+	var value []byte
+	depth := 0
+	for p.position++; p.position < len(p.data); p.position++ {
+		c := p.data[p.position]
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				p.position++
+				return value, nil
+			}
+			depth--
+		case '\\':
+			p.position++
+			if p.position == len(p.data) {
+				break
+			}
+			c = p.data[p.position]
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if p.position+1 < len(p.data) && p.data[p.position+1] == '\n' {
+					p.position++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if c >= '0' && c <= '7' {
+					octal := 0
+					for i := 0; i < 3 && p.position < len(p.data) && p.data[p.position] >= '0' && p.data[p.position] <= '7'; i++ {
+						octal = octal*8 + int(p.data[p.position]-'0')
+						p.position++
+					}
+					p.position--
+					c = byte(octal)
+				}
+			}
+		}
+		value = append(value, c)
+	}
+	return nil, fmt.Errorf("unterminated string")
+}
+
+func (p *pdfParser) hex() ([]byte, error) {
+	end := bytes.IndexByte(p.data[p.position:], '>')
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated hexadecimal string")
+	}
+	digits := bytes.Map(func(r rune) rune {
+		if isPDFSpace(byte(r)) {
+			return -1
+		}
+		return r
+	}, p.data[p.position+1:p.position+end])
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	p.position += end + 1
+	return hex.DecodeString(string(digits))
+}
+
+// object parses the next object, the references included.
+func (p *pdfParser) object() (interface{}, error) {
+	p.skip()
+	if p.position >= len(p.data) {
+		return nil, fmt.Errorf("unexpected end of data")
+	}
+	switch c := p.data[p.position]; {
+	case c == '/':
+		p.position++
+		return pdfName(p.token()), nil
+	case c == '(':
+		return p.literal()
+	case c == '<' && p.position+1 < len(p.data) && p.data[p.position+1] == '<':
+		p.position += 2
+		dictionary := make(map[string]interface{})
+		for {
+			p.skip()
+			if bytes.HasPrefix(p.data[p.position:], []byte(">>")) {
+				p.position += 2
+				return dictionary, nil
+			}
+			key, err := p.object()
+			if err != nil {
+				return nil, err
+			}
+			name, ok := key.(pdfName)
+			if !ok {
+				return nil, fmt.Errorf("invalid dictionary key %v", key)
+			}
+			value, err := p.object()
+			if err != nil {
+				return nil, err
+			}
+			dictionary[string(name)] = value
+		}
+	case c == '<':
+		return p.hex()
+	case c == '[':
+		p.position++
+		var array []interface{}
+		for {
+			p.skip()
+			if p.position < len(p.data) && p.data[p.position] == ']' {
+				p.position++
+				return array, nil
+			}
+			value, err := p.object()
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+	}
+	token := p.token()
+	if len(token) == 0 {
+		return nil, fmt.Errorf("unexpected character %q", p.data[p.position])
+	}
+	switch token {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	number, err := strconv.ParseInt(token, 10, 64)
+	if err != nil {
+		if f, err := strconv.ParseFloat(token, 64); err == nil {
+			return f, nil
+		}
+		return nil, fmt.Errorf("unexpected token %q", token)
+	}
+	// a reference is "number generation R"
+	saved := p.position
+	p.skip()
+	generation := p.token()
+	p.skip()
+	if g, err := strconv.Atoi(generation); err == nil && p.position < len(p.data) && p.data[p.position] == 'R' &&
+		(p.position+1 == len(p.data) || isPDFDelimiter(p.data[p.position+1])) {
+		p.position++
+		return pdfRef{int(number), g}, nil
+	}
+	p.position = saved
+	return number, nil
+}
+
+// PDF is the standard security handler of an encrypted PDF file.
+type PDF struct {
+	path            string
+	revision        int
+	keyLength       int
+	owner           []byte
+	user            []byte
+	permissions     uint32
+	id              []byte
+	encryptMetadata bool
+}
+
+var (
+	encryptRegexp = regexp.MustCompile(`/Encrypt[\s/<]`)
+	idRegexp      = regexp.MustCompile(`/ID\s*\[`)
+)
+
+// OpenPDF reads the encryption dictionary of a PDF file: RC4 40 and 128
+// bits and AES 128 and 256 bits, revisions 2 to 6.
+func OpenPDF(path string) (*PDF, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePDF(path, data)
+}
+
+// ParsePDF reads the encryption dictionary of the PDF data, named path.
+func ParsePDF(path string, data []byte) (*PDF, error) {
+	// the last trailer wins with incremental updates
+	matches := encryptRegexp.FindAllIndex(data, -1)
+	if len(matches) == 0 {
+		return nil, ErrNotEncrypted
+	}
+	parser := &pdfParser{data: data, position: matches[len(matches)-1][0] + len("/Encrypt")}
+	value, err := parser.object()
+	if err != nil {
+		return nil, fmt.Errorf("%s: encryption dictionary: %s", path, err)
+	}
+	if ref, ok := value.(pdfRef); ok {
+		objects := regexp.MustCompile(fmt.Sprintf(`(^|[^0-9])%d\s+%d\s+obj`, ref.number, ref.generation)).
+			FindAllIndex(data, -1)
+		if len(objects) == 0 {
+			return nil, fmt.Errorf("%s: missing encryption dictionary %d %d", path, ref.number, ref.generation)
+		}
+		position := objects[len(objects)-1][1]
+		parser = &pdfParser{data: data, position: position}
+		if value, err = parser.object(); err != nil {
+			return nil, fmt.Errorf("%s: encryption dictionary: %s", path, err)
+		}
+	}
+	dictionary, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: invalid encryption dictionary", path)
+	}
+	if filter, _ := dictionary["Filter"].(pdfName); filter != "Standard" {
+		return nil, fmt.Errorf("%s: unsupported security handler %q", path, filter)
+	}
+	pdf := &PDF{path: path, keyLength: 5, encryptMetadata: true}
+	revision, _ := dictionary["R"].(int64)
+	permissions, _ := dictionary["P"].(int64)
+	pdf.revision = int(revision)
+	pdf.permissions = uint32(int32(permissions))
+	pdf.owner, _ = dictionary["O"].([]byte)
+	pdf.user, _ = dictionary["U"].([]byte)
+	if metadata, ok := dictionary["EncryptMetadata"].(bool); ok {
+		pdf.encryptMetadata = metadata
+	}
+	switch {
+	case pdf.revision == 3:
+		if length, ok := dictionary["Length"].(int64); ok {
+			pdf.keyLength = int(length / 8)
+		}
+	case pdf.revision == 4:
+		pdf.keyLength = 16
+	}
+	minimal := 32
+	if pdf.revision >= 5 {
+		minimal = 48
+	}
+	if pdf.revision < 2 || pdf.revision > 6 || pdf.keyLength < 5 || pdf.keyLength > 16 ||
+		len(pdf.owner) < minimal || len(pdf.user) < minimal {
+		return nil, fmt.Errorf("%s: unsupported encryption revision %d", path, pdf.revision)
+	}
+	if pdf.revision <= 4 {
+		if ids := idRegexp.FindAllIndex(data, -1); len(ids) > 0 {
+			parser = &pdfParser{data: data, position: ids[len(ids)-1][1] - 1}
+			if value, err := parser.object(); err == nil {
+				if array, ok := value.([]interface{}); ok && len(array) > 0 {
+					pdf.id, _ = array[0].([]byte)
+				}
+			}
+		}
+		if pdf.id == nil {
+			return nil, fmt.Errorf("%s: missing file identifier", path)
+		}
+	}
+	return pdf, nil
+}
+
+// String returns the path of the file.
+func (p *PDF) String() string {
+	return p.path
+}
+
+// Revision returns the revision of the standard security handler.
+func (p *PDF) Revision() int {
+	return p.revision
+}
+
+func pad(password string) []byte {
+	padded := append([]byte(password), pdfPadding...)
+	return padded[:32]
+}
+
+func rc4Crypt(key, data []byte) []byte {
+	c, _ := rc4.NewCipher(key)
+	out := make([]byte, len(data))
+	c.XORKeyStream(out, data)
+	return out
+}
+
+// rc4Rounds encrypts or decrypts with the 20 RC4 rounds of the revisions
+// 3 and 4, the key being xored with the round number.
+func rc4Rounds(key, data []byte, decrypt bool) []byte {
+	round := make([]byte, len(key))
+	for i := 0; i < 20; i++ {
+		r := i
+		if decrypt {
+			r = 19 - i
+		}
+		for j := range key {
+			round[j] = key[j] ^ byte(r)
+		}
+		data = rc4Crypt(round, data)
+	}
+	return data
+}
+
+// fileKey computes the file encryption key of the revisions 2 to 4
+// (algorithm 2).
+func (p *PDF) fileKey(password string) []byte {
+	h := md5.New()
+	h.Write(pad(password))
+	h.Write(p.owner[:32])
+	h.Write(binary.LittleEndian.AppendUint32(nil, p.permissions))
+	h.Write(p.id)
+	if p.revision >= 4 && !p.encryptMetadata {
+		h.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	}
+	key := h.Sum(nil)
+	if p.revision >= 3 {
+		for i := 0; i < 50; i++ {
+			sum := md5.Sum(key[:p.keyLength])
+			key = sum[:]
+		}
+	}
+	return key[:p.keyLength]
+}
+
+// userHash computes the U entry, or its first 16 bytes from the revision
+// 3, of the file key (algorithms 4 and 5).
+func (p *PDF) userHash(key []byte) []byte {
+	if p.revision == 2 {
+		return rc4Crypt(key, pdfPadding)
+	}
+	h := md5.New()
+	h.Write(pdfPadding)
+	h.Write(p.id)
+	return rc4Rounds(key, h.Sum(nil), false)
+}
+
+// ownerKey computes the key encrypting the user password in the O entry
+// (algorithm 3).
+func (p *PDF) ownerKey(password string) []byte {
+	sum := md5.Sum(pad(password))
+	key := sum[:]
+	if p.revision >= 3 {
+		for i := 0; i < 50; i++ {
+			sum = md5.Sum(key)
+			key = sum[:]
+		}
+	}
+	return key[:p.keyLength]
+}
+
+// hash2B is the password hash of the revision 6 (algorithm 2.B), the
+// revision 5 using a single SHA-256.
+func (p *PDF) hash2B(password, salt, user []byte) []byte {
+	h := sha256.New()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(user)
+	k := h.Sum(nil)
+	if p.revision == 5 {
+		return k
+	}
+	var e []byte
+	for i := 0; i < 64 || int(e[len(e)-1]) > i-32; i++ {
+		round := append(append(append([]byte(nil), password...), k...), user...)
+		k1 := bytes.Repeat(round, 64)
+		block, _ := aes.NewCipher(k[:16])
+		e = make([]byte, len(k1))
+		cipher.NewCBCEncrypter(block, k[16:32]).CryptBlocks(e, k1)
+		sum := 0
+		for _, b := range e[:16] {
+			sum += int(b)
+		}
+		switch sum % 3 {
+		case 0:
+			s := sha256.Sum256(e)
+			k = s[:]
+		case 1:
+			s := sha512.Sum384(e)
+			k = s[:]
+		case 2:
+			s := sha512.Sum512(e)
+			k = s[:]
+		}
+	}
+	return k[:32]
+}
+
+func truncate(password string) []byte {
+	if len(password) > 127 {
+		return []byte(password[:127])
+	}
+	return []byte(password)
+}
+
+// VerifyUser checks the user password, the one opening the document.
+func (p *PDF) VerifyUser(password string) bool {
+	if p.revision >= 5 {
+		return bytes.Equal(p.hash2B(truncate(password), p.user[32:40], nil), p.user[:32])
+	}
+	hash := p.userHash(p.fileKey(password))
+	if p.revision == 2 {
+		return bytes.Equal(hash, p.user[:32])
+	}
+	return bytes.Equal(hash, p.user[:16])
+}
+
+// VerifyOwner checks the owner password, the one granting all the
+// permissions.
+func (p *PDF) VerifyOwner(password string) bool {
+	if p.revision >= 5 {
+		return bytes.Equal(p.hash2B(truncate(password), p.owner[32:40], p.user[:48]), p.owner[:32])
+	}
+	key := p.ownerKey(password)
+	var user []byte
+	if p.revision == 2 {
+		user = rc4Crypt(key, p.owner[:32])
+	} else {
+		user = rc4Rounds(key, p.owner[:32], true)
+	}
+	// the decrypted O entry is the padded user password
+	return p.VerifyUser(string(user))
+}
+
+// Verify checks the password as a user or an owner password.
+func (p *PDF) Verify(password string) bool {
+	return p.VerifyUser(password) || p.VerifyOwner(password)
+}
@@ -0,0 +1,202 @@
+package encrypted
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hacking/algorithms"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// literal writes data as a literal string, escaping what needs to be.
+func literal(data []byte) string {
+	var b bytes.Buffer
+	b.WriteByte('(')
+	for _, c := range data {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// encryptPDF computes the O and U entries of the passwords.
+func encryptPDF(revision, keyLength int, user, owner string, id []byte, metadata bool) *PDF {
+	p := &PDF{
+		revision:        revision,
+		keyLength:       keyLength,
+		permissions:     uint32(0xfffff0c4),
+		id:              id,
+		encryptMetadata: metadata,
+	}
+	if revision >= 5 {
+		userSalt := []byte("uvalsaltukeysalt")
+		p.user = append(p.hash2B(truncate(user), userSalt[:8], nil), userSalt...)
+		ownerSalt := []byte("ovalsaltokeysalt")
+		p.owner = append(p.hash2B(truncate(owner), ownerSalt[:8], p.user), ownerSalt...)
+		return p
+	}
+	key := p.ownerKey(owner)
+	if revision == 2 {
+		p.owner = rc4Crypt(key, pad(user))
+	} else {
+		p.owner = rc4Rounds(key, pad(user), false)
+	}
+	p.user = p.userHash(p.fileKey(user))
+	// the last 16 bytes of U are arbitrary from the revision 3
+	p.user = append(p.user, bytes.Repeat([]byte{0x42}, 32-len(p.user))...)
+	return p
+}
+
+func writePDF(t *testing.T, name, encrypt string, id []byte) string {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	b.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	b.WriteString("2 0 obj\n<< /Type /Pages /Kids [] /Count 0 >>\nendobj\n")
+	trailer := "/Encrypt " + encrypt
+	if len(encrypt) == 0 {
+		trailer = ""
+	} else if encrypt[0] != '<' {
+		fmt.Fprintf(&b, "13 0 obj\n<< /Author (not it) >>\nendobj\n")
+		fmt.Fprintf(&b, "3 0 obj\n%s\nendobj\n", encrypt)
+		trailer = "/Encrypt 3 0 R"
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size 4 /Root 1 0 R %s /ID [<%x> <%x>] >>\n%%%%EOF\n", trailer, id, id)
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, b.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPDF(t *testing.T) {
+	id := []byte("\x8a\x13(\\)\x00file identifier")
+	tests := []struct {
+		name       string
+		revision   int
+		keyLength  int
+		version    int
+		metadata   bool
+		dictionary string
+	}{
+		{"rc4-40", 2, 5, 1, true, "/Length 40"},
+		{"rc4-128", 3, 16, 2, true, "/Length 128"},
+		{"rc4-56", 3, 7, 2, true, "/Length 56"},
+		{"aes-128", 4, 16, 4, false, "/Length 128 /EncryptMetadata false /CF << /StdCF << /CFM /AESV2 /Length 16 >> >> /StmF /StdCF /StrF /StdCF"},
+		{"aes-256-r5", 5, 32, 5, true, "/Length 256 /CF << /StdCF << /CFM /AESV3 /Length 32 >> >> /StmF /StdCF /StrF /StdCF"},
+		{"aes-256", 6, 32, 5, true, "/Length 256 /CF << /StdCF << /CFM /AESV3 /Length 32 >> >> /StmF /StdCF /StrF /StdCF"},
+	}
+	for _, test := range tests {
+		fixture := encryptPDF(test.revision, test.keyLength, "b2", "c3", id, test.metadata)
+		entries := fmt.Sprintf("/O %s /U <%x>", literal(fixture.owner), fixture.user)
+		if test.revision >= 5 {
+			entries += fmt.Sprintf(" /OE <%x> /UE <%x> /Perms <%x>", bytes.Repeat([]byte{1}, 32),
+				bytes.Repeat([]byte{2}, 32), bytes.Repeat([]byte{3}, 16))
+		}
+		encrypt := fmt.Sprintf("<< /Filter /Standard /V %d /R %d %s %s /P -3900 >>",
+			test.version, test.revision, test.dictionary, entries)
+		for _, indirect := range []bool{false, true} {
+			name, object := test.name, encrypt
+			if indirect {
+				// a reference to the dictionary, "% comment" included
+				name += "-indirect"
+				object = "% encryption\n" + encrypt
+			}
+			path := writePDF(t, name+".pdf", object, id)
+			pdf, err := OpenPDF(path)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if pdf.Revision() != test.revision {
+				t.Errorf("%s: expected the revision %d, got %d", name, test.revision, pdf.Revision())
+			}
+			if !pdf.VerifyUser("b2") || pdf.VerifyOwner("b2") {
+				t.Errorf("%s: the user password is not verified", name)
+			}
+			if !pdf.VerifyOwner("c3") || pdf.VerifyUser("c3") {
+				t.Errorf("%s: the owner password is not verified", name)
+			}
+			for i := 0; i < 100; i++ {
+				if candidate := fmt.Sprint(i); pdf.Verify(candidate) {
+					t.Errorf("%s: wrong password %q verified", name, candidate)
+				}
+			}
+		}
+		keyspace, _ := algorithms.NewMaskKeyspace("c?d")
+		pdf, _ := ParsePDF(test.name, []byte(fmt.Sprintf("trailer << /Encrypt %s /ID [<%x><%x>] >>", encrypt, id, id)))
+		found, err := algorithms.BruteForceKeyspace(keyspace, 2, pdf.VerifyOwner, nil)
+		if err != nil || found != "c3" {
+			t.Errorf("%s: expected the brute force to find c3, got %q (%v)", test.name, found, err)
+		}
+	}
+
+	// the revision 5 is a single SHA-256 of the password and the salt
+	r5 := encryptPDF(5, 32, "b2", "c3", nil, true)
+	if sum := sha256.Sum256([]byte("b2uvalsalt")); !bytes.Equal(r5.user[:32], sum[:]) {
+		t.Errorf("unexpected revision 5 user hash %x", r5.user[:32])
+	}
+
+	plain := writePDF(t, "plain.pdf", "", nil)
+	if _, err := OpenPDF(plain); err != ErrNotEncrypted {
+		t.Errorf("expected ErrNotEncrypted, got %v", err)
+	}
+}
+
+// TestPDFReference checks entries computed apart from the package, from
+// the algorithms of ISO 32000-2 (7.6.4.3 and 7.6.4.4), OpenSSL doing the
+// AES of the revision 6, for the user b2 and the owner c3 passwords.
+func TestPDFReference(t *testing.T) {
+	id, _ := hex.DecodeString("5d2b7f1e0c9a4b6e8f3d2a1c0b9e8d7f")
+	tests := []struct {
+		name       string
+		dictionary string
+		owner      string
+		user       string
+	}{
+		{
+			"rc4-128",
+			"/V 2 /R 3 /Length 128",
+			"f23fd6b475a66ee1c83a5759444587a7dbc298c278a7f45073521510d7f05e11",
+			"d4704fcb6a5cefc44e42c41a755de4c3000102030405060708090a0b0c0d0e0f",
+		},
+		{
+			"aes-128",
+			"/V 4 /R 4 /Length 128 /EncryptMetadata false /CF << /StdCF << /CFM /AESV2 /Length 16 >> >> /StmF /StdCF /StrF /StdCF",
+			"f23fd6b475a66ee1c83a5759444587a7dbc298c278a7f45073521510d7f05e11",
+			"6ee7b6a73326522303ec91c2efdd4d57000102030405060708090a0b0c0d0e0f",
+		},
+		{
+			"aes-256",
+			"/V 5 /R 6 /Length 256 /CF << /StdCF << /CFM /AESV3 /Length 32 >> >> /StmF /StdCF /StrF /StdCF" +
+				" /OE <" + strings.Repeat("01", 32) + "> /UE <" + strings.Repeat("02", 32) + "> /Perms <" + strings.Repeat("03", 16) + ">",
+			"8045311a290179f3152076fbba59ad1b7040d098b514531f569577559e826b1e33333333333333334444444444444444",
+			"d6f8c6924a3d0c1f9056e5aa8ea5b40251a41795db0db12fe60995bfd6a7cf8b11111111111111112222222222222222",
+		},
+	}
+	for _, test := range tests {
+		encrypt := fmt.Sprintf("<< /Filter /Standard %s /O <%s> /U <%s> /P -3900 >>", test.dictionary, test.owner, test.user)
+		pdf, err := OpenPDF(writePDF(t, test.name+".pdf", encrypt, id))
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !pdf.VerifyUser("b2") || pdf.VerifyUser("c3") {
+			t.Errorf("%s: the user password is not verified", test.name)
+		}
+		if !pdf.VerifyOwner("c3") || pdf.VerifyOwner("b2") {
+			t.Errorf("%s: the owner password is not verified", test.name)
+		}
+	}
+}
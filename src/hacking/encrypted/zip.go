@@ -0,0 +1,260 @@
+// Package encrypted verifies password candidates against encrypted files:
+// ZipCrypto and AES zip archives, RC4 and AES PDF documents.
+package encrypted
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+)
+
+const (
+	// zipAES is the compression method of the WinZip AES entries
+	zipAES = 99
+	// zipAESExtra is the extra field id of the WinZip AES entries
+	zipAESExtra = 0x9901
+	// zipHeaderSize is the size of the ZipCrypto encryption header
+	zipHeaderSize = 12
+	// zipAuthSize is the size of the WinZip AES authentication code
+	zipAuthSize = 10
+	// zipAESRounds is the number of PBKDF2 rounds of WinZip AES
+	zipAESRounds = 1000
+)
+
+// ErrNotEncrypted is returned when a file has no encrypted content.
+var ErrNotEncrypted = errors.New("no encrypted content")
+
+// zipKeys are the keys of the ZipCrypto cipher.
+type zipKeys [3]uint32
+
+func newZipKeys(password string) *zipKeys {
+	keys := &zipKeys{0x12345678, 0x23456789, 0x34567890}
+	for i := 0; i < len(password); i++ {
+		keys.update(password[i])
+	}
+	return keys
+}
+
+func crc32Byte(crc uint32, b byte) uint32 {
+	return crc32.IEEETable[byte(crc)^b] ^ crc>>8
+}
+
+func (k *zipKeys) update(b byte) {
+	k[0] = crc32Byte(k[0], b)
+	k[1] = (k[1]+k[0]&0xff)*134775813 + 1
+	k[2] = crc32Byte(k[2], byte(k[1]>>24))
+}
+
+func (k *zipKeys) stream() byte {
+	t := k[2] | 2
+	return byte((t * (t ^ 1)) >> 8)
+}
+
+func (k *zipKeys) decrypt(data []byte) {
+	for i, c := range data {
+		p := c ^ k.stream()
+		k.update(p)
+		data[i] = p
+	}
+}
+
+// zipEntry is an encrypted entry of an archive.
+type zipEntry struct {
+	file *zip.File
+	// ZipCrypto encryption header and its check byte
+	header [zipHeaderSize]byte
+	check  byte
+	// WinZip AES key length, salt, password verifier and compression method
+	keyLength int
+	salt      []byte
+	verifier  []byte
+	method    uint16
+}
+
+// aesExtra returns the WinZip AES key length and actual compression
+// method of an entry from its extra field.
+func aesExtra(extra []byte) (int, uint16, error) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+size {
+			break
+		}
+		if id == zipAESExtra && size >= 7 {
+			field := extra[4 : 4+size]
+			method := binary.LittleEndian.Uint16(field[5:])
+			switch field[4] {
+			case 1:
+				return 16, method, nil
+			case 2:
+				return 24, method, nil
+			case 3:
+				return 32, method, nil
+			}
+			return 0, 0, fmt.Errorf("invalid AES strength %d", field[4])
+		}
+		extra = extra[4+size:]
+	}
+	return 0, 0, fmt.Errorf("missing AES extra field")
+}
+
+func newZipEntry(file *zip.File) (*zipEntry, error) {
+	entry := &zipEntry{file: file, method: file.Method}
+	raw, err := file.OpenRaw()
+	if err != nil {
+		return nil, err
+	}
+	if file.Method == zipAES {
+		entry.keyLength, entry.method, err = aesExtra(file.Extra)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Name, err)
+		}
+		header := make([]byte, entry.keyLength/2+2)
+		if _, err := io.ReadFull(raw, header); err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Name, err)
+		}
+		entry.salt = header[:entry.keyLength/2]
+		entry.verifier = header[entry.keyLength/2:]
+		return entry, nil
+	}
+	if _, err := io.ReadFull(raw, entry.header[:]); err != nil {
+		return nil, fmt.Errorf("%s: %s", file.Name, err)
+	}
+	// with a data descriptor, the check byte is the high byte of the time
+	entry.check = byte(file.CRC32 >> 24)
+	if file.Flags&0x8 != 0 {
+		entry.check = byte(file.ModifiedTime >> 8)
+	}
+	return entry, nil
+}
+
+// quick checks the password against the check bytes of the entry and
+// returns the keys for the full check.
+func (e *zipEntry) quick(password string) ([]byte, *zipKeys, bool) {
+	if e.keyLength > 0 {
+		keys, err := pbkdf2.Key(sha1.New, password, e.salt, zipAESRounds, 2*e.keyLength+2)
+		if err != nil || !bytes.Equal(keys[2*e.keyLength:], e.verifier) {
+			return nil, nil, false
+		}
+		return keys, nil, true
+	}
+	keys := newZipKeys(password)
+	header := e.header
+	keys.decrypt(header[:])
+	return nil, keys, header[zipHeaderSize-1] == e.check
+}
+
+// full checks the password against the content of the entry: its
+// authentication code with AES, its CRC otherwise.
+func (e *zipEntry) full(aesKeys []byte, keys *zipKeys) bool {
+	raw, err := e.file.OpenRaw()
+	if err != nil {
+		return false
+	}
+	if aesKeys != nil {
+		content, err := io.ReadAll(raw)
+		if err != nil || len(content) < len(e.salt)+2+zipAuthSize {
+			return false
+		}
+		data := content[len(e.salt)+2 : len(content)-zipAuthSize]
+		mac := hmac.New(sha1.New, aesKeys[e.keyLength:2*e.keyLength])
+		mac.Write(data)
+		return hmac.Equal(mac.Sum(nil)[:zipAuthSize], content[len(content)-zipAuthSize:])
+	}
+	content, err := io.ReadAll(raw)
+	if err != nil || len(content) < zipHeaderSize {
+		return false
+	}
+	data := content[zipHeaderSize:]
+	keys.decrypt(data)
+	var reader io.Reader = bytes.NewReader(data)
+	switch e.method {
+	case zip.Store:
+	case zip.Deflate:
+		reader = flate.NewReader(reader)
+	default:
+		return false
+	}
+	crc := crc32.NewIEEE()
+	size, err := io.Copy(crc, io.LimitReader(reader, int64(e.file.UncompressedSize64)+1))
+	return err == nil && uint64(size) == e.file.UncompressedSize64 && crc.Sum32() == e.file.CRC32
+}
+
+// Zip is an encrypted zip archive.
+type Zip struct {
+	path    string
+	reader  *zip.ReadCloser
+	entries []*zipEntry
+}
+
+// OpenZip opens an archive with ZipCrypto or WinZip AES encrypted entries.
+func OpenZip(path string) (*Zip, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	z := &Zip{path: path, reader: reader}
+	for _, file := range reader.File {
+		if file.Flags&0x1 == 0 || file.FileInfo().IsDir() {
+			continue
+		}
+		entry, err := newZipEntry(file)
+		if err != nil {
+			reader.Close()
+			return nil, err
+		}
+		z.entries = append(z.entries, entry)
+	}
+	if len(z.entries) == 0 {
+		reader.Close()
+		return nil, ErrNotEncrypted
+	}
+	// the smallest entry is the cheapest to check fully
+	sort.SliceStable(z.entries, func(i, j int) bool {
+		return z.entries[i].file.CompressedSize64 < z.entries[j].file.CompressedSize64
+	})
+	return z, nil
+}
+
+// String returns the path of the archive.
+func (z *Zip) String() string {
+	return z.path
+}
+
+// Verify checks the password against the check bytes of the entries
+// then against the content of the smallest one. It is safe for
+// concurrent use.
+func (z *Zip) Verify(password string) bool {
+	// a ZipCrypto check byte only rejects 255 passwords out of 256, so
+	// all of them are checked before the content
+	var aesKeys []byte
+	var keys *zipKeys
+	for i, entry := range z.entries {
+		a, k, ok := entry.quick(password)
+		if !ok {
+			return false
+		}
+		if i == 0 {
+			aesKeys, keys = a, k
+		}
+		if a != nil {
+			// the AES verifier rejects 65535 passwords out of 65536
+			break
+		}
+	}
+	return z.entries[0].full(aesKeys, keys)
+}
+
+// Close closes the archive.
+func (z *Zip) Close() error {
+	return z.reader.Close()
+}
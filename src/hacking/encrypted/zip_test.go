@@ -0,0 +1,153 @@
+package encrypted
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"hacking/algorithms"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func deflate(t *testing.T, content []byte) []byte {
+	var compressed bytes.Buffer
+	writer, _ := flate.NewWriter(&compressed, flate.BestCompression)
+	writer.Write(content)
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return compressed.Bytes()
+}
+
+func addZipCrypto(t *testing.T, w *zip.Writer, name, password string, content []byte, descriptor bool) {
+	header := &zip.FileHeader{
+		Name:               name,
+		Method:             zip.Deflate,
+		Flags:              0x1,
+		CRC32:              crc32.ChecksumIEEE(content),
+		UncompressedSize64: uint64(len(content)),
+		ModifiedTime:       0xa1b2,
+	}
+	check := byte(header.CRC32 >> 24)
+	if descriptor {
+		header.Flags |= 0x8
+		check = byte(header.ModifiedTime >> 8)
+	}
+	plain := append([]byte("0123456789a"), check)
+	plain = append(plain, deflate(t, content)...)
+	keys := newZipKeys(password)
+	encrypted := make([]byte, len(plain))
+	for i, p := range plain {
+		encrypted[i] = p ^ keys.stream()
+		keys.update(p)
+	}
+	header.CompressedSize64 = uint64(len(encrypted))
+	writer, err := w.CreateRaw(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Write(encrypted)
+}
+
+func addAES(t *testing.T, w *zip.Writer, name, password string, content []byte) {
+	salt := []byte("0123456789abcdef")
+	keys, _ := pbkdf2.Key(sha1.New, password, salt, zipAESRounds, 2*32+2)
+	block, err := aes.NewCipher(keys[:32])
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := deflate(t, content)
+	// WinZip AES uses CTR mode with a little-endian counter starting at 1
+	var counter, stream [aes.BlockSize]byte
+	for i := range data {
+		if i%aes.BlockSize == 0 {
+			binary.LittleEndian.PutUint64(counter[:], uint64(i/aes.BlockSize+1))
+			block.Encrypt(stream[:], counter[:])
+		}
+		data[i] ^= stream[i%aes.BlockSize]
+	}
+	mac := hmac.New(sha1.New, keys[32:64])
+	mac.Write(data)
+	encrypted := append(append(append([]byte(nil), salt...), keys[64:]...), data...)
+	encrypted = append(encrypted, mac.Sum(nil)[:zipAuthSize]...)
+	extra := []byte{0x01, 0x99, 7, 0, 2, 0, 'A', 'E', 3, byte(zip.Deflate), 0}
+	writer, err := w.CreateRaw(&zip.FileHeader{
+		Name:               name,
+		Method:             zipAES,
+		Flags:              0x1,
+		Extra:              extra,
+		UncompressedSize64: uint64(len(content)),
+		CompressedSize64:   uint64(len(encrypted)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Write(encrypted)
+}
+
+func makeArchive(t *testing.T, name string, add func(w *zip.Writer)) string {
+	path := filepath.Join(t.TempDir(), name)
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	w := zip.NewWriter(file)
+	add(w)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestZip(t *testing.T) {
+	content := bytes.Repeat([]byte("some secret content\n"), 50)
+	archives := map[string]string{
+		"zipcrypto": makeArchive(t, "zipcrypto.zip", func(w *zip.Writer) {
+			addZipCrypto(t, w, "big.txt", "ab1", append(content, content...), false)
+			addZipCrypto(t, w, "small.txt", "ab1", content, true)
+			plain, _ := w.Create("plain.txt")
+			plain.Write(content)
+		}),
+		"aes": makeArchive(t, "aes.zip", func(w *zip.Writer) {
+			addAES(t, w, "secret.txt", "ab1", content)
+		}),
+	}
+	for name, path := range archives {
+		archive, err := OpenZip(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		defer archive.Close()
+		if !archive.Verify("ab1") {
+			t.Errorf("%s: the password is not verified", name)
+		}
+		// the check bytes let some wrong passwords through, not the content
+		for i := 0; i < 1000; i++ {
+			if candidate := fmt.Sprint(i); archive.Verify(candidate) {
+				t.Errorf("%s: wrong password %q verified", name, candidate)
+			}
+		}
+		keyspace, _ := algorithms.NewMaskKeyspace("a?l?d")
+		found, err := algorithms.BruteForceKeyspace(keyspace, 4, archive.Verify, nil)
+		if err != nil || found != "ab1" {
+			t.Errorf("%s: expected the brute force to find ab1, got %q (%v)", name, found, err)
+		}
+	}
+
+	plain := makeArchive(t, "plain.zip", func(w *zip.Writer) {
+		file, _ := w.Create("plain.txt")
+		file.Write(content)
+	})
+	if _, err := OpenZip(plain); err != ErrNotEncrypted {
+		t.Errorf("expected ErrNotEncrypted, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+//go:build linux
+
+package evdev
+
+import (
+	"os"
+	"strings"
+	"unsafe"
+)
+
+// OpenDevice opens the event device of the path and reads its name and the
+// ranges of its absolute axes.
+func OpenDevice(path string) (*Device, *os.File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	d := &Device{Path: path, Abs: make(map[uint16]AbsInfo)}
+	name := make([]byte, 256)
+	// EVIOCGNAME
+	if err := ioctl(file.Fd(), ioc(true, 'E', 0x06, uintptr(len(name))), uintptr(unsafe.Pointer(&name[0]))); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	d.Name = strings.TrimRight(string(name), "\x00")
+	// EVIOCGBIT(EV_ABS), the absolute axes of the device
+	var axes [8]byte
+	if err := ioctl(file.Fd(), ioc(true, 'E', 0x20+EvAbs, uintptr(len(axes))), uintptr(unsafe.Pointer(&axes[0]))); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	for code := uintptr(0); code < 64; code++ {
+		if axes[code/8]&(1<<(code%8)) == 0 {
+			continue
+		}
+		// EVIOCGABS, struct input_absinfo starting with the current value
+		var info [6]int32
+		if err := ioctl(file.Fd(), ioc(true, 'E', 0x40+code, unsafe.Sizeof(info)), uintptr(unsafe.Pointer(&info[0]))); err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		d.Abs[uint16(code)] = AbsInfo{
+			Minimum:    info[1],
+			Maximum:    info[2],
+			Fuzz:       info[3],
+			Flat:       info[4],
+			Resolution: info[5],
+		}
+	}
+	return d, file, nil
+}
@@ -0,0 +1,33 @@
+//go:build !linux
+
+package evdev
+
+import (
+	"fmt"
+	"os"
+)
+
+// OpenDevice opens the event device of the path, which is only supported
+// on Linux.
+func OpenDevice(path string) (*Device, *os.File, error) {
+	return nil, nil, fmt.Errorf("event devices are only supported on Linux")
+}
+
+// Uinput are the virtual devices replaying the events of a recording,
+// which are only supported on Linux.
+type Uinput struct{}
+
+// NewUinput returns an error out of Linux.
+func NewUinput(rec *Recording) (*Uinput, error) {
+	return nil, fmt.Errorf("uinput is only supported on Linux")
+}
+
+// Emit returns an error out of Linux.
+func (u *Uinput) Emit(e Event) error {
+	return fmt.Errorf("uinput is only supported on Linux")
+}
+
+// Close does nothing out of Linux.
+func (u *Uinput) Close() error {
+	return nil
+}
@@ -0,0 +1,125 @@
+// Package evdev records the input events of the Linux event devices
+// (/dev/input/event*), keyboards and mice, to a timestamped text file and
+// replays them through uinput at the original or a scaled speed.
+package evdev
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Event types of the kernel.
+const (
+	EvSyn = 0x00
+	EvKey = 0x01
+	EvRel = 0x02
+	EvAbs = 0x03
+	EvMsc = 0x04
+)
+
+var typeNames = map[uint16]string{
+	EvSyn: "EV_SYN",
+	EvKey: "EV_KEY",
+	EvRel: "EV_REL",
+	EvAbs: "EV_ABS",
+	EvMsc: "EV_MSC",
+}
+
+const (
+	// longSize is the size of a long of the kernel.
+	longSize = strconv.IntSize / 8
+	// eventSize is the size of a struct input_event: a struct timeval of
+	// two longs, the type, the code and the value.
+	eventSize = 2*longSize + 8
+)
+
+// Event is an input event of a device.
+type Event struct {
+	// Time is the time of the event since the start of the recording.
+	Time time.Duration
+	// Device is the index of the device in the recording.
+	Device int
+	Type   uint16
+	Code   uint16
+	Value  int32
+}
+
+// TypeName returns the name of the event type, its number if unknown.
+func TypeName(kind uint16) string {
+	if name, ok := typeNames[kind]; ok {
+		return name
+	}
+	return strconv.Itoa(int(kind))
+}
+
+// ParseType parses an event type, its name or its number.
+func ParseType(text string) (uint16, error) {
+	for kind, name := range typeNames {
+		if name == text {
+			return kind, nil
+		}
+	}
+	kind, err := strconv.ParseUint(text, 0, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid event type %q", text)
+	}
+	return uint16(kind), nil
+}
+
+// ReadEvent reads a struct input_event, from a device file or a synthetic
+// stream, and returns it with its timestamp.
+func ReadEvent(r io.Reader) (time.Time, Event, error) {
+	data := make([]byte, eventSize)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return time.Time{}, Event{}, err
+	}
+	var sec, usec int64
+	if longSize == 8 {
+		sec = int64(binary.NativeEndian.Uint64(data))
+		usec = int64(binary.NativeEndian.Uint64(data[8:]))
+	} else {
+		sec = int64(int32(binary.NativeEndian.Uint32(data)))
+		usec = int64(int32(binary.NativeEndian.Uint32(data[4:])))
+	}
+	e := Event{
+		Type:  binary.NativeEndian.Uint16(data[2*longSize:]),
+		Code:  binary.NativeEndian.Uint16(data[2*longSize+2:]),
+		Value: int32(binary.NativeEndian.Uint32(data[2*longSize+4:])),
+	}
+	return time.Unix(sec, usec*1000), e, nil
+}
+
+// appendEvent appends the struct input_event of the event with the
+// timestamp to the buffer, the zero time giving a zero timestamp.
+func appendEvent(buffer []byte, t time.Time, e Event) []byte {
+	var sec, usec int64
+	if !t.IsZero() {
+		sec, usec = t.Unix(), int64(t.Nanosecond()/1000)
+	}
+	if longSize == 8 {
+		buffer = binary.NativeEndian.AppendUint64(buffer, uint64(sec))
+		buffer = binary.NativeEndian.AppendUint64(buffer, uint64(usec))
+	} else {
+		buffer = binary.NativeEndian.AppendUint32(buffer, uint32(sec))
+		buffer = binary.NativeEndian.AppendUint32(buffer, uint32(usec))
+	}
+	buffer = binary.NativeEndian.AppendUint16(buffer, e.Type)
+	buffer = binary.NativeEndian.AppendUint16(buffer, e.Code)
+	return binary.NativeEndian.AppendUint32(buffer, uint32(e.Value))
+}
+
+// WriteEvent writes the event as a struct input_event without timestamp,
+// which the kernel sets, such as to uinput.
+func WriteEvent(w io.Writer, e Event) error {
+	_, err := w.Write(appendEvent(nil, time.Time{}, e))
+	return err
+}
+
+// Devices returns the paths of the event devices.
+func Devices() ([]string, error) {
+	return filepath.Glob("/dev/input/event*")
+}
@@ -0,0 +1,41 @@
+package evdev
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestEvent(t *testing.T) {
+	stamp := time.Unix(1700000000, 123456000)
+	e := Event{Type: EvRel, Code: 1, Value: -5}
+	data := appendEvent(nil, stamp, e)
+	if len(data) != eventSize {
+		t.Fatalf("expected %d bytes, got %d", eventSize, len(data))
+	}
+	read, decoded, err := ReadEvent(bytes.NewReader(data))
+	if err != nil || !read.Equal(stamp) || decoded != e {
+		t.Errorf("expected %v at %v, got %v at %v (%v)", e, stamp, decoded, read, err)
+	}
+
+	// the events written for uinput have no timestamp
+	var output bytes.Buffer
+	WriteEvent(&output, Event{Time: time.Second, Device: 2, Type: EvKey, Code: 30, Value: 1})
+	read, decoded, _ = ReadEvent(&output)
+	if read.Unix() != 0 || decoded != (Event{Type: EvKey, Code: 30, Value: 1}) {
+		t.Errorf("unexpected event %v at %v", decoded, read)
+	}
+	if _, _, err := ReadEvent(bytes.NewReader(data[:eventSize-1])); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected a truncated event, got %v", err)
+	}
+
+	for _, name := range []string{"EV_KEY", "1", "0x01"} {
+		if kind, err := ParseType(name); err != nil || kind != EvKey {
+			t.Errorf("expected %s to be EV_KEY, got %d (%v)", name, kind, err)
+		}
+	}
+	if TypeName(0x15) != "21" {
+		t.Errorf("expected the number of an unknown type, got %s", TypeName(0x15))
+	}
+}
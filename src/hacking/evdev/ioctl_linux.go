@@ -0,0 +1,26 @@
+//go:build linux
+
+package evdev
+
+import (
+	"syscall"
+)
+
+// ioc encodes the request of an ioctl in the generic layout of the kernel.
+func ioc(read bool, kind byte, number, size uintptr) uintptr {
+	request := size<<16 | uintptr(kind)<<8 | number
+	if read {
+		request |= 2 << 30
+	} else if size > 0 {
+		request |= 1 << 30
+	}
+	return request
+}
+
+func ioctl(fd uintptr, request uintptr, arg uintptr) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, arg)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
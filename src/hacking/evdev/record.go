@@ -0,0 +1,108 @@
+package evdev
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Recorder records the events of several devices to a single recording,
+// timed by their kernel timestamps from the first event.
+type Recorder struct {
+	output  *Writer
+	mutex   sync.Mutex
+	start   time.Time
+	events  uint64
+	err     error
+	stopped bool
+	closers []io.Closer
+	quit    sync.WaitGroup
+}
+
+// NewRecorder creates a recorder writing the recording to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{output: NewWriter(w)}
+}
+
+// Add records the events of the device read from the reader, its device
+// file or a synthetic stream of struct input_event, until its end or Stop.
+func (r *Recorder) Add(d *Device, events io.Reader) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	index, err := r.output.WriteDevice(d)
+	if err != nil {
+		return err
+	}
+	if closer, ok := events.(io.Closer); ok {
+		r.closers = append(r.closers, closer)
+	}
+	r.quit.Add(1)
+	go func() {
+		defer r.quit.Done()
+		for {
+			t, e, err := ReadEvent(events)
+			if err != nil {
+				// reading a closed device fails
+				if err != io.EOF {
+					r.fail(err)
+				}
+				return
+			}
+			e.Device = index
+			if err := r.write(t, e); err != nil {
+				r.fail(err)
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (r *Recorder) fail(err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.err == nil && !r.stopped {
+		r.err = err
+	}
+}
+
+// write writes the event of the timestamp.
+func (r *Recorder) write(t time.Time, e Event) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.start.IsZero() {
+		r.start = t
+	}
+	e.Time = max(t.Sub(r.start), 0)
+	r.events++
+	return r.output.WriteEvent(e)
+}
+
+// Events returns the number of events recorded so far.
+func (r *Recorder) Events() uint64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.events
+}
+
+// Stop closes the devices, which ends their recording.
+func (r *Recorder) Stop() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.stopped = true
+	for _, closer := range r.closers {
+		closer.Close()
+	}
+}
+
+// Wait waits for the end of the devices and writes the last events. It
+// returns the first error of the devices.
+func (r *Recorder) Wait() error {
+	r.quit.Wait()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if err := r.output.Flush(); err != nil && r.err == nil {
+		r.err = err
+	}
+	return r.err
+}
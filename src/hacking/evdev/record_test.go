@@ -0,0 +1,67 @@
+package evdev
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+// stream returns a synthetic device stream of the events at the times.
+func stream(start time.Time, events ...Event) []byte {
+	var data []byte
+	for _, e := range events {
+		data = appendEvent(data, start.Add(e.Time), e)
+	}
+	return data
+}
+
+func TestRecorder(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	var output bytes.Buffer
+	r := NewRecorder(&output)
+	keyboard := stream(start.Add(time.Second),
+		Event{Time: 0, Type: EvKey, Code: 30, Value: 1},
+		Event{Time: 0, Type: EvSyn},
+		Event{Time: 80 * time.Millisecond, Type: EvKey, Code: 30, Value: 0},
+		Event{Time: 80 * time.Millisecond, Type: EvSyn},
+	)
+	r.Add(&Device{Path: "keyboard", Name: "keyboard"}, bytes.NewReader(keyboard))
+	if err := r.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a device still open is closed by Stop
+	reader, writer := io.Pipe()
+	r.Add(&Device{Path: "mouse", Name: "mouse"}, reader)
+	writer.Write(stream(start.Add(1500*time.Millisecond), Event{Type: EvRel, Code: 0, Value: 7}))
+	for r.Events() < 5 {
+		time.Sleep(time.Millisecond)
+	}
+	r.Stop()
+	if err := r.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := ReadRecording(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Devices) != 2 || len(rec.Events) != 5 {
+		t.Fatalf("expected 2 devices and 5 events, got %d and %d", len(rec.Devices), len(rec.Events))
+	}
+	// the times are the ones since the first event
+	if e := rec.Events[2]; e.Time != 80*time.Millisecond || e.Code != 30 || e.Value != 0 {
+		t.Errorf("unexpected event %v", e)
+	}
+	if e := rec.Events[4]; e.Time != 500*time.Millisecond || e.Device != 1 || e.Value != 7 {
+		t.Errorf("unexpected event %v", e)
+	}
+
+	// a truncated stream is an error
+	r = NewRecorder(io.Discard)
+	r.Add(&Device{}, bytes.NewReader(keyboard[:eventSize+3]))
+	if err := r.Wait(); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected a truncated stream, got %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package evdev
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// header is the first line of a recording.
+const header = "# evdev recording"
+
+// AbsInfo is the range of an absolute axis, like a touchpad coordinate.
+type AbsInfo struct {
+	Minimum    int32
+	Maximum    int32
+	Fuzz       int32
+	Flat       int32
+	Resolution int32
+}
+
+// Device is a recorded device.
+type Device struct {
+	Path string
+	Name string
+	// Abs are the ranges of the absolute axes by code, if any.
+	Abs map[uint16]AbsInfo
+}
+
+// Recording is a recording read back: the devices and their events, in the
+// order of their times.
+type Recording struct {
+	Devices []*Device
+	Events  []Event
+}
+
+// Writer writes a recording, one line per device then per event:
+//
+//	# evdev recording
+//	device 0 "/dev/input/event3" "AT Translated Set 2 keyboard"
+//	abs 0 53 0 1920 0 0 0
+//	0.016004 0 EV_KEY 30 1
+//
+// An abs line is the code, minimum, maximum, fuzz, flat and resolution of
+// an absolute axis of a device. An event line is its time in seconds, its
+// device, type, code and value.
+type Writer struct {
+	output  *bufio.Writer
+	devices int
+}
+
+// NewWriter creates a writer of a recording to w.
+func NewWriter(w io.Writer) *Writer {
+	output := bufio.NewWriter(w)
+	output.WriteString(header + "\n")
+	return &Writer{output: output}
+}
+
+// WriteDevice writes the device, before its events, and returns its index.
+func (w *Writer) WriteDevice(d *Device) (int, error) {
+	index := w.devices
+	w.devices++
+	fmt.Fprintf(w.output, "device %d %s %s\n", index, strconv.Quote(d.Path), strconv.Quote(d.Name))
+	for code := uint16(0); code < 64; code++ {
+		if abs, ok := d.Abs[code]; ok {
+			fmt.Fprintf(w.output, "abs %d %d %d %d %d %d %d\n", index, code,
+				abs.Minimum, abs.Maximum, abs.Fuzz, abs.Flat, abs.Resolution)
+		}
+	}
+	return index, w.output.Flush()
+}
+
+// WriteEvent writes the event, flushed with the next synchronization one.
+func (w *Writer) WriteEvent(e Event) error {
+	_, err := fmt.Fprintf(w.output, "%.6f %d %s %d %d\n", e.Time.Seconds(), e.Device, TypeName(e.Type), e.Code, e.Value)
+	if err == nil && e.Type == EvSyn {
+		err = w.output.Flush()
+	}
+	return err
+}
+
+// Flush writes the buffered events.
+func (w *Writer) Flush() error {
+	return w.output.Flush()
+}
+
+// unquote parses the quoted string at the start of the text and returns
+// it with the rest of the text.
+func unquote(text string) (string, string, error) {
+	quoted, err := strconv.QuotedPrefix(text)
+	if err != nil {
+		return "", "", err
+	}
+	value, err := strconv.Unquote(quoted)
+	return value, strings.TrimSpace(text[len(quoted):]), err
+}
+
+// parseDevice parses the index, path and name of a device line.
+func parseDevice(text string) (int, *Device, error) {
+	fields := strings.SplitN(text, " ", 2)
+	if len(fields) != 2 {
+		return 0, nil, fmt.Errorf("invalid device")
+	}
+	index, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, nil, err
+	}
+	d := &Device{Abs: make(map[uint16]AbsInfo)}
+	path, rest, err := unquote(fields[1])
+	if err != nil {
+		return 0, nil, err
+	}
+	name, _, err := unquote(rest)
+	if err != nil {
+		return 0, nil, err
+	}
+	d.Path, d.Name = path, name
+	return index, d, nil
+}
+
+// ReadRecording reads a recording written by a Writer, the events being
+// sorted by time.
+func ReadRecording(r io.Reader) (*Recording, error) {
+	rec := &Recording{}
+	scanner := bufio.NewScanner(r)
+	number := 0
+	for scanner.Scan() {
+		number++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		fail := func(err error) (*Recording, error) {
+			return nil, fmt.Errorf("line %d: %s: %q", number, err, line)
+		}
+		switch {
+		case strings.HasPrefix(line, "device "):
+			index, d, err := parseDevice(line[len("device "):])
+			if err != nil {
+				return fail(err)
+			}
+			if index != len(rec.Devices) {
+				return fail(fmt.Errorf("unexpected device %d", index))
+			}
+			rec.Devices = append(rec.Devices, d)
+		case strings.HasPrefix(line, "abs "):
+			var index int
+			var code uint16
+			var abs AbsInfo
+			if _, err := fmt.Sscanf(line, "abs %d %d %d %d %d %d %d", &index, &code,
+				&abs.Minimum, &abs.Maximum, &abs.Fuzz, &abs.Flat, &abs.Resolution); err != nil {
+				return fail(err)
+			}
+			if index < 0 || index >= len(rec.Devices) {
+				return fail(fmt.Errorf("unknown device %d", index))
+			}
+			rec.Devices[index].Abs[code] = abs
+		default:
+			fields := strings.Fields(line)
+			if len(fields) != 5 {
+				return fail(fmt.Errorf("invalid event"))
+			}
+			seconds, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return fail(err)
+			}
+			e := Event{Time: time.Duration(seconds * float64(time.Second)).Round(time.Microsecond)}
+			if e.Device, err = strconv.Atoi(fields[1]); err != nil {
+				return fail(err)
+			}
+			if e.Device < 0 || e.Device >= len(rec.Devices) {
+				return fail(fmt.Errorf("unknown device %d", e.Device))
+			}
+			if e.Type, err = ParseType(fields[2]); err != nil {
+				return fail(err)
+			}
+			code, err := strconv.ParseUint(fields[3], 0, 16)
+			if err != nil {
+				return fail(err)
+			}
+			value, err := strconv.ParseInt(fields[4], 0, 32)
+			if err != nil {
+				return fail(err)
+			}
+			e.Code, e.Value = uint16(code), int32(value)
+			rec.Events = append(rec.Events, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(rec.Events, func(i, j int) bool {
+		return rec.Events[i].Time < rec.Events[j].Time
+	})
+	return rec, nil
+}
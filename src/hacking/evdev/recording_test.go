@@ -0,0 +1,65 @@
+package evdev
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecording(t *testing.T) {
+	var output bytes.Buffer
+	w := NewWriter(&output)
+	keyboard, _ := w.WriteDevice(&Device{Path: "/dev/input/event3", Name: `AT "keyboard"`})
+	touchpad, _ := w.WriteDevice(&Device{Path: "/dev/input/event7", Name: "touchpad",
+		Abs: map[uint16]AbsInfo{0: {Maximum: 1920, Resolution: 12}, 1: {Minimum: -5, Maximum: 1080}}})
+	events := []Event{
+		{Time: 0, Device: keyboard, Type: EvKey, Code: 30, Value: 1},
+		{Time: 0, Device: keyboard, Type: EvSyn},
+		{Time: 16004 * time.Microsecond, Device: touchpad, Type: EvAbs, Code: 0, Value: 960},
+		{Time: 16004 * time.Microsecond, Device: touchpad, Type: EvSyn},
+		// out of order between devices
+		{Time: 16000 * time.Microsecond, Device: keyboard, Type: EvKey, Code: 30, Value: 0},
+	}
+	for _, e := range events {
+		if err := w.WriteEvent(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Flush()
+	if !strings.Contains(output.String(), "0.016004 1 EV_ABS 0 960\n") {
+		t.Errorf("unexpected recording:\n%s", output.String())
+	}
+
+	rec, err := ReadRecording(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Devices) != 2 || rec.Devices[0].Name != `AT "keyboard"` || rec.Devices[1].Path != "/dev/input/event7" {
+		t.Fatalf("unexpected devices %+v", rec.Devices)
+	}
+	if abs := rec.Devices[1].Abs; len(abs) != 2 || abs[0].Resolution != 12 || abs[1].Minimum != -5 {
+		t.Errorf("unexpected ranges %+v", abs)
+	}
+	expected := []Event{events[0], events[1], events[4], events[2], events[3]}
+	if len(rec.Events) != len(expected) {
+		t.Fatalf("expected %d events, got %d", len(expected), len(rec.Events))
+	}
+	for i, e := range expected {
+		if rec.Events[i] != e {
+			t.Errorf("expected %v, got %v", e, rec.Events[i])
+		}
+	}
+
+	for _, invalid := range []string{
+		"0.1 0 EV_KEY 30 1",
+		"device 0 \"/dev/input/event0\"\n",
+		"device 1 \"a\" \"b\"",
+		"device 0 \"a\" \"b\"\n0.1 0 EV_KEY 30",
+		"device 0 \"a\" \"b\"\nabs 1 0 0 10 0 0 0",
+	} {
+		if _, err := ReadRecording(strings.NewReader(invalid)); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
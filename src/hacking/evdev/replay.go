@@ -0,0 +1,50 @@
+package evdev
+
+import (
+	"context"
+	"time"
+)
+
+// Player replays the events of a recording.
+type Player struct {
+	// Speed scales the pace of the recording, 2 replaying it twice as fast,
+	// 0 as fast as possible.
+	Speed float64
+}
+
+// NewPlayer creates a player at the given speed.
+func NewPlayer(speed float64) *Player {
+	return &Player{Speed: speed}
+}
+
+// Play calls emit on the events of the recording at their times scaled by
+// the speed, until the end of the recording, an error of emit or the
+// cancellation of the context.
+func (p *Player) Play(ctx context.Context, rec *Recording, emit func(Event) error) error {
+	start := time.Now()
+	// the timer is started and drained, then reset for every event
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+	for _, e := range rec.Events {
+		if p.Speed > 0 {
+			// the events are scheduled from the start not to drift
+			wait := time.Until(start.Add(time.Duration(float64(e.Time) / p.Speed)))
+			if wait > 0 {
+				timer.Reset(wait)
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := emit(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package evdev
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPlayer(t *testing.T) {
+	rec := &Recording{Devices: []*Device{{}}}
+	for i := 0; i < 5; i++ {
+		rec.Events = append(rec.Events, Event{Time: time.Duration(i) * 100 * time.Millisecond, Type: EvKey, Code: uint16(i)})
+	}
+
+	// the times are scaled by the speed
+	var times []time.Duration
+	start := time.Now()
+	err := NewPlayer(4).Play(context.Background(), rec, func(e Event) error {
+		if int(e.Code) != len(times) {
+			t.Errorf("unexpected event %v", e)
+		}
+		times = append(times, time.Since(start))
+		return nil
+	})
+	if err != nil || len(times) != 5 {
+		t.Fatalf("expected 5 events, got %d (%v)", len(times), err)
+	}
+	for i, elapsed := range times {
+		if expected := time.Duration(i) * 25 * time.Millisecond; elapsed < expected {
+			t.Errorf("event %d replayed after %v instead of %v", i, elapsed, expected)
+		}
+	}
+	if times[4] > 500*time.Millisecond {
+		t.Errorf("expected the replay to be 4 times faster, took %v", times[4])
+	}
+
+	// as fast as possible
+	start = time.Now()
+	NewPlayer(0).Play(context.Background(), rec, func(Event) error { return nil })
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("expected an immediate replay, took %v", elapsed)
+	}
+
+	// the replay stops on cancellation and errors
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	count := 0
+	err = NewPlayer(1).Play(ctx, rec, func(Event) error {
+		count++
+		return nil
+	})
+	if err != context.DeadlineExceeded || count != 2 {
+		t.Errorf("expected the replay to stop after 2 events, got %d (%v)", count, err)
+	}
+	failure := errors.New("device gone")
+	if err := NewPlayer(0).Play(context.Background(), rec, func(Event) error { return failure }); err != failure {
+		t.Errorf("expected the emit error, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+//go:build linux
+
+package evdev
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Uinput are the virtual devices replaying the events of a recording, one
+// per recorded device.
+type Uinput struct {
+	files []*os.File
+}
+
+// uinputBits are the UI_SET_*BIT requests enabling the codes of the event
+// types.
+var uinputBits = map[uint16]uintptr{
+	EvKey: 101,
+	EvRel: 102,
+	EvAbs: 103,
+	EvMsc: 104,
+}
+
+// NewUinput creates a virtual device through /dev/uinput for every device
+// of the recording, with the events it uses.
+func NewUinput(rec *Recording) (*Uinput, error) {
+	u := &Uinput{}
+	for i, d := range rec.Devices {
+		file, err := createDevice(d, i, rec.Events)
+		if err != nil {
+			u.Close()
+			return nil, fmt.Errorf("%s: %s", d.Name, err)
+		}
+		u.files = append(u.files, file)
+	}
+	// the virtual devices are given time to be set up by the system
+	time.Sleep(500 * time.Millisecond)
+	return u, nil
+}
+
+// createDevice creates the virtual device of the device of the index.
+func createDevice(d *Device, index int, events []Event) (*os.File, error) {
+	file, err := os.OpenFile("/dev/uinput", os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	fail := func(err error) (*os.File, error) {
+		file.Close()
+		return nil, err
+	}
+	enabled := make(map[[2]uint16]bool)
+	// struct uinput_user_dev: the name, the identifiers, ff_effects_max and
+	// the maximum, minimum, fuzz and flat of the absolute axes
+	var absmax, absmin, absfuzz, absflat [64]int32
+	for code, abs := range d.Abs {
+		if code < 64 {
+			absmax[code], absmin[code], absfuzz[code], absflat[code] = abs.Maximum, abs.Minimum, abs.Fuzz, abs.Flat
+		}
+	}
+	for _, e := range events {
+		if e.Device != index || e.Type == EvSyn || enabled[[2]uint16{e.Type, e.Code}] {
+			continue
+		}
+		bit, ok := uinputBits[e.Type]
+		if !ok {
+			continue
+		}
+		if !enabled[[2]uint16{e.Type, 0xffff}] {
+			enabled[[2]uint16{e.Type, 0xffff}] = true
+			// UI_SET_EVBIT
+			if err := ioctl(file.Fd(), ioc(false, 'U', 100, 4), uintptr(e.Type)); err != nil {
+				return fail(err)
+			}
+		}
+		enabled[[2]uint16{e.Type, e.Code}] = true
+		if err := ioctl(file.Fd(), ioc(false, 'U', bit, 4), uintptr(e.Code)); err != nil {
+			return fail(err)
+		}
+		// the axes without recorded range span the values replayed
+		if _, ok := d.Abs[e.Code]; e.Type == EvAbs && !ok && e.Code < 64 {
+			absmin[e.Code], absmax[e.Code] = min(absmin[e.Code], e.Value), max(absmax[e.Code], e.Value)
+		}
+	}
+	var setup bytes.Buffer
+	name := make([]byte, 80)
+	copy(name[:79], "replay "+d.Name)
+	setup.Write(name)
+	// BUS_VIRTUAL, vendor, product, version and ff_effects_max
+	binary.Write(&setup, binary.NativeEndian, []uint16{0x06, 0, 0, 1})
+	binary.Write(&setup, binary.NativeEndian, uint32(0))
+	for _, values := range [][64]int32{absmax, absmin, absfuzz, absflat} {
+		binary.Write(&setup, binary.NativeEndian, values)
+	}
+	if _, err := file.Write(setup.Bytes()); err != nil {
+		return fail(err)
+	}
+	// UI_DEV_CREATE
+	if err := ioctl(file.Fd(), ioc(false, 'U', 1, 0), 0); err != nil {
+		return fail(err)
+	}
+	return file, nil
+}
+
+// Emit emits the event on the virtual device of its device.
+func (u *Uinput) Emit(e Event) error {
+	if e.Device < 0 || e.Device >= len(u.files) {
+		return fmt.Errorf("unknown device %d", e.Device)
+	}
+	return WriteEvent(u.files[e.Device], e)
+}
+
+// Close destroys the virtual devices.
+func (u *Uinput) Close() error {
+	var first error
+	for _, file := range u.files {
+		// UI_DEV_DESTROY
+		ioctl(file.Fd(), ioc(false, 'U', 2, 0), 0)
+		if err := file.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	u.files = nil
+	return first
+}
@@ -0,0 +1,289 @@
+package hashes
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+const (
+	argon2d  = 0
+	argon2i  = 1
+	argon2id = 2
+
+	argon2Version = 0x13
+	argon2Slices  = 4
+	// number of 64 bits words in a block
+	argon2Words = 128
+)
+
+type argon2Block [argon2Words]uint64
+
+// argon2HashLong is the variable length hash function H' of Argon2.
+func argon2HashLong(size int, data ...[]byte) []byte {
+	length := binary.LittleEndian.AppendUint32(nil, uint32(size))
+	input := append([][]byte{length}, data...)
+	if size <= 64 {
+		return blake2b(size, input...)
+	}
+	var out []byte
+	v := blake2b(64, input...)
+	for size-len(out) > 64 {
+		out = append(out, v[:32]...)
+		if size-len(out) > 64 {
+			v = blake2b(64, v)
+		}
+	}
+	return append(out, blake2b(size-len(out), v)...)
+}
+
+func argon2Round(v []*uint64) {
+	mix := func(a, b, c, d *uint64) {
+		*a = *a + *b + 2*uint64(uint32(*a))*uint64(uint32(*b))
+		*d = bits.RotateLeft64(*d^*a, -32)
+		*c = *c + *d + 2*uint64(uint32(*c))*uint64(uint32(*d))
+		*b = bits.RotateLeft64(*b^*c, -24)
+		*a = *a + *b + 2*uint64(uint32(*a))*uint64(uint32(*b))
+		*d = bits.RotateLeft64(*d^*a, -16)
+		*c = *c + *d + 2*uint64(uint32(*c))*uint64(uint32(*d))
+		*b = bits.RotateLeft64(*b^*c, -63)
+	}
+	mix(v[0], v[4], v[8], v[12])
+	mix(v[1], v[5], v[9], v[13])
+	mix(v[2], v[6], v[10], v[14])
+	mix(v[3], v[7], v[11], v[15])
+	mix(v[0], v[5], v[10], v[15])
+	mix(v[1], v[6], v[11], v[12])
+	mix(v[2], v[7], v[8], v[13])
+	mix(v[3], v[4], v[9], v[14])
+}
+
+// argon2Compress is the compression function G of Argon2, xoring the
+// result into out if xor is set.
+func argon2Compress(out, x, y *argon2Block, xor bool) {
+	var r argon2Block
+	for i := range r {
+		r[i] = x[i] ^ y[i]
+	}
+	q := r
+	v := make([]*uint64, 16)
+	for row := 0; row < 8; row++ {
+		for i := range v {
+			v[i] = &q[16*row+i]
+		}
+		argon2Round(v)
+	}
+	for column := 0; column < 8; column++ {
+		for i := range v {
+			v[i] = &q[2*column+16*(i/2)+i%2]
+		}
+		argon2Round(v)
+	}
+	for i := range out {
+		if xor {
+			out[i] ^= q[i] ^ r[i]
+		} else {
+			out[i] = q[i] ^ r[i]
+		}
+	}
+}
+
+func argon2BlockBytes(block *argon2Block) []byte {
+	data := make([]byte, 8*argon2Words)
+	for i, word := range block {
+		binary.LittleEndian.PutUint64(data[8*i:], word)
+	}
+	return data
+}
+
+func argon2BytesBlock(data []byte, block *argon2Block) {
+	for i := range block {
+		block[i] = binary.LittleEndian.Uint64(data[8*i:])
+	}
+}
+
+// argon2Key derives a key of size bytes following RFC 9106, the memory
+// being in KiB.
+func argon2Key(mode int, password, salt, secret, data []byte, time, memory uint32, threads uint8, size int) []byte {
+	lanes := uint32(threads)
+	if memory < 8*lanes {
+		memory = 8 * lanes
+	}
+	segment := memory / (argon2Slices * lanes)
+	laneLength := segment * argon2Slices
+	memory = laneLength * lanes
+
+	le32 := func(v uint32) []byte {
+		return binary.LittleEndian.AppendUint32(nil, v)
+	}
+	h0 := blake2b(64, le32(lanes), le32(uint32(size)), le32(memory), le32(time),
+		le32(argon2Version), le32(uint32(mode)),
+		le32(uint32(len(password))), password, le32(uint32(len(salt))), salt,
+		le32(uint32(len(secret))), secret, le32(uint32(len(data))), data)
+
+	blocks := make([]argon2Block, memory)
+	for lane := uint32(0); lane < lanes; lane++ {
+		for i := uint32(0); i < 2; i++ {
+			first := argon2HashLong(8*argon2Words, h0, le32(i), le32(lane))
+			argon2BytesBlock(first, &blocks[lane*laneLength+i])
+		}
+	}
+
+	var zero, input, addresses argon2Block
+	for pass := uint32(0); pass < time; pass++ {
+		for slice := uint32(0); slice < argon2Slices; slice++ {
+			independent := mode == argon2i || (mode == argon2id && pass == 0 && slice < 2)
+			for lane := uint32(0); lane < lanes; lane++ {
+				next := func() {
+					input[6]++
+					argon2Compress(&addresses, &zero, &input, false)
+					argon2Compress(&addresses, &zero, &addresses, false)
+				}
+				start := uint32(0)
+				if independent {
+					input = argon2Block{}
+					input[0] = uint64(pass)
+					input[1] = uint64(lane)
+					input[2] = uint64(slice)
+					input[3] = uint64(memory)
+					input[4] = uint64(time)
+					input[5] = uint64(mode)
+				}
+				if pass == 0 && slice == 0 {
+					start = 2
+					if independent {
+						next()
+					}
+				}
+				for index := start; index < segment; index++ {
+					column := slice*segment + index
+					current := lane*laneLength + column
+					previous := current - 1
+					if column == 0 {
+						previous = current + laneLength - 1
+					}
+					var random uint64
+					if independent {
+						if index%argon2Words == 0 {
+							next()
+						}
+						random = addresses[index%argon2Words]
+					} else {
+						random = blocks[previous][0]
+					}
+					referenceLane := uint32(random>>32) % lanes
+					if pass == 0 && slice == 0 {
+						referenceLane = lane
+					}
+					var area uint32
+					if pass == 0 {
+						if referenceLane == lane {
+							area = slice*segment + index - 1
+						} else {
+							area = slice * segment
+							if index == 0 {
+								area--
+							}
+						}
+					} else {
+						if referenceLane == lane {
+							area = laneLength - segment + index - 1
+						} else {
+							area = laneLength - segment
+							if index == 0 {
+								area--
+							}
+						}
+					}
+					j1 := random & 0xffffffff
+					relative := uint64(area) - 1 - (uint64(area) * (j1 * j1 >> 32) >> 32)
+					begin := uint32(0)
+					if pass > 0 && slice != argon2Slices-1 {
+						begin = (slice + 1) * segment
+					}
+					reference := referenceLane*laneLength + (begin+uint32(relative))%laneLength
+					argon2Compress(&blocks[current], &blocks[previous], &blocks[reference], pass > 0)
+				}
+			}
+		}
+	}
+
+	final := blocks[laneLength-1]
+	for lane := uint32(1); lane < lanes; lane++ {
+		last := &blocks[lane*laneLength+laneLength-1]
+		for i := range final {
+			final[i] ^= last[i]
+		}
+	}
+	return argon2HashLong(size, argon2BlockBytes(&final))
+}
+
+// argon2 verifies the Argon2 hashes in the PHC string format, such as
+// $argon2id$v=19$m=65536,t=3,p=4$salt$hash.
+type argon2 struct {
+	text    string
+	mode    int
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	hash    []byte
+}
+
+func parseArgon2(text string) (*argon2, error) {
+	parts := strings.Split(text, "$")
+	if len(parts) != 6 || parts[0] != "" {
+		return nil, fmt.Errorf("invalid argon2 hash %q", text)
+	}
+	a := &argon2{text: text}
+	switch parts[1] {
+	case "argon2d":
+		a.mode = argon2d
+	case "argon2i":
+		a.mode = argon2i
+	case "argon2id":
+		a.mode = argon2id
+	default:
+		return nil, fmt.Errorf("invalid argon2 variant in %q", text)
+	}
+	if parts[2] != fmt.Sprintf("v=%d", argon2Version) {
+		return nil, fmt.Errorf("unsupported argon2 version in %q", text)
+	}
+	var threads uint32
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &a.memory, &a.time, &threads)
+	if err != nil || a.time == 0 || threads == 0 || threads > 255 {
+		return nil, fmt.Errorf("invalid argon2 parameters in %q", text)
+	}
+	a.threads = uint8(threads)
+	if a.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, fmt.Errorf("invalid argon2 salt in %q", text)
+	}
+	if a.hash, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(a.hash) < 4 {
+		return nil, fmt.Errorf("invalid argon2 hash in %q", text)
+	}
+	return a, nil
+}
+
+// Argon2id returns the Argon2id hash of the password in the PHC string
+// format, the memory being in KiB.
+func Argon2id(password string, salt []byte, time, memory uint32, threads uint8, size int) string {
+	hash := argon2Key(argon2id, []byte(password), salt, nil, nil, time, memory, threads, size)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2Version, memory, time, threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hash))
+}
+
+func (a *argon2) Kind() Kind {
+	return KindArgon2
+}
+
+func (a *argon2) String() string {
+	return a.text
+}
+
+func (a *argon2) Verify(password string) bool {
+	hash := argon2Key(a.mode, []byte(password), a.salt, nil, nil, a.time, a.memory, a.threads, len(a.hash))
+	return subtle.ConstantTimeCompare(hash, a.hash) == 1
+}
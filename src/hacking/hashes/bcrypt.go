@@ -0,0 +1,219 @@
+package hashes
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// bcryptEncoding is the base64 variant of bcrypt.
+var bcryptEncoding = base64.NewEncoding("./ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789").
+	WithPadding(base64.NoPadding)
+
+var (
+	piOnce sync.Once
+	piP    [18]uint32
+	piS    [4][256]uint32
+)
+
+// piWords computes the initial Blowfish subkeys: the hexadecimal digits of
+// the fractional part of pi, from Machin's formula.
+func piWords() {
+	const words = 18 + 4*256
+	precision := uint(32*words + 64)
+	one := new(big.Int).Lsh(big.NewInt(1), precision)
+	arctan := func(x int64) *big.Int {
+		term := new(big.Int).Div(one, big.NewInt(x))
+		sum := new(big.Int).Set(term)
+		square := big.NewInt(x * x)
+		t := new(big.Int)
+		for k := int64(1); term.Sign() != 0; k++ {
+			term.Div(term, square)
+			t.Div(term, big.NewInt(2*k+1))
+			if k%2 == 1 {
+				sum.Sub(sum, t)
+			} else {
+				sum.Add(sum, t)
+			}
+		}
+		return sum
+	}
+	pi := new(big.Int).Mul(arctan(5), big.NewInt(16))
+	pi.Sub(pi, new(big.Int).Mul(arctan(239), big.NewInt(4)))
+	pi.Sub(pi, new(big.Int).Mul(one, big.NewInt(3)))
+	mask := big.NewInt(0xffffffff)
+	word := func(i int) uint32 {
+		w := new(big.Int).Rsh(pi, precision-uint(32*(i+1)))
+		return uint32(w.And(w, mask).Uint64())
+	}
+	for i := range piP {
+		piP[i] = word(i)
+	}
+	for box := range piS {
+		for i := range piS[box] {
+			piS[box][i] = word(18 + 256*box + i)
+		}
+	}
+}
+
+// blowfish is the state of the Blowfish cipher.
+type blowfish struct {
+	p [18]uint32
+	s [4][256]uint32
+}
+
+func newBlowfish() *blowfish {
+	piOnce.Do(piWords)
+	return &blowfish{p: piP, s: piS}
+}
+
+func (b *blowfish) f(x uint32) uint32 {
+	return ((b.s[0][x>>24] + b.s[1][x>>16&0xff]) ^ b.s[2][x>>8&0xff]) + b.s[3][x&0xff]
+}
+
+func (b *blowfish) encrypt(l, r uint32) (uint32, uint32) {
+	for i := 0; i < 16; i += 2 {
+		l ^= b.p[i]
+		r ^= b.f(l)
+		r ^= b.p[i+1]
+		l ^= b.f(r)
+	}
+	l ^= b.p[16]
+	r ^= b.p[17]
+	return r, l
+}
+
+// stream returns the next big-endian word of the cyclic data.
+func stream(data []byte, position *int) uint32 {
+	var word uint32
+	for i := 0; i < 4; i++ {
+		word = word<<8 | uint32(data[*position])
+		*position = (*position + 1) % len(data)
+	}
+	return word
+}
+
+// expand is the key schedule of Blowfish, with the salt of eksblowfish
+// (no salt if nil).
+func (b *blowfish) expand(key, salt []byte) {
+	position := 0
+	for i := range b.p {
+		b.p[i] ^= stream(key, &position)
+	}
+	position = 0
+	var l, r uint32
+	next := func() {
+		if salt != nil {
+			l ^= stream(salt, &position)
+			r ^= stream(salt, &position)
+		}
+		l, r = b.encrypt(l, r)
+	}
+	for i := 0; i < len(b.p); i += 2 {
+		next()
+		b.p[i], b.p[i+1] = l, r
+	}
+	for box := range b.s {
+		for i := 0; i < len(b.s[box]); i += 2 {
+			next()
+			b.s[box][i], b.s[box][i+1] = l, r
+		}
+	}
+}
+
+// bcryptHash returns the 23 bytes of the bcrypt hash of the password.
+func bcryptHash(password []byte, cost int, salt []byte) []byte {
+	key := append(append([]byte(nil), password...), 0)
+	if len(key) > 72 {
+		key = key[:72]
+	}
+	state := newBlowfish()
+	state.expand(key, salt)
+	for i := 0; i < 1<<uint(cost); i++ {
+		state.expand(key, nil)
+		state.expand(salt, nil)
+	}
+	text := []byte("OrpheanBeholderScryDoubt")
+	words := make([]uint32, 6)
+	position := 0
+	for i := range words {
+		words[i] = stream(text, &position)
+	}
+	for i := 0; i < 64; i++ {
+		for j := 0; j < len(words); j += 2 {
+			words[j], words[j+1] = state.encrypt(words[j], words[j+1])
+		}
+	}
+	hash := make([]byte, 24)
+	for i, word := range words {
+		hash[4*i] = byte(word >> 24)
+		hash[4*i+1] = byte(word >> 16)
+		hash[4*i+2] = byte(word >> 8)
+		hash[4*i+3] = byte(word)
+	}
+	return hash[:23]
+}
+
+// bcrypt verifies the $2a$, $2b$ and $2y$ bcrypt hashes.
+type bcrypt struct {
+	text string
+	cost int
+	salt []byte
+	hash []byte
+}
+
+func parseBcrypt(text string) (*bcrypt, error) {
+	parts := strings.Split(text, "$")
+	if len(parts) != 4 || parts[0] != "" || (parts[1] != "2a" && parts[1] != "2b" && parts[1] != "2y") ||
+		len(parts[3]) != 53 {
+		return nil, fmt.Errorf("invalid bcrypt hash %q", text)
+	}
+	cost, err := strconv.Atoi(parts[2])
+	if err != nil || cost < 4 || cost > 31 {
+		return nil, fmt.Errorf("invalid bcrypt cost in %q", text)
+	}
+	salt, err := bcryptEncoding.DecodeString(parts[3][:22])
+	if err != nil {
+		return nil, fmt.Errorf("invalid bcrypt salt in %q", text)
+	}
+	hash, err := bcryptEncoding.DecodeString(parts[3][22:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid bcrypt hash in %q", text)
+	}
+	return &bcrypt{
+		text: text,
+		cost: cost,
+		salt: salt,
+		hash: hash,
+	}, nil
+}
+
+// Bcrypt returns the bcrypt hash of the password.
+func Bcrypt(password string, cost int, salt []byte) (string, error) {
+	if cost < 4 || cost > 31 {
+		return "", fmt.Errorf("invalid bcrypt cost %d", cost)
+	}
+	if len(salt) != 16 {
+		return "", fmt.Errorf("the bcrypt salt must be 16 bytes long")
+	}
+	hash := bcryptHash([]byte(password), cost, salt)
+	return fmt.Sprintf("$2b$%02d$%s%s", cost, bcryptEncoding.EncodeToString(salt),
+		bcryptEncoding.EncodeToString(hash)), nil
+}
+
+func (b *bcrypt) Kind() Kind {
+	return KindBcrypt
+}
+
+func (b *bcrypt) String() string {
+	return b.text
+}
+
+func (b *bcrypt) Verify(password string) bool {
+	hash := bcryptHash([]byte(password), b.cost, b.salt)
+	return subtle.ConstantTimeCompare(hash, b.hash) == 1
+}
@@ -0,0 +1,227 @@
+// Package hashes verifies password candidates against MD5, SHA-1,
+// SHA-256, SHA-512, NTLM, bcrypt, PBKDF2 and Argon2 hashes.
+package hashes
+
+import (
+	"crypto/md5"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+// Kind is a hash algorithm.
+type Kind int
+
+const (
+	// KindAuto detects the algorithm from the hash format.
+	KindAuto Kind = iota
+	KindMD5
+	KindSHA1
+	KindSHA256
+	KindSHA512
+	KindNTLM
+	KindBcrypt
+	KindPBKDF2
+	KindArgon2
+)
+
+var kindNames = map[Kind]string{
+	KindAuto:   "auto",
+	KindMD5:    "md5",
+	KindSHA1:   "sha1",
+	KindSHA256: "sha256",
+	KindSHA512: "sha512",
+	KindNTLM:   "ntlm",
+	KindBcrypt: "bcrypt",
+	KindPBKDF2: "pbkdf2",
+	KindArgon2: "argon2",
+}
+
+// String returns the name of the algorithm.
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// ParseKind returns the algorithm of the given name.
+func ParseKind(name string) (Kind, error) {
+	for kind, n := range kindNames {
+		if n == strings.ToLower(name) {
+			return kind, nil
+		}
+	}
+	return KindAuto, fmt.Errorf("unknown hash type %q", name)
+}
+
+// Hash is a hash a password can be verified against.
+type Hash interface {
+	Kind() Kind
+	// String returns the hash as parsed.
+	String() string
+	Verify(password string) bool
+}
+
+// Digest returns the unsalted digest of the password for MD5, SHA-1,
+// SHA-256, SHA-512 and NTLM, nil for the other algorithms.
+func Digest(kind Kind, password string) []byte {
+	switch kind {
+	case KindMD5:
+		sum := md5.Sum([]byte(password))
+		return sum[:]
+	case KindSHA1:
+		sum := sha1.Sum([]byte(password))
+		return sum[:]
+	case KindSHA256:
+		sum := sha256.Sum256([]byte(password))
+		return sum[:]
+	case KindSHA512:
+		sum := sha512.Sum512([]byte(password))
+		return sum[:]
+	case KindNTLM:
+		sum := ntlm(password)
+		return sum[:]
+	}
+	return nil
+}
+
+// digest verifies the unsalted hashes, in hexadecimal.
+type digest struct {
+	kind Kind
+	text string
+	sum  []byte
+}
+
+func (d *digest) Kind() Kind {
+	return d.kind
+}
+
+func (d *digest) String() string {
+	return d.text
+}
+
+func (d *digest) Verify(password string) bool {
+	return subtle.ConstantTimeCompare(Digest(d.kind, password), d.sum) == 1
+}
+
+// pbkdf2Hash verifies the PBKDF2 hashes in the passlib format
+// ($pbkdf2-sha256$rounds$salt$hash) or in the Django one
+// (pbkdf2_sha256$rounds$salt$hash).
+type pbkdf2Hash struct {
+	text   string
+	digest func() hash.Hash
+	rounds int
+	salt   []byte
+	hash   []byte
+}
+
+// passlibEncoding is the base64 variant of passlib, '.' replacing '+'.
+var passlibEncoding = base64.NewEncoding("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789./").
+	WithPadding(base64.NoPadding)
+
+func parsePBKDF2(text string) (*pbkdf2Hash, error) {
+	parts := strings.Split(text, "$")
+	django := len(parts) == 4 && strings.HasPrefix(parts[0], "pbkdf2_")
+	passlib := len(parts) == 5 && parts[0] == "" && strings.HasPrefix(parts[1], "pbkdf2")
+	if !django && !passlib {
+		return nil, fmt.Errorf("invalid pbkdf2 hash %q", text)
+	}
+	if passlib {
+		parts = parts[1:]
+	}
+	h := &pbkdf2Hash{text: text}
+	switch strings.TrimLeft(parts[0][len("pbkdf2"):], "-_") {
+	case "", "sha1":
+		h.digest = sha1.New
+	case "sha256":
+		h.digest = sha256.New
+	case "sha512":
+		h.digest = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported pbkdf2 digest in %q", text)
+	}
+	var err error
+	if h.rounds, err = strconv.Atoi(parts[1]); err != nil || h.rounds <= 0 {
+		return nil, fmt.Errorf("invalid pbkdf2 rounds in %q", text)
+	}
+	if django {
+		h.salt = []byte(parts[2])
+		h.hash, err = base64.StdEncoding.DecodeString(parts[3])
+	} else {
+		if h.salt, err = passlibEncoding.DecodeString(parts[2]); err != nil {
+			return nil, fmt.Errorf("invalid pbkdf2 salt in %q", text)
+		}
+		h.hash, err = passlibEncoding.DecodeString(parts[3])
+	}
+	if err != nil || len(h.hash) == 0 {
+		return nil, fmt.Errorf("invalid pbkdf2 hash in %q", text)
+	}
+	return h, nil
+}
+
+func (h *pbkdf2Hash) Kind() Kind {
+	return KindPBKDF2
+}
+
+func (h *pbkdf2Hash) String() string {
+	return h.text
+}
+
+func (h *pbkdf2Hash) Verify(password string) bool {
+	key, err := pbkdf2.Key(h.digest, password, h.salt, h.rounds, len(h.hash))
+	return err == nil && subtle.ConstantTimeCompare(key, h.hash) == 1
+}
+
+// detect returns the algorithm of a hash from its format, MD5 for the
+// 32 hexadecimal digits hashes.
+func detect(text string) Kind {
+	switch {
+	case strings.HasPrefix(text, "$2"):
+		return KindBcrypt
+	case strings.HasPrefix(text, "$argon2"):
+		return KindArgon2
+	case strings.HasPrefix(text, "$pbkdf2") || strings.HasPrefix(text, "pbkdf2_"):
+		return KindPBKDF2
+	}
+	switch len(text) {
+	case 2 * md5.Size:
+		return KindMD5
+	case 2 * sha1.Size:
+		return KindSHA1
+	case 2 * sha256.Size:
+		return KindSHA256
+	case 2 * sha512.Size:
+		return KindSHA512
+	}
+	return KindAuto
+}
+
+// Parse parses a hash of the given algorithm, detected from its format
+// with KindAuto. NTLM hashes can't be told from MD5 ones and need KindNTLM.
+func Parse(text string, kind Kind) (Hash, error) {
+	text = strings.TrimSpace(text)
+	if kind == KindAuto {
+		kind = detect(text)
+	}
+	switch kind {
+	case KindBcrypt:
+		return parseBcrypt(text)
+	case KindArgon2:
+		return parseArgon2(text)
+	case KindPBKDF2:
+		return parsePBKDF2(text)
+	case KindMD5, KindSHA1, KindSHA256, KindSHA512, KindNTLM:
+		sum, err := hex.DecodeString(text)
+		if err != nil || len(sum) != len(Digest(kind, "")) {
+			return nil, fmt.Errorf("invalid %s hash %q", kind, text)
+		}
+		return &digest{kind: kind, text: text, sum: sum}, nil
+	}
+	return nil, fmt.Errorf("unknown hash format %q", text)
+}
@@ -0,0 +1,172 @@
+package hashes
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrimitives(t *testing.T) {
+	for data, expected := range map[string]string{
+		"":                              "31d6cfe0d16ae931b73c59d7e0c089c0",
+		"abc":                           "a448017aaf21d8525fc10ae87aa6729d",
+		"message digest":                "d9130a8164549fe818874806e1c7014b",
+		strings.Repeat("1234567890", 8): "e33b4ddc9c38f2199c3e7b164fcc0536",
+	} {
+		if sum := md4([]byte(data)); hex.EncodeToString(sum[:]) != expected {
+			t.Errorf("md4(%q): expected %s, got %x", data, expected, sum)
+		}
+	}
+	if sum := ntlm("password"); hex.EncodeToString(sum[:]) != "8846f7eaee8fb117ad06bdd830b7586c" {
+		t.Errorf("unexpected NT hash %x", sum)
+	}
+	if sum := blake2b(64, []byte("abc")); hex.EncodeToString(sum) != "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923" {
+		t.Errorf("unexpected blake2b digest %x", sum)
+	}
+	if sum := blake2b(32, []byte(strings.Repeat("a", 200)), []byte(strings.Repeat("a", 100))); hex.EncodeToString(sum) != "3c1292de00a518e36823f9ff908ac2da46be38718c018713403461df077e15f6" {
+		t.Errorf("unexpected blake2b digest %x", sum)
+	}
+	cipher := newBlowfish()
+	cipher.expand(make([]byte, 8), nil)
+	if l, r := cipher.encrypt(0, 0); l != 0x4ef99745 || r != 0x6198dd78 {
+		t.Errorf("unexpected blowfish block %08x%08x", l, r)
+	}
+}
+
+func TestArgon2(t *testing.T) {
+	// RFC 9106 test vectors
+	password := []byte(strings.Repeat("\x01", 32))
+	salt := []byte(strings.Repeat("\x02", 16))
+	secret := []byte(strings.Repeat("\x03", 8))
+	data := []byte(strings.Repeat("\x04", 12))
+	for mode, expected := range map[int]string{
+		argon2d:  "512b391b6f1162975371d30919734294f868e3be3984f3c1a13a4db9fabe4acb",
+		argon2i:  "c814d9d1dc7f37aa13f0d77f2494bda1c8de6b016dd388d29952a4c4672b6ce8",
+		argon2id: "0d640df58d78766c08c037a34a8b53c9d01ef0452d75b65eb52520e96b01e659",
+	} {
+		if tag := argon2Key(mode, password, salt, secret, data, 3, 32, 4, 32); hex.EncodeToString(tag) != expected {
+			t.Errorf("argon2 mode %d: expected %s, got %x", mode, expected, tag)
+		}
+	}
+	text := Argon2id("secret", []byte("somesalt"), 2, 64, 1, 16)
+	h, err := Parse(text, KindAuto)
+	if err != nil || h.Kind() != KindArgon2 || !h.Verify("secret") || h.Verify("Secret") {
+		t.Errorf("unexpected argon2 hash %s (%v)", text, err)
+	}
+}
+
+func TestHashes(t *testing.T) {
+	for _, test := range []struct {
+		text     string
+		kind     Kind
+		password string
+	}{
+		{"5d41402abc4b2a76b9719d911017c592", KindMD5, "hello"},
+		{"aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", KindSHA1, "hello"},
+		{"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", KindSHA256, "hello"},
+		{"11853df40f4b2b919d3815f64792e58d08663767a494bcbb38c0b2389d9140bbb170281b4a847be7757bde12c9cd0054ce3652d0ad3a1a0c92babb69798246ee", KindSHA512, "world"},
+		// OpenWall crypt_blowfish test vectors
+		{"$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW", KindBcrypt, "U*U"},
+		{"$2a$05$CCCCCCCCCCCCCCCCCCCCC.VGOzA784oUp/Z0DY336zx7pLYAy0lwK", KindBcrypt, "U*U*"},
+		{"$2a$05$XXXXXXXXXXXXXXXXXXXXXOAcXxm9kjPGEMsLznoKqmqw7tc8WCx4a", KindBcrypt, "U*U*U"},
+		{"$2a$05$CCCCCCCCCCCCCCCCCCCCC.7uG0VCzI2bS7j6ymqJi9CdcdxiRTWNy", KindBcrypt, ""},
+		// RFC 6070
+		{"pbkdf2_sha1$1$salt$DGDID5YfDnHzqbUkr2ASBi/gN6Y=", KindPBKDF2, "password"},
+		{"$pbkdf2-sha256$1000$c2FsdHNhbHRzYWx0$sYIePhT5IXESDKvnouJXtE5pTJ6Znbmef4vViYmc9Uc", KindPBKDF2, "password"},
+		{"pbkdf2_sha256$2000$abcdefgh$C+xwVhK4IDzCVr0PYb4Kn+dCr75rX0cy8ZNp/hCUgbk=", KindPBKDF2, "letmein"},
+	} {
+		h, err := Parse(test.text, KindAuto)
+		if err != nil {
+			t.Errorf("unexpected error for %s: %v", test.text, err)
+			continue
+		}
+		if h.Kind() != test.kind || h.String() != test.text {
+			t.Errorf("unexpected hash %s %s", h.Kind(), h)
+		}
+		if !h.Verify(test.password) {
+			t.Errorf("%s doesn't verify %q", test.text, test.password)
+		}
+		if h.Verify(test.password + "x") {
+			t.Errorf("%s verifies %q", test.text, test.password+"x")
+		}
+	}
+	h, err := Parse("8846F7EAEE8FB117AD06BDD830B7586C", KindNTLM)
+	if err != nil || !h.Verify("password") {
+		t.Errorf("unexpected NTLM hash (%v)", err)
+	}
+	text, err := Bcrypt("secret", 4, []byte("0123456789abcdef"))
+	if h, _ := Parse(text, KindAuto); err != nil || h == nil || !h.Verify("secret") {
+		t.Errorf("unexpected bcrypt hash %s (%v)", text, err)
+	}
+	for _, invalid := range []string{"1234", "zz41402abc4b2a76b9719d911017c592", "$2a$99$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW", "$argon2id$v=16$m=8,t=1,p=1$c2FsdA$aGFzaA", "pbkdf2_md5$1$salt$aGFzaA=="} {
+		if _, err := Parse(invalid, KindAuto); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+	if kind, err := ParseKind("NTLM"); err != nil || kind != KindNTLM {
+		t.Errorf("unexpected kind %s (%v)", kind, err)
+	}
+}
+
+func TestTargets(t *testing.T) {
+	directory := t.TempDir()
+	hashes := filepath.Join(directory, "hashes.txt")
+	content := "# users\nalice:5d41402abc4b2a76b9719d911017c592\nbob:5d41402abc4b2a76b9719d911017c592\n\n" +
+		"aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d\n" +
+		"carol:$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW\n"
+	if err := os.WriteFile(hashes, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	targets, err := LoadTargets(hashes, KindAuto)
+	if err != nil || targets.Remaining() != 4 || targets.Targets()[0].User != "alice" {
+		t.Fatalf("unexpected targets (%v)", err)
+	}
+	potfile, err := OpenPotfile(filepath.Join(directory, "brute.potfile"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets.SetPotfile(potfile)
+	cracked, err := targets.Check("hello")
+	if err != nil || len(cracked) != 3 || targets.Remaining() != 1 {
+		t.Errorf("expected 3 cracked targets, got %d (%v)", len(cracked), err)
+	}
+	if cracked, _ := targets.Check("hello"); len(cracked) != 0 {
+		t.Errorf("expected no new cracked target, got %d", len(cracked))
+	}
+	if password, ok := targets.Targets()[1].Password(); !ok || password != "hello" {
+		t.Errorf("unexpected password %q", password)
+	}
+	if _, ok := targets.Targets()[3].Password(); ok {
+		t.Error("unexpected cracked bcrypt target")
+	}
+	if _, err := targets.Check("U*U"); err != nil || targets.Remaining() != 0 {
+		t.Errorf("expected all the targets to be cracked (%v)", err)
+	}
+	if targets.Hashes() == 0 {
+		t.Error("expected the hashes to be counted")
+	}
+	if err := potfile.Add("0123", "a:b\n"); err != nil {
+		t.Fatal(err)
+	}
+	potfile.Close()
+
+	// the potfile marks the targets as cracked on the next run
+	potfile, err = OpenPotfile(filepath.Join(directory, "brute.potfile"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer potfile.Close()
+	if password, ok := potfile.Lookup("0123"); !ok || password != "a:b\n" {
+		t.Errorf("unexpected potfile password %q", password)
+	}
+	targets, _ = LoadTargets(hashes, KindAuto)
+	targets.SetPotfile(potfile)
+	if targets.Remaining() != 0 {
+		t.Errorf("expected the potfile to crack the targets, %d remaining", targets.Remaining())
+	}
+	if _, err := ReadTargets(strings.NewReader("5d41402abc\n"), KindAuto); err == nil || !strings.HasPrefix(err.Error(), "line 1:") {
+		t.Errorf("expected an error at line 1, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package hashes
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"unicode/utf16"
+)
+
+// md4 returns the MD4 digest of the data (RFC 1320), only used by NTLM.
+func md4(data []byte) [16]byte {
+	length := uint64(len(data)) * 8
+	message := append(append([]byte(nil), data...), 0x80)
+	for len(message)%64 != 56 {
+		message = append(message, 0)
+	}
+	message = binary.LittleEndian.AppendUint64(message, length)
+
+	a, b, c, d := uint32(0x67452301), uint32(0xefcdab89), uint32(0x98badcfe), uint32(0x10325476)
+	var x [16]uint32
+	for block := 0; block < len(message); block += 64 {
+		for i := range x {
+			x[i] = binary.LittleEndian.Uint32(message[block+4*i:])
+		}
+		aa, bb, cc, dd := a, b, c, d
+		// round 1
+		for _, i := range []int{0, 4, 8, 12} {
+			a = bits.RotateLeft32(a+(b&c|^b&d)+x[i], 3)
+			d = bits.RotateLeft32(d+(a&b|^a&c)+x[i+1], 7)
+			c = bits.RotateLeft32(c+(d&a|^d&b)+x[i+2], 11)
+			b = bits.RotateLeft32(b+(c&d|^c&a)+x[i+3], 19)
+		}
+		// round 2
+		for _, i := range []int{0, 1, 2, 3} {
+			a = bits.RotateLeft32(a+(b&c|b&d|c&d)+x[i]+0x5a827999, 3)
+			d = bits.RotateLeft32(d+(a&b|a&c|b&c)+x[i+4]+0x5a827999, 5)
+			c = bits.RotateLeft32(c+(d&a|d&b|a&b)+x[i+8]+0x5a827999, 9)
+			b = bits.RotateLeft32(b+(c&d|c&a|d&a)+x[i+12]+0x5a827999, 13)
+		}
+		// round 3
+		for _, i := range []int{0, 2, 1, 3} {
+			a = bits.RotateLeft32(a+(b^c^d)+x[i]+0x6ed9eba1, 3)
+			d = bits.RotateLeft32(d+(a^b^c)+x[i+8]+0x6ed9eba1, 9)
+			c = bits.RotateLeft32(c+(d^a^b)+x[i+4]+0x6ed9eba1, 11)
+			b = bits.RotateLeft32(b+(c^d^a)+x[i+12]+0x6ed9eba1, 15)
+		}
+		a, b, c, d = a+aa, b+bb, c+cc, d+dd
+	}
+	var digest [16]byte
+	binary.LittleEndian.PutUint32(digest[0:], a)
+	binary.LittleEndian.PutUint32(digest[4:], b)
+	binary.LittleEndian.PutUint32(digest[8:], c)
+	binary.LittleEndian.PutUint32(digest[12:], d)
+	return digest
+}
+
+// ntlm returns the NT hash of a password: the MD4 digest of its UTF-16LE
+// encoding.
+func ntlm(password string) [16]byte {
+	encoded := utf16.Encode([]rune(password))
+	data := make([]byte, 2*len(encoded))
+	for i, u := range encoded {
+		binary.LittleEndian.PutUint16(data[2*i:], u)
+	}
+	return md4(data)
+}
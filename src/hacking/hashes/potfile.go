@@ -0,0 +1,93 @@
+package hashes
+
+import (
+	"bufio"
+	"encoding/hex"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Potfile stores the cracked hashes, one "hash:password" per line. The
+// passwords with ':' or non printable characters are written $HEX[...].
+type Potfile struct {
+	mutex   sync.Mutex
+	file    *os.File
+	entries map[string]string
+}
+
+// OpenPotfile loads the potfile, creating it if needed, and opens it for
+// appending.
+func OpenPotfile(path string) (*Potfile, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	p := &Potfile{
+		file:    file,
+		entries: make(map[string]string),
+	}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+		p.entries[line[:i]] = decodePassword(line[i+1:])
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func encodePassword(password string) string {
+	for _, c := range []byte(password) {
+		if c < 0x20 || c >= 0x7f || c == ':' {
+			return "$HEX[" + hex.EncodeToString([]byte(password)) + "]"
+		}
+	}
+	if strings.HasPrefix(password, "$HEX[") {
+		return "$HEX[" + hex.EncodeToString([]byte(password)) + "]"
+	}
+	return password
+}
+
+func decodePassword(text string) string {
+	if strings.HasPrefix(text, "$HEX[") && strings.HasSuffix(text, "]") {
+		if decoded, err := hex.DecodeString(text[5 : len(text)-1]); err == nil {
+			return string(decoded)
+		}
+	}
+	return text
+}
+
+// Lookup returns the password of a hash, if cracked.
+func (p *Potfile) Lookup(hash string) (string, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	password, ok := p.entries[hash]
+	return password, ok
+}
+
+// Add saves the password of a hash.
+func (p *Potfile) Add(hash, password string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if current, ok := p.entries[hash]; ok && current == password {
+		return nil
+	}
+	p.entries[hash] = password
+	if _, err := p.file.WriteString(hash + ":" + encodePassword(password) + "\n"); err != nil {
+		return err
+	}
+	return p.file.Sync()
+}
+
+// Close closes the potfile.
+func (p *Potfile) Close() error {
+	return p.file.Close()
+}
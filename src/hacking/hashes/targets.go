@@ -0,0 +1,177 @@
+package hashes
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Target is a hash to crack.
+type Target struct {
+	Hash Hash
+	// User is the name given before the hash in the hash file, if any.
+	User string
+
+	cracked  int32
+	password string
+}
+
+// Password returns the password of the target and whether it is cracked.
+func (t *Target) Password() (string, bool) {
+	if atomic.LoadInt32(&t.cracked) == 0 {
+		return "", false
+	}
+	return t.password, true
+}
+
+// Targets are the hashes checked by a single attack. The unsalted hashes
+// are looked up with a single digest of the candidate per algorithm.
+type Targets struct {
+	targets   []*Target
+	digests   map[Kind]map[string][]*Target
+	salted    []*Target
+	remaining int32
+	hashes    uint64
+	mutex     sync.Mutex
+	potfile   *Potfile
+}
+
+// NewTargets creates the targets of the given hashes.
+func NewTargets(hashes ...Hash) *Targets {
+	t := &Targets{
+		digests: make(map[Kind]map[string][]*Target),
+	}
+	for _, h := range hashes {
+		t.add(&Target{Hash: h})
+	}
+	return t
+}
+
+func (t *Targets) add(target *Target) {
+	t.targets = append(t.targets, target)
+	t.remaining++
+	if d, ok := target.Hash.(*digest); ok {
+		if t.digests[d.kind] == nil {
+			t.digests[d.kind] = make(map[string][]*Target)
+		}
+		t.digests[d.kind][string(d.sum)] = append(t.digests[d.kind][string(d.sum)], target)
+		return
+	}
+	t.salted = append(t.salted, target)
+}
+
+// ReadTargets reads one hash per line, optionally prefixed with a user
+// name and ':', skipping the empty lines and the comments starting with '#'.
+func ReadTargets(r io.Reader, kind Kind) (*Targets, error) {
+	t := NewTargets()
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		user := ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			user, line = line[:i], line[i+1:]
+		}
+		h, err := Parse(line, kind)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", number, err)
+		}
+		t.add(&Target{Hash: h, User: user})
+	}
+	return t, scanner.Err()
+}
+
+// LoadTargets reads the hashes of a file.
+func LoadTargets(path string, kind Kind) (*Targets, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadTargets(file, kind)
+}
+
+// Targets returns all the targets.
+func (t *Targets) Targets() []*Target {
+	return t.targets
+}
+
+// Remaining returns the number of targets not cracked yet.
+func (t *Targets) Remaining() int {
+	return int(atomic.LoadInt32(&t.remaining))
+}
+
+// Hashes returns the number of hashes computed so far.
+func (t *Targets) Hashes() uint64 {
+	return atomic.LoadUint64(&t.hashes)
+}
+
+// SetPotfile marks the targets found in the potfile as cracked and saves
+// the next cracked ones to it.
+func (t *Targets) SetPotfile(potfile *Potfile) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.potfile = nil
+	for _, target := range t.targets {
+		if password, ok := potfile.Lookup(target.Hash.String()); ok && target.Hash.Verify(password) {
+			t.crack(target, password)
+		}
+	}
+	t.potfile = potfile
+}
+
+// crack must be called with the mutex locked.
+func (t *Targets) crack(target *Target, password string) (bool, error) {
+	if atomic.LoadInt32(&target.cracked) != 0 {
+		return false, nil
+	}
+	target.password = password
+	atomic.StoreInt32(&target.cracked, 1)
+	atomic.AddInt32(&t.remaining, -1)
+	if t.potfile != nil {
+		return true, t.potfile.Add(target.Hash.String(), password)
+	}
+	return true, nil
+}
+
+// Check verifies the candidate against the targets not cracked yet and
+// returns the ones it cracks. It is safe for concurrent use.
+func (t *Targets) Check(candidate string) ([]*Target, error) {
+	var matches []*Target
+	for kind, digests := range t.digests {
+		atomic.AddUint64(&t.hashes, 1)
+		matches = append(matches, digests[string(Digest(kind, candidate))]...)
+	}
+	for _, target := range t.salted {
+		if atomic.LoadInt32(&target.cracked) != 0 {
+			continue
+		}
+		atomic.AddUint64(&t.hashes, 1)
+		if target.Hash.Verify(candidate) {
+			matches = append(matches, target)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	var cracked []*Target
+	var err error
+	for _, target := range matches {
+		ok, e := t.crack(target, candidate)
+		if ok {
+			cracked = append(cracked, target)
+		}
+		if e != nil {
+			err = e
+		}
+	}
+	return cracked, err
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"hacking/evdev"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func main() {
+	flag.Usage = func() {
+		// http://patorjk.com/software/taag/#p=display&f=Big
+		fmt.Fprintf(os.Stderr, ""+
+			`input [OPTIONS] [DEVICES]
+
+--------------------------------
+  _____                   _
+ |_   _|                 | |
+   | |  _ __  _ __  _   _| |_
+   | | | '_ \| '_ \| | | | __|
+  _| |_| | | | |_) | |_| | |_
+ |_____|_| |_| .__/ \__,_|\__|
+             | |
+             |_|
+--------------------------------
+
+Records the keyboard and mouse events of the Linux event devices and
+replays them through uinput, for UI test automation. It needs the read
+permission of /dev/input/event* to record and the write permission of
+/dev/uinput to replay, usually root.
+
+Usage:
+
+  input -list
+
+lists the event devices and their names.
+
+  input -record session.txt /dev/input/event3 /dev/input/event5
+
+records the events of the devices (all of them if none) to session.txt,
+until Ctrl+C or the end of -duration. Every line is an event: its time
+in seconds since the first one, its device, type, code and value.
+
+  input -replay session.txt -speed 2 -delay 3s
+
+replays session.txt twice as fast after 3 seconds, through a virtual
+device per recorded device. With -speed 0, the events are replayed as
+fast as possible. Ctrl+C stops the replay.
+
+Options:
+`)
+		flag.PrintDefaults()
+	}
+	list := flag.Bool("list", false, "list the event devices")
+	recordPath := flag.String("record", "", "file to record the events of the devices to")
+	duration := flag.Duration("duration", 0, "duration of the recording, until Ctrl+C if 0")
+	replayPath := flag.String("replay", "", "recording to replay")
+	speed := flag.Float64("speed", 1, "speed of the replay, 2 for twice as fast, 0 as fast as possible")
+	delay := flag.Duration("delay", 3*time.Second, "delay before the replay, to focus the window under test")
+	flag.Parse()
+
+	switch {
+	case *list:
+		listDevices()
+	case len(*recordPath) > 0:
+		record(*recordPath, flag.Args(), *duration)
+	case len(*replayPath) > 0:
+		replay(*replayPath, *speed, *delay)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func listDevices() {
+	paths, err := evdev.Devices()
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, path := range paths {
+		d, file, err := evdev.OpenDevice(path)
+		if err != nil {
+			fmt.Printf("%s\t%s\n", path, err)
+			continue
+		}
+		file.Close()
+		fmt.Printf("%s\t%s\n", path, d.Name)
+	}
+}
+
+func record(path string, devices []string, duration time.Duration) {
+	if len(devices) == 0 {
+		var err error
+		if devices, err = evdev.Devices(); err != nil {
+			log.Fatal(err)
+		}
+		if len(devices) == 0 {
+			log.Fatalf("no event device found in /dev/input")
+		}
+	}
+	// the recording holds every key typed, passwords included
+	output, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer output.Close()
+	r := evdev.NewRecorder(output)
+	for _, device := range devices {
+		d, file, err := evdev.OpenDevice(device)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("recording %s (%s)", device, d.Name)
+		if err := r.Add(d, file); err != nil {
+			log.Fatal(err)
+		}
+	}
+	log.Println("record (-record)", path)
+	log.Println("duration (-duration)", duration)
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	var timeout <-chan time.Time
+	if duration > 0 {
+		timeout = time.After(duration)
+	}
+	go func() {
+		select {
+		case <-interrupt:
+		case <-timeout:
+		}
+		log.Println("stopping the recording...")
+		r.Stop()
+	}()
+	if err := r.Wait(); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("events recorded", r.Events())
+}
+
+func replay(path string, speed float64, delay time.Duration) {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rec, err := evdev.ReadRecording(file)
+	file.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("replay (-replay)", path, len(rec.Devices), "devices", len(rec.Events), "events")
+	log.Println("speed (-speed)", speed)
+	u, err := evdev.NewUinput(rec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer u.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	log.Println("replaying in", delay, "(-delay)...")
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(delay):
+	}
+	start := time.Now()
+	if err := evdev.NewPlayer(speed).Play(ctx, rec, u.Emit); err != nil {
+		log.Println(err)
+		return
+	}
+	log.Println("replayed in", time.Since(start).Round(time.Millisecond))
+}
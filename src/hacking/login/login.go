@@ -0,0 +1,341 @@
+// Package login verifies password candidates against HTTP login forms and
+// basic authentication endpoints, for the authorized testing of your own
+// applications.
+package login
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// UserField is replaced by the username in a form.
+	UserField = "^USER^"
+	// PassField is replaced by the password candidate in a form.
+	PassField = "^PASS^"
+	// maxBody is the size of the response body read for the regexps.
+	maxBody = 1 << 20
+	// maxBackoff is the longest delay before a retry, unless the server
+	// asks for more.
+	maxBackoff = time.Minute
+	// maxRetryAfter is the longest Retry-After delay waited for.
+	maxRetryAfter = 10 * time.Minute
+)
+
+// Login is an HTTP login endpoint. Without a form, the candidates are sent
+// with basic authentication. The conditions set must all hold for a login
+// to succeed; with none, it succeeds on a 2xx status with basic
+// authentication. A form needs Redirect, Success or Failure, as many
+// applications redirect a failed login to the form again.
+type Login struct {
+	// URL is the URL the form is posted to, or the basic authentication one.
+	URL string
+	// Username is the username of the candidates.
+	Username string
+	// Form is the URL-encoded body of the login request, such as
+	// "user=^USER^&pass=^PASS^".
+	Form string
+
+	// CSRFField is the name of the form input holding the CSRF token,
+	// fetched from CSRFURL (URL if empty) before each login request.
+	CSRFField string
+	CSRFURL   string
+
+	// Status is the status of a successful login.
+	Status int
+	// Redirect is a part of the Location of a successful login.
+	Redirect string
+	// Success matches the body of a successful login.
+	Success *regexp.Regexp
+	// Failure matches the body of a failed login.
+	Failure *regexp.Regexp
+
+	// Rate is the maximum number of requests per second, unlimited if 0.
+	Rate float64
+	// Retries is the number of times a request failing is retried.
+	Retries int
+	// Backoff is the delay before the first retry, doubled at every retry,
+	// or the Retry-After delay of the response if longer, up to 10 minutes.
+	Backoff time.Duration
+	// Transport sends the requests, http.DefaultTransport if nil.
+	Transport http.RoundTripper
+
+	mutex    sync.Mutex
+	next     time.Time
+	requests uint64
+	errors   uint64
+	err      error
+}
+
+// NewLogin creates a login for the username on the endpoint, retrying
+// failing requests 3 times after 1, 2 and 4 seconds.
+func NewLogin(endpoint, username string) *Login {
+	return &Login{
+		URL:      endpoint,
+		Username: username,
+		Retries:  3,
+		Backoff:  time.Second,
+	}
+}
+
+// statusError is the response of a server overloaded or throttling the
+// requests, to retry after retryAfter if set.
+type statusError struct {
+	url        string
+	status     string
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return e.url + ": " + e.status
+}
+
+// parseRetryAfter returns the delay of a Retry-After header, in seconds or
+// as a date, 0 if not set.
+func parseRetryAfter(header string) time.Duration {
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+// wait blocks until the rate limit allows another request.
+func (l *Login) wait() {
+	if l.Rate <= 0 {
+		return
+	}
+	l.mutex.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	at := l.next
+	l.next = l.next.Add(time.Duration(float64(time.Second) / l.Rate))
+	l.mutex.Unlock()
+	time.Sleep(time.Until(at))
+}
+
+// client returns a client with its own cookies, which doesn't follow the
+// redirects.
+func (l *Login) client() *http.Client {
+	jar, _ := cookiejar.New(nil)
+	return &http.Client{
+		Transport: l.Transport,
+		Jar:       jar,
+		Timeout:   30 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func (l *Login) do(client *http.Client, request *http.Request) (*http.Response, []byte, error) {
+	l.wait()
+	atomic.AddUint64(&l.requests, 1)
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxBody))
+	return response, body, err
+}
+
+// csrf fetches the CSRF token, the session cookies being kept by the
+// client.
+func (l *Login) csrf(client *http.Client) (string, error) {
+	page := l.CSRFURL
+	if len(page) == 0 {
+		page = l.URL
+	}
+	request, err := http.NewRequest(http.MethodGet, page, nil)
+	if err != nil {
+		return "", err
+	}
+	response, body, err := l.do(client, request)
+	if err != nil {
+		return "", err
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("CSRF page %s: %s", page, response.Status)
+	}
+	return findInput(string(body), l.CSRFField)
+}
+
+var (
+	inputRegexp = regexp.MustCompile(`(?is)<input\s[^>]*>`)
+	nameRegexp  = regexp.MustCompile(`(?is)\sname\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	valueRegexp = regexp.MustCompile(`(?is)\svalue\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+)
+
+func attribute(re *regexp.Regexp, tag string) (string, bool) {
+	match := re.FindStringSubmatch(tag)
+	if match == nil {
+		return "", false
+	}
+	return html.UnescapeString(match[1] + match[2] + match[3]), true
+}
+
+// findInput returns the value of the input named name of the page.
+func findInput(page, name string) (string, error) {
+	for _, tag := range inputRegexp.FindAllString(page, -1) {
+		if n, ok := attribute(nameRegexp, tag); ok && n == name {
+			value, _ := attribute(valueRegexp, tag)
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("missing CSRF input %q", name)
+}
+
+// Check returns an error if the login can't tell a failed login, a form
+// without Redirect, Success or Failure.
+func (l *Login) Check() error {
+	if len(l.Form) > 0 && len(l.Redirect) == 0 && l.Success == nil && l.Failure == nil {
+		return fmt.Errorf("%s: a login form needs a redirect, success or failure condition", l.URL)
+	}
+	return nil
+}
+
+// Try sends the password and returns whether the login succeeded.
+func (l *Login) Try(password string) (bool, error) {
+	if err := l.Check(); err != nil {
+		return false, err
+	}
+	client := l.client()
+	var request *http.Request
+	var err error
+	if len(l.Form) > 0 {
+		form := strings.NewReplacer(UserField, url.QueryEscape(l.Username),
+			PassField, url.QueryEscape(password)).Replace(l.Form)
+		if len(l.CSRFField) > 0 {
+			token, err := l.csrf(client)
+			if err != nil {
+				return false, err
+			}
+			form += "&" + url.QueryEscape(l.CSRFField) + "=" + url.QueryEscape(token)
+		}
+		request, err = http.NewRequest(http.MethodPost, l.URL, strings.NewReader(form))
+		if err != nil {
+			return false, err
+		}
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		request, err = http.NewRequest(http.MethodGet, l.URL, nil)
+		if err != nil {
+			return false, err
+		}
+		request.SetBasicAuth(l.Username, password)
+	}
+	response, body, err := l.do(client, request)
+	if err != nil {
+		return false, err
+	}
+	if response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500 {
+		return false, &statusError{
+			url:        l.URL,
+			status:     response.Status,
+			retryAfter: parseRetryAfter(response.Header.Get("Retry-After")),
+		}
+	}
+	return l.succeeded(response, body), nil
+}
+
+func (l *Login) succeeded(response *http.Response, body []byte) bool {
+	redirect := response.StatusCode >= 300 && response.StatusCode < 400
+	conditions := false
+	if l.Status != 0 {
+		if response.StatusCode != l.Status {
+			return false
+		}
+		conditions = true
+	}
+	if len(l.Redirect) > 0 {
+		if !redirect || !strings.Contains(response.Header.Get("Location"), l.Redirect) {
+			return false
+		}
+		conditions = true
+	}
+	if l.Success != nil {
+		if !l.Success.Match(body) {
+			return false
+		}
+		conditions = true
+	}
+	if l.Failure != nil {
+		if l.Failure.Match(body) {
+			return false
+		}
+		conditions = true
+	}
+	if conditions {
+		return true
+	}
+	return response.StatusCode >= 200 && response.StatusCode < 300
+}
+
+// backoff returns the delay before the retry following the error.
+func (l *Login) backoff(retry int, err error) time.Duration {
+	delay := l.Backoff
+	for i := 0; i < retry && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxBackoff)
+	var status *statusError
+	if errors.As(err, &status) && status.retryAfter > delay {
+		delay = min(status.retryAfter, maxRetryAfter)
+	}
+	return delay
+}
+
+// Verify tries the password, retrying the failing requests with a backoff,
+// and returns whether the login succeeded. The candidates whose requests
+// kept failing, or all of them if the login fails Check, are counted by
+// Errors. It is safe for concurrent use.
+func (l *Login) Verify(password string) bool {
+	for retry := 0; ; retry++ {
+		ok, err := l.Try(password)
+		if err == nil {
+			return ok
+		}
+		if retry >= l.Retries || l.Check() != nil {
+			atomic.AddUint64(&l.errors, 1)
+			l.mutex.Lock()
+			l.err = err
+			l.mutex.Unlock()
+			return false
+		}
+		time.Sleep(l.backoff(retry, err))
+	}
+}
+
+// Requests returns the number of requests sent.
+func (l *Login) Requests() uint64 {
+	return atomic.LoadUint64(&l.requests)
+}
+
+// Errors returns the number of candidates not verified because of failing
+// requests, and the last error.
+func (l *Login) Errors() (uint64, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return atomic.LoadUint64(&l.errors), l.err
+}
+
+// String returns the username and the URL of the login.
+func (l *Login) String() string {
+	return l.Username + "@" + l.URL
+}
@@ -0,0 +1,217 @@
+package login
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"hacking/algorithms"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+// server is a test application with a CSRF protected login form and a
+// basic authentication endpoint.
+func server(t *testing.T, password string) *httptest.Server {
+	var mutex sync.Mutex
+	sessions := make(map[string]string)
+	var post http.HandlerFunc
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			post(w, r)
+			return
+		}
+		random := make([]byte, 8)
+		rand.Read(random)
+		session, token := hex.EncodeToString(random[:4]), hex.EncodeToString(random[4:])
+		mutex.Lock()
+		sessions[session] = token
+		mutex.Unlock()
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: session})
+		fmt.Fprintf(w, `<form method="post"><input type="text" name="user">
+<input type='hidden' value='%s' name='csrf_token' /><input type="password" name="pass"></form>`, token)
+	})
+	post = func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+		if err != nil {
+			http.Error(w, "no session", http.StatusForbidden)
+			return
+		}
+		mutex.Lock()
+		token, ok := sessions[cookie.Value]
+		delete(sessions, cookie.Value)
+		mutex.Unlock()
+		if !ok || r.PostFormValue("csrf_token") != token {
+			http.Error(w, "invalid CSRF token", http.StatusForbidden)
+			return
+		}
+		if r.PostFormValue("user") == "admin" && r.PostFormValue("pass") == password {
+			http.Redirect(w, r, "/home", http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, "invalid username or password")
+	}
+	mux.HandleFunc("/basic", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "admin" || pass != password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "welcome admin")
+	})
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestLogin(t *testing.T) {
+	s := server(t, "p&7")
+
+	form := NewLogin(s.URL+"/login", "admin")
+	form.Form = "user=^USER^&pass=^PASS^"
+	form.CSRFField = "csrf_token"
+	form.Redirect = "/home"
+	basic := NewLogin(s.URL+"/basic", "admin")
+	body := NewLogin(s.URL+"/basic", "admin")
+	body.Status = http.StatusOK
+	body.Success = regexp.MustCompile("welcome")
+	failure := NewLogin(s.URL+"/login", "admin")
+	failure.Form = form.Form
+	failure.CSRFField = form.CSRFField
+	failure.Redirect = "/home"
+	failure.Failure = regexp.MustCompile("invalid")
+
+	for name, login := range map[string]*Login{"form": form, "basic": basic, "body": body, "failure": failure} {
+		for _, candidate := range []string{"p", "p&", "P&7", "p&7 "} {
+			if ok, err := login.Try(candidate); ok || err != nil {
+				t.Errorf("%s: wrong password %q verified (%v)", name, candidate, err)
+			}
+		}
+		if ok, err := login.Try("p&7"); !ok || err != nil {
+			t.Errorf("%s: the password is not verified (%v)", name, err)
+		}
+	}
+
+	// without the CSRF token, the server rejects every request
+	noCSRF := NewLogin(s.URL+"/login", "admin")
+	noCSRF.Form = form.Form
+	noCSRF.Redirect = form.Redirect
+	if noCSRF.Verify("p&7") {
+		t.Errorf("the password is verified without CSRF token")
+	}
+	if errors, _ := noCSRF.Errors(); errors != 0 {
+		t.Errorf("expected no errors, got %d", errors)
+	}
+
+	down := NewLogin("http://127.0.0.1:1/login", "admin")
+	down.Retries = 1
+	down.Backoff = time.Millisecond
+	if down.Verify("p&7") || down.Requests() != 2 {
+		t.Errorf("expected 2 failing requests, got %d", down.Requests())
+	}
+	if errors, err := down.Errors(); errors != 1 || err == nil {
+		t.Errorf("expected 1 error, got %d (%v)", errors, err)
+	}
+
+	// a form can't tell a failed login without condition
+	unconditional := NewLogin(s.URL+"/login", "admin")
+	unconditional.Form = form.Form
+	if _, err := unconditional.Try("p&7"); err == nil {
+		t.Errorf("expected an error for a form without condition")
+	}
+	if unconditional.Verify("p&7") || unconditional.Requests() != 0 {
+		t.Errorf("expected no request for a form without condition, got %d", unconditional.Requests())
+	}
+	if errors, err := unconditional.Errors(); errors != 1 || err == nil {
+		t.Errorf("expected 1 error, got %d (%v)", errors, err)
+	}
+
+	keyspace, _ := algorithms.NewMaskKeyspace("p?s?d")
+	found, err := algorithms.BruteForceKeyspace(keyspace, 4, form.Verify, nil)
+	if err != nil || found != "p&7" {
+		t.Errorf("expected the brute force to find p&7, got %q (%v)", found, err)
+	}
+
+	wordlist := filepath.Join(t.TempDir(), "words.txt")
+	os.WriteFile(wordlist, []byte("admin\npassword\nP&7\nletmein\n"), 0600)
+	rules, _ := algorithms.ParseRules([]string{":", "l"})
+	found, err = algorithms.Dictionary([]string{wordlist}, rules, 2, basic.Verify)
+	if err != nil || found != "p&7" {
+		t.Errorf("expected the dictionary to find p&7, got %q (%v)", found, err)
+	}
+}
+
+func TestRate(t *testing.T) {
+	s := server(t, "secret")
+	login := NewLogin(s.URL+"/basic", "admin")
+	login.Rate = 50
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				login.Verify(fmt.Sprint(i, j))
+			}
+		}(i)
+	}
+	wg.Wait()
+	// 20 requests at 50 per second, the first one being immediate
+	if elapsed := time.Since(start); elapsed < 380*time.Millisecond {
+		t.Errorf("expected 20 requests to take at least 380ms, took %s", elapsed)
+	}
+	if login.Requests() != 20 {
+		t.Errorf("expected 20 requests, got %d", login.Requests())
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	var mutex sync.Mutex
+	requests := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests++
+		count := requests
+		mutex.Unlock()
+		switch count {
+		case 1:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		default:
+			fmt.Fprint(w, "welcome admin")
+		}
+	}))
+	defer s.Close()
+	login := NewLogin(s.URL, "admin")
+	login.Backoff = 50 * time.Millisecond
+	start := time.Now()
+	if !login.Verify("secret") {
+		t.Fatalf("expected the password to be verified after the retries")
+	}
+	// 50ms after the 503, then the second of the Retry-After
+	if elapsed := time.Since(start); elapsed < time.Second+50*time.Millisecond {
+		t.Errorf("expected the retries to take at least 1.05s, took %s", elapsed)
+	}
+	if errors, _ := login.Errors(); errors != 0 || login.Requests() != 3 {
+		t.Errorf("expected 3 requests without error, got %d requests and %d errors", login.Requests(), errors)
+	}
+	if delay := login.backoff(20, nil); delay != maxBackoff {
+		t.Errorf("expected the backoff to be capped to %s, got %s", maxBackoff, delay)
+	}
+	if delay := login.backoff(0, &statusError{retryAfter: 24 * time.Hour}); delay != maxRetryAfter {
+		t.Errorf("expected the Retry-After delay to be capped to %s, got %s", maxRetryAfter, delay)
+	}
+	if delay := parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); delay < 59*time.Minute {
+		t.Errorf("unexpected Retry-After delay %s", delay)
+	}
+}
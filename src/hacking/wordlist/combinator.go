@@ -0,0 +1,69 @@
+package wordlist
+
+import (
+	"hacking/algorithms"
+	"io"
+)
+
+// ReadAll returns the words of the reader, one per line.
+func ReadAll(r io.Reader) ([]string, error) {
+	var words []string
+	_, err := algorithms.ReadWords(r, func(word string) bool {
+		words = append(words, word)
+		return true
+	})
+	return words, err
+}
+
+// Combine calls emit on every word of left joined by the separator to every
+// word of right, like the combinator attack of hashcat, until emit returns
+// false. The left words are streamed, so that only the right ones must fit
+// in memory. It returns false if emit did.
+func Combine(left io.Reader, right []string, separator string, emit func(string) bool) (bool, error) {
+	return algorithms.ReadWords(left, func(word string) bool {
+		for _, other := range right {
+			if !emit(word + separator + other) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Permute calls emit on every arrangement of 1 to depth distinct words of
+// the list joined by the separator, shortest first, until emit returns
+// false. It returns false if emit did.
+func Permute(words []string, depth int, separator string, emit func(string) bool) bool {
+	used := make([]bool, len(words))
+	var arrange func(prefix string, left int) bool
+	arrange = func(prefix string, left int) bool {
+		for i, word := range words {
+			if used[i] {
+				continue
+			}
+			candidate := word
+			if len(prefix) > 0 {
+				candidate = prefix + separator + word
+			}
+			if left == 1 {
+				if !emit(candidate) {
+					return false
+				}
+				continue
+			}
+			used[i] = true
+			more := arrange(candidate, left-1)
+			used[i] = false
+			if !more {
+				return false
+			}
+		}
+		return true
+	}
+	for length := 1; length <= depth && length <= len(words); length++ {
+		if !arrange("", length) {
+			return false
+		}
+	}
+	return true
+}
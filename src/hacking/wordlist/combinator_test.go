@@ -0,0 +1,46 @@
+package wordlist
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCombine(t *testing.T) {
+	var combined []string
+	more, err := Combine(strings.NewReader("red\nblue\n"), []string{"cat", "dog"}, "-", func(word string) bool {
+		combined = append(combined, word)
+		return true
+	})
+	expected := "red-cat red-dog blue-cat blue-dog"
+	if err != nil || !more || strings.Join(combined, " ") != expected {
+		t.Errorf("expected %s, got %v (%v)", expected, combined, err)
+	}
+	count := 0
+	more, _ = Combine(strings.NewReader("red\nblue\n"), []string{"cat", "dog"}, "", func(word string) bool {
+		count++
+		return count < 3
+	})
+	if more || count != 3 {
+		t.Errorf("expected the combination to stop after 3 words, got %d", count)
+	}
+}
+
+func TestPermute(t *testing.T) {
+	var permuted []string
+	Permute([]string{"a", "b", "c"}, 2, "", func(word string) bool {
+		permuted = append(permuted, word)
+		return true
+	})
+	expected := "a b c ab ac ba bc ca cb"
+	if strings.Join(permuted, " ") != expected {
+		t.Errorf("expected %s, got %v", expected, permuted)
+	}
+	count := 0
+	Permute([]string{"a", "b", "c", "d"}, 4, "", func(string) bool {
+		count++
+		return true
+	})
+	if count != 4+12+24+24 {
+		t.Errorf("expected 64 arrangements, got %d", count)
+	}
+}
@@ -0,0 +1,90 @@
+package wordlist
+
+import (
+	"fmt"
+	"hacking/algorithms"
+	"strings"
+	"unicode/utf8"
+)
+
+// Filter keeps the words of a length range made of a charset, such as the
+// ones meeting a password policy.
+type Filter struct {
+	// MinLength and MaxLength are the length range in characters, 0 for
+	// no bound.
+	MinLength int
+	MaxLength int
+	// Charset are the bytes allowed, all if empty.
+	Charset string
+	// Required are charsets the words must each contain a byte of.
+	Required []string
+}
+
+// NewFilter creates the filter of the length range and of the charsets,
+// which may use the built-in ones of the masks, like "?l?d_". The required
+// charsets are comma-separated, such as "?u,?d" for an uppercase letter and
+// a digit.
+func NewFilter(min, max int, charset, required string) (*Filter, error) {
+	if min < 0 || max < 0 || (max > 0 && max < min) {
+		return nil, fmt.Errorf("invalid length range %d-%d", min, max)
+	}
+	f := &Filter{MinLength: min, MaxLength: max}
+	if len(charset) > 0 {
+		expanded, err := algorithms.ExpandCharset(charset)
+		if err != nil {
+			return nil, err
+		}
+		f.Charset = expanded
+	}
+	if len(required) > 0 {
+		for _, class := range strings.Split(required, ",") {
+			expanded, err := algorithms.ExpandCharset(class)
+			if err != nil {
+				return nil, err
+			}
+			f.Required = append(f.Required, expanded)
+		}
+	}
+	return f, nil
+}
+
+// Match tells whether the filter keeps the word.
+func (f *Filter) Match(word string) bool {
+	length := utf8.RuneCountInString(word)
+	if length < f.MinLength || (f.MaxLength > 0 && length > f.MaxLength) {
+		return false
+	}
+	if len(f.Charset) > 0 {
+		for i := 0; i < len(word); i++ {
+			if strings.IndexByte(f.Charset, word[i]) < 0 {
+				return false
+			}
+		}
+	}
+	for _, class := range f.Required {
+		if !containsByte(word, class) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsByte tells whether the word contains a byte of the charset.
+func containsByte(word, charset string) bool {
+	for i := 0; i < len(word); i++ {
+		if strings.IndexByte(charset, word[i]) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns the emit function of the words kept by the filter.
+func (f *Filter) Apply(emit func(string) bool) func(string) bool {
+	return func(word string) bool {
+		if !f.Match(word) {
+			return true
+		}
+		return emit(word)
+	}
+}
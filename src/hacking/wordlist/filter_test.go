@@ -0,0 +1,44 @@
+package wordlist
+
+import (
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	f, err := NewFilter(6, 10, "?l?u?d!", "?u,?d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for word, expected := range map[string]bool{
+		"Secret1":      true,
+		"Secret1!":     true,
+		"Sec1":         false,
+		"secret1":      false,
+		"SecretSecre1": false,
+		"Secret 1":     false,
+	} {
+		if f.Match(word) != expected {
+			t.Errorf("%q: expected %v", word, expected)
+		}
+	}
+	// the length is in characters
+	if f, _ := NewFilter(2, 2, "", ""); !f.Match("éé") || f.Match("abc") {
+		t.Error("expected the length to be counted in characters")
+	}
+	var kept []string
+	emit := f.Apply(func(word string) bool {
+		kept = append(kept, word)
+		return true
+	})
+	emit("short")
+	emit("Passw0rd")
+	if len(kept) != 1 || kept[0] != "Passw0rd" {
+		t.Errorf("unexpected words kept %v", kept)
+	}
+	if _, err := NewFilter(5, 3, "", ""); err == nil {
+		t.Error("expected an error for an invalid length range")
+	}
+	if _, err := NewFilter(0, 0, "?z", ""); err == nil {
+		t.Error("expected an error for an unknown charset")
+	}
+}
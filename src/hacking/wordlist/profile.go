@@ -0,0 +1,153 @@
+package wordlist
+
+import (
+	"fmt"
+	"hacking/algorithms"
+	"strings"
+	"time"
+)
+
+// DefaultSuffixes are appended to the candidates of a profile without
+// suffixes.
+var DefaultSuffixes = []string{"1", "12", "123", "1234", "!", "01", "69", "007", "@", "#"}
+
+// profileRules are the variations of the keywords of a profile: case
+// changes and leetspeak.
+var profileRules = []string{"l", "c", "u", "lsa@se3si1so0", "csa@se3si1so0"}
+
+// Profile is what is known of a target, generating its likely passwords
+// like the personal wordlist generators.
+type Profile struct {
+	// Names are first names, last names, nicknames, children or pets.
+	Names []string
+	// Dates are birthdays or anniversaries, as YYYY-MM-DD.
+	Dates []string
+	// Words are the company, products, hobbies or places of the target.
+	Words []string
+	// Suffixes are appended to the candidates, DefaultSuffixes if nil.
+	Suffixes []string
+	// Filter keeps the candidates generated, all of them if nil.
+	Filter *Filter
+}
+
+// NewProfile creates the profile of the keywords.
+func NewProfile(names, dates, words []string) *Profile {
+	return &Profile{
+		Names: names,
+		Dates: dates,
+		Words: words,
+	}
+}
+
+// dateParts returns the forms of the date found in passwords, such as
+// 1990, 90, 1705 and 17051990 for 1990-05-17.
+func dateParts(date string) ([]string, error) {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", date)
+	}
+	forms := []string{"2006", "06", "0201", "0102", "020106", "02012006", "01022006", "20060102"}
+	parts := make([]string, len(forms))
+	for i, form := range forms {
+		parts[i] = t.Format(form)
+	}
+	return parts, nil
+}
+
+// Generate calls emit on the candidates of the profile, the simplest
+// first and without duplicates, until emit returns false: the keywords and
+// their variations, the dates, then the keywords followed by the suffixes,
+// by the dates, by the dates and the suffixes, and the pairs of keywords.
+// It returns false if emit did.
+func (p *Profile) Generate(emit func(string) bool) (bool, error) {
+	rules, err := algorithms.ParseRules(profileRules)
+	if err != nil {
+		return false, err
+	}
+	suffixes := p.Suffixes
+	if suffixes == nil {
+		suffixes = DefaultSuffixes
+	}
+	var dates []string
+	for _, date := range p.Dates {
+		parts, err := dateParts(date)
+		if err != nil {
+			return false, err
+		}
+		dates = append(dates, parts...)
+	}
+	// the variations of the keywords, and their lowercase and capitalized
+	// forms for the pairs
+	var variants, forms [][]string
+	for _, keyword := range append(append([]string{}, p.Names...), p.Words...) {
+		keyword = strings.Join(strings.Fields(keyword), "")
+		if len(keyword) == 0 {
+			continue
+		}
+		var variations []string
+		algorithms.Mangle(keyword, rules, func(variation string) bool {
+			variations = append(variations, variation)
+			return true
+		})
+		variants = append(variants, variations)
+		forms = append(forms, variations[:min(2, len(variations))])
+	}
+
+	seen := make(map[string]bool)
+	add := func(candidate string) bool {
+		if seen[candidate] {
+			return true
+		}
+		seen[candidate] = true
+		if p.Filter != nil && !p.Filter.Match(candidate) {
+			return true
+		}
+		return emit(candidate)
+	}
+	// each step combines the variations with the endings
+	steps := [][]string{{""}, suffixes, dates}
+	var both []string
+	for _, date := range dates {
+		for _, suffix := range suffixes {
+			both = append(both, date+suffix)
+		}
+	}
+	steps = append(steps, both)
+	for i, endings := range steps {
+		for _, variations := range variants {
+			for _, variation := range variations {
+				for _, ending := range endings {
+					if !add(variation + ending) {
+						return false, nil
+					}
+				}
+			}
+		}
+		if i == 0 {
+			for _, date := range dates {
+				if !add(date) {
+					return false, nil
+				}
+			}
+		}
+	}
+	for _, endings := range [][]string{{""}, suffixes, dates} {
+		for i, first := range forms {
+			for j, second := range forms {
+				if i == j {
+					continue
+				}
+				for _, a := range first {
+					for _, b := range second {
+						for _, ending := range endings {
+							if !add(a + b + ending) {
+								return false, nil
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,50 @@
+package wordlist
+
+import (
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	p := NewProfile([]string{"Alice", "rex"}, []string{"1990-05-17"}, []string{"acme corp"})
+	var candidates []string
+	seen := make(map[string]bool)
+	more, err := p.Generate(func(candidate string) bool {
+		if seen[candidate] {
+			t.Errorf("%q generated twice", candidate)
+		}
+		seen[candidate] = true
+		candidates = append(candidates, candidate)
+		return true
+	})
+	if err != nil || !more {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"alice", "Alice", "ALICE", "@l1c3", "Al1c3", "acmecorp", "1990", "17051990",
+		"alice123", "Rex1990", "rex0517", "Alice90!", "AliceRex", "rexacmecorp1", "alicerex1705"} {
+		if !seen[expected] {
+			t.Errorf("expected %q to be generated", expected)
+		}
+	}
+	if candidates[0] != "alice" || candidates[1] != "Alice" {
+		t.Errorf("expected the keywords first, got %v", candidates[:2])
+	}
+
+	// the filter applies to the candidates
+	p.Filter, _ = NewFilter(8, 0, "", "?u,?d")
+	count := 0
+	p.Generate(func(candidate string) bool {
+		if !p.Filter.Match(candidate) {
+			t.Errorf("%q doesn't match the filter", candidate)
+		}
+		count++
+		return count < 10
+	})
+	if count != 10 {
+		t.Errorf("expected the generation to stop after 10 candidates, got %d", count)
+	}
+
+	p = NewProfile(nil, []string{"17/05/1990"}, nil)
+	if _, err := p.Generate(func(string) bool { return true }); err == nil {
+		t.Error("expected an error for an invalid date")
+	}
+}
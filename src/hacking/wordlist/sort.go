@@ -0,0 +1,241 @@
+// Package wordlist processes wordlists: the external sort removing the
+// duplicates of lists larger than the memory, the combinator, length and
+// charset filters, and the lists generated from what is known of a target.
+package wordlist
+
+import (
+	"bufio"
+	"container/heap"
+	"hacking/algorithms"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	// fanIn is the maximum number of runs merged at once.
+	fanIn = 64
+	// defaultMemory is the memory of a sorter without one.
+	defaultMemory = 256 << 20
+)
+
+// Sorter sorts wordlists and removes their duplicates with an external
+// merge sort, so that they may be larger than the memory.
+type Sorter struct {
+	// Memory is the number of bytes of words sorted in memory at once,
+	// 256 MB if not set.
+	Memory int
+	// TempDir is the directory of the sorted runs, the default one if empty.
+	TempDir string
+}
+
+// NewSorter creates a sorter using the given memory.
+func NewSorter(memory int) *Sorter {
+	return &Sorter{Memory: memory}
+}
+
+// Dedupe writes the distinct words of the readers, one per line, to w in
+// byte order. It returns their number.
+func (s *Sorter) Dedupe(w io.Writer, readers ...io.Reader) (uint64, error) {
+	var runs []string
+	defer func() {
+		for _, run := range runs {
+			os.Remove(run)
+		}
+	}()
+	memory := s.Memory
+	if memory <= 0 {
+		memory = defaultMemory
+	}
+	var chunk []string
+	size := 0
+	flush := func() error {
+		run, err := s.writeRun(chunk)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, run)
+		chunk, size = nil, 0
+		return nil
+	}
+	for _, r := range readers {
+		var err error
+		_, e := algorithms.ReadWords(r, func(word string) bool {
+			chunk = append(chunk, word)
+			// the string header is counted too
+			size += len(word) + 16
+			if size >= memory {
+				err = flush()
+			}
+			return err == nil
+		})
+		if e != nil {
+			return 0, e
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	output := bufio.NewWriter(w)
+	if len(runs) == 0 {
+		// everything fits in memory
+		count := uint64(0)
+		for _, word := range distinct(chunk) {
+			count++
+			output.WriteString(word + "\n")
+		}
+		return count, output.Flush()
+	}
+	if len(chunk) > 0 {
+		if err := flush(); err != nil {
+			return 0, err
+		}
+	}
+	// the runs are merged by groups while they are too many
+	for len(runs) > fanIn {
+		var merged []string
+		for i := 0; i < len(runs); i += fanIn {
+			group := runs[i:min(i+fanIn, len(runs))]
+			run, err := s.mergeRun(group)
+			if err != nil {
+				runs = append(runs, merged...)
+				return 0, err
+			}
+			for _, path := range group {
+				os.Remove(path)
+			}
+			merged = append(merged, run)
+		}
+		runs = merged
+	}
+	count, err := merge(output, runs)
+	if err != nil {
+		return 0, err
+	}
+	return count, output.Flush()
+}
+
+// distinct sorts the words and removes their duplicates in place.
+func distinct(words []string) []string {
+	sort.Strings(words)
+	unique := words[:0]
+	for i, word := range words {
+		if i == 0 || word != words[i-1] {
+			unique = append(unique, word)
+		}
+	}
+	return unique
+}
+
+// writeRun writes the distinct words of the chunk to a new run file.
+func (s *Sorter) writeRun(chunk []string) (string, error) {
+	file, err := os.CreateTemp(s.TempDir, "wordlist-*.run")
+	if err != nil {
+		return "", err
+	}
+	output := bufio.NewWriter(file)
+	for _, word := range distinct(chunk) {
+		output.WriteString(word + "\n")
+	}
+	if err := output.Flush(); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), file.Close()
+}
+
+// mergeRun merges the runs into a new one.
+func (s *Sorter) mergeRun(runs []string) (string, error) {
+	file, err := os.CreateTemp(s.TempDir, "wordlist-*.run")
+	if err != nil {
+		return "", err
+	}
+	output := bufio.NewWriter(file)
+	_, err = merge(output, runs)
+	if err == nil {
+		err = output.Flush()
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), file.Close()
+}
+
+// cursor is the current word of a run being merged.
+type cursor struct {
+	word   string
+	reader *bufio.Reader
+}
+
+// next reads the next word of the run and returns false at its end.
+func (c *cursor) next() (bool, error) {
+	line, err := c.reader.ReadString('\n')
+	if err == io.EOF && len(line) == 0 {
+		return false, nil
+	}
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	c.word = strings.TrimSuffix(line, "\n")
+	return true, nil
+}
+
+type cursors []*cursor
+
+func (c cursors) Len() int            { return len(c) }
+func (c cursors) Less(i, j int) bool  { return c[i].word < c[j].word }
+func (c cursors) Swap(i, j int)       { c[i], c[j] = c[j], c[i] }
+func (c *cursors) Push(x interface{}) { *c = append(*c, x.(*cursor)) }
+func (c *cursors) Pop() interface{} {
+	old := *c
+	last := old[len(old)-1]
+	*c = old[:len(old)-1]
+	return last
+}
+
+// merge writes the distinct words of the sorted runs to the output and
+// returns their number.
+func merge(output *bufio.Writer, runs []string) (uint64, error) {
+	var queue cursors
+	for _, run := range runs {
+		file, err := os.Open(run)
+		if err != nil {
+			return 0, err
+		}
+		defer file.Close()
+		c := &cursor{reader: bufio.NewReader(file)}
+		more, err := c.next()
+		if err != nil {
+			return 0, err
+		}
+		if more {
+			queue = append(queue, c)
+		}
+	}
+	heap.Init(&queue)
+	count := uint64(0)
+	last := ""
+	for len(queue) > 0 {
+		c := queue[0]
+		if count == 0 || c.word != last {
+			count++
+			last = c.word
+			output.WriteString(last + "\n")
+		}
+		more, err := c.next()
+		if err != nil {
+			return 0, err
+		}
+		if more {
+			heap.Fix(&queue, 0)
+		} else {
+			heap.Pop(&queue)
+		}
+	}
+	return count, nil
+}
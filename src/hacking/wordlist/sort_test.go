@@ -0,0 +1,49 @@
+package wordlist
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestDedupe(t *testing.T) {
+	// words with many duplicates spread over several readers
+	var first, second strings.Builder
+	expected := make(map[string]bool)
+	for i := 0; i < 5000; i++ {
+		word := fmt.Sprintf("word%d", (i*7919)%1300)
+		expected[word] = true
+		if i%2 == 0 {
+			first.WriteString(word + "\n")
+		} else {
+			second.WriteString(word + "\r\n")
+		}
+	}
+	var words []string
+	for word := range expected {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	want := strings.Join(words, "\n") + "\n"
+
+	// in memory, with a few runs, and with more runs than merged at once
+	for _, memory := range []int{1 << 20, 20000, 300} {
+		directory := t.TempDir()
+		s := NewSorter(memory)
+		s.TempDir = directory
+		var output bytes.Buffer
+		count, err := s.Dedupe(&output, strings.NewReader(first.String()), strings.NewReader(second.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != uint64(len(words)) || output.String() != want {
+			t.Errorf("memory %d: expected %d sorted words, got %d", memory, len(words), count)
+		}
+		if runs, _ := os.ReadDir(directory); len(runs) > 0 {
+			t.Errorf("memory %d: %d runs left", memory, len(runs))
+		}
+	}
+}
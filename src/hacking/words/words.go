@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"hacking/algorithms"
+	"hacking/wordlist"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	flag.Usage = func() {
+		// http://patorjk.com/software/taag/#p=display&f=Big
+		fmt.Fprintf(os.Stderr, ""+
+			`words [OPTIONS] [FILES]
+
+----------------------------------
+ __          __           _
+ \ \        / /          | |
+  \ \  /\  / /__  _ __ __| |___
+   \ \/  \/ / _ \| '__/ _`+"`"+` / __|
+    \  /\  / (_) | | | (_| \__ \
+     \/  \/ \___/|_|  \__,_|___/
+
+----------------------------------
+
+Usage:
+
+  words -mode dedupe -memory 512 -o unique.txt huge.txt other.txt
+
+writes the distinct words of the files (stdin if none) sorted, with an
+external sort using 512 MB of memory so that they may be larger than it.
+
+  words -mode combine -sep "-" left.txt right.txt
+
+writes every word of left.txt joined to every word of right.txt, which
+must fit in memory.
+
+  words -mode permute -depth 3 words.txt
+
+writes the arrangements of 1 to 3 distinct words of words.txt.
+
+  words -mode filter -min 8 -s "?l?u?d?s" -require "?u,?d" words.txt
+
+keeps the words of 8 characters or more made of letters, digits and
+special characters, with an uppercase letter and a digit. The filter
+options apply to the words written by the other modes too.
+
+  words -mode profile -names "alice,smith,rex" -dates 1990-05-17
+        -keywords "acme,rocket"
+
+generates the likely passwords of a target from what is known of it:
+the variations of the names and keywords (case, leetspeak), the forms
+of the dates, the suffixes (-suffixes) and their combinations.
+
+Options:
+`)
+		flag.PrintDefaults()
+	}
+	mode := flag.String("mode", "dedupe", "mode: dedupe, combine, permute, filter or profile")
+	outputPath := flag.String("o", "", "output file, stdout if empty")
+	memory := flag.Int("memory", 256, "memory of the external sort of the dedupe mode, in MB")
+	tempDir := flag.String("tmp", "", "directory of the temporary files of the dedupe mode")
+	separator := flag.String("sep", "", "separator of the words of the combine and permute modes")
+	depth := flag.Int("depth", 2, "maximum number of words of the permute mode")
+	minLength := flag.Int("min", 0, "minimum length of the words, in characters")
+	maxLength := flag.Int("max", 0, "maximum length of the words, in characters, unlimited if 0")
+	charset := flag.String("s", "", "charset of the words, such as ?l?d_, any if empty")
+	require := flag.String("require", "", "comma-separated charsets the words must contain, such as ?u,?d")
+	names := flag.String("names", "", "comma-separated names of the target of the profile mode")
+	dates := flag.String("dates", "", "comma-separated dates of the target of the profile mode, as YYYY-MM-DD")
+	keywords := flag.String("keywords", "", "comma-separated keywords of the target of the profile mode")
+	suffixes := flag.String("suffixes", strings.Join(wordlist.DefaultSuffixes, ","), "comma-separated suffixes of the profile mode")
+	flag.Parse()
+
+	filter, err := wordlist.NewFilter(*minLength, *maxLength, *charset, *require)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var file *os.File = os.Stdout
+	if len(*outputPath) > 0 {
+		file, err = os.Create(*outputPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	output := bufio.NewWriter(file)
+	write := filter.Apply(func(word string) bool {
+		output.WriteString(word + "\n")
+		return true
+	})
+	log.Println("mode (-mode)", *mode)
+
+	switch *mode {
+	case "dedupe":
+		sorter := wordlist.NewSorter(*memory << 20)
+		sorter.TempDir = *tempDir
+		log.Println("memory (-memory)", *memory, "MB")
+		count, err := sorter.Dedupe(output, filtered(inputs(), filter))
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Println("distinct words", count)
+	case "combine":
+		if flag.NArg() != 2 {
+			log.Fatalf("the combine mode needs a left and a right wordlist")
+		}
+		right := open(flag.Arg(1))
+		words, err := wordlist.ReadAll(right)
+		right.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		left := open(flag.Arg(0))
+		defer left.Close()
+		if _, err := wordlist.Combine(left, words, *separator, write); err != nil {
+			log.Fatal(err)
+		}
+	case "permute":
+		words, err := wordlist.ReadAll(io.MultiReader(inputs()...))
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Println("depth (-depth)", *depth)
+		wordlist.Permute(words, *depth, *separator, write)
+	case "filter":
+		for _, r := range inputs() {
+			if _, err := algorithms.ReadWords(r, write); err != nil {
+				log.Fatal(err)
+			}
+		}
+	case "profile":
+		p := wordlist.NewProfile(split(*names), split(*dates), split(*keywords))
+		p.Suffixes = split(*suffixes)
+		p.Filter = filter
+		if _, err := p.Generate(write); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		log.Fatalf("unknown mode (-mode) %q", *mode)
+	}
+	if err := output.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// split returns the comma-separated values, none if empty.
+func split(values string) []string {
+	if len(values) == 0 {
+		return []string{}
+	}
+	return strings.Split(values, ",")
+}
+
+func open(path string) *os.File {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return file
+}
+
+// inputs returns the files of the arguments, or stdin without any. They
+// are closed at exit.
+func inputs() []io.Reader {
+	if flag.NArg() == 0 {
+		return []io.Reader{os.Stdin}
+	}
+	var readers []io.Reader
+	for _, path := range flag.Args() {
+		readers = append(readers, open(path))
+	}
+	return readers
+}
+
+// filtered returns the reader of the words of the readers kept by the
+// filter.
+func filtered(readers []io.Reader, filter *wordlist.Filter) io.Reader {
+	r, w := io.Pipe()
+	go func() {
+		output := bufio.NewWriter(w)
+		emit := filter.Apply(func(word string) bool {
+			output.WriteString(word + "\n")
+			return true
+		})
+		for _, reader := range readers {
+			if _, err := algorithms.ReadWords(reader, emit); err != nil {
+				w.CloseWithError(err)
+				return
+			}
+		}
+		w.CloseWithError(output.Flush())
+	}()
+	return r
+}
@@ -0,0 +1,411 @@
+package logparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWebhookTimeout = 10 * time.Second
+	defaultTickInterval   = time.Second
+)
+
+// Duration is a time.Duration read from JSON as a string such as "1m30s".
+type Duration time.Duration
+
+// UnmarshalJSON reads a duration string.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(value)
+	*d = Duration(duration)
+	return err
+}
+
+// MarshalJSON writes a duration string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// AlertRule fires when more than Above records matching its filter are
+// seen within its window or, if Absent is set, when no record matching
+// its filter is seen within its window. It resolves when the condition
+// stops being true.
+type AlertRule struct {
+	Name string `json:"name"`
+	// Filter is a filter expression, see ParseFilter.
+	Filter string   `json:"filter"`
+	Window Duration `json:"window"`
+	Above  int      `json:"above"`
+	Absent bool     `json:"absent"`
+}
+
+// alertState is the state of an alert rule over the records seen.
+type alertState struct {
+	rule    *AlertRule
+	filter  Filter
+	matches []time.Time
+	last    time.Time
+	firing  bool
+}
+
+// AlertState is the state of an alert event.
+type AlertState string
+
+const (
+	// Firing is the state of an alert whose condition became true.
+	Firing AlertState = "firing"
+	// Resolved is the state of an alert whose condition stopped being true.
+	Resolved AlertState = "resolved"
+)
+
+// AlertEvent is the firing or the resolution of an alert rule.
+type AlertEvent struct {
+	Rule  string     `json:"rule"`
+	State AlertState `json:"state"`
+	At    time.Time  `json:"at"`
+	// Count is the number of matching records within the window.
+	Count   int    `json:"count"`
+	Message string `json:"message"`
+}
+
+// AlertSink receives the alert events.
+type AlertSink interface {
+	Send(event *AlertEvent) error
+}
+
+// WriterSink writes the alert events as text lines.
+type WriterSink struct {
+	Writer io.Writer
+}
+
+// Send writes the event.
+func (s *WriterSink) Send(event *AlertEvent) error {
+	_, err := fmt.Fprintf(s.Writer, "%s [%s] %s: %s\n", event.At.Format(time.RFC3339),
+		strings.ToUpper(string(event.State)), event.Rule, event.Message)
+	return err
+}
+
+// WebhookSink posts the alert events as JSON to a URL.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// Send posts the event.
+func (s *WebhookSink) Send(event *AlertEvent) error {
+	content, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: defaultWebhookTimeout}
+	}
+	response, err := client.Post(s.URL, "application/json", bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook %s answered %s", s.URL, response.Status)
+	}
+	return nil
+}
+
+// CommandSink runs a command for every alert event, with the event as
+// JSON on its standard input and in the ALERT_RULE, ALERT_STATE,
+// ALERT_COUNT and ALERT_MESSAGE environment variables.
+type CommandSink struct {
+	Command []string
+}
+
+// Send runs the command.
+func (s *CommandSink) Send(event *AlertEvent) error {
+	if len(s.Command) == 0 {
+		return fmt.Errorf("empty alert command")
+	}
+	content, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	command := exec.Command(s.Command[0], s.Command[1:]...)
+	command.Stdin = bytes.NewReader(content)
+	command.Env = append(os.Environ(),
+		"ALERT_RULE="+event.Rule,
+		"ALERT_STATE="+string(event.State),
+		"ALERT_COUNT="+strconv.Itoa(event.Count),
+		"ALERT_MESSAGE="+event.Message)
+	output, err := command.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("alert command failed: %v: %s", err, output)
+	}
+	return nil
+}
+
+// alertsConfig is the content of an alerts file.
+type alertsConfig struct {
+	Rules []*AlertRule `json:"rules"`
+	Sinks []struct {
+		Type    string   `json:"type"`
+		URL     string   `json:"url"`
+		Command []string `json:"command"`
+	} `json:"sinks"`
+}
+
+// Alerts evaluates alert rules over a stream of records and sends their
+// events to sinks. It is safe for concurrent use.
+type Alerts struct {
+	states []*alertState
+	sinks  []AlertSink
+	mutex  sync.Mutex
+}
+
+// NewAlerts creates alerts with the given rules and sinks.
+func NewAlerts(rules []*AlertRule, sinks ...AlertSink) (*Alerts, error) {
+	alerts := &Alerts{
+		sinks: sinks,
+	}
+	for _, rule := range rules {
+		if rule.Window <= 0 {
+			return nil, fmt.Errorf("alert rule %q must have a positive window", rule.Name)
+		}
+		filter, err := ParseFilter(rule.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("alert rule %q: %v", rule.Name, err)
+		}
+		alerts.states = append(alerts.states, &alertState{rule: rule, filter: filter})
+	}
+	return alerts, nil
+}
+
+// ParseAlerts reads alerts from JSON or from the equivalent YAML, for
+// instance:
+//
+//	{
+//	  "rules": [
+//	    {"name": "api errors", "filter": "level=error source=api", "window": "1m", "above": 50},
+//	    {"name": "heartbeat", "filter": "heartbeat", "window": "5m", "absent": true}
+//	  ],
+//	  "sinks": [
+//	    {"type": "stdout"},
+//	    {"type": "webhook", "url": "http://localhost:9093/alerts"},
+//	    {"type": "command", "command": ["notify-send", "alert"]}
+//	  ]
+//	}
+//
+// or:
+//
+//	rules:
+//	  - name: api errors
+//	    filter: level=error source=api
+//	    window: 1m
+//	    above: 50
+//	sinks:
+//	  - type: command
+//	    command: [notify-send, alert]
+//
+// A content starting with { is read as JSON, any other as YAML.
+func ParseAlerts(content []byte) (*Alerts, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) {
+		value, err := parseYAML(content)
+		if err != nil {
+			return nil, err
+		}
+		if content, err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+	config := &alertsConfig{}
+	if err := json.Unmarshal(content, config); err != nil {
+		return nil, err
+	}
+	var sinks []AlertSink
+	for _, sink := range config.Sinks {
+		switch sink.Type {
+		case "stdout":
+			sinks = append(sinks, &WriterSink{Writer: os.Stdout})
+		case "webhook":
+			sinks = append(sinks, &WebhookSink{URL: sink.URL})
+		case "command":
+			sinks = append(sinks, &CommandSink{Command: sink.Command})
+		default:
+			return nil, fmt.Errorf("unknown alert sink type: %s", sink.Type)
+		}
+	}
+	return NewAlerts(config.Rules, sinks...)
+}
+
+// LoadAlerts reads alerts from a JSON or YAML file, see ParseAlerts.
+func LoadAlerts(path string) (*Alerts, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAlerts(content)
+}
+
+// Sinks returns the number of sinks of the alerts.
+func (a *Alerts) Sinks() int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return len(a.sinks)
+}
+
+// AddSink adds a sink to the alerts.
+func (a *Alerts) AddSink(sink AlertSink) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.sinks = append(a.sinks, sink)
+}
+
+// evaluate prunes the matches out of the window and returns the event of
+// the rule at the given time, if its state changes.
+func (s *alertState) evaluate(at time.Time) *AlertEvent {
+	r := s.rule
+	window := time.Duration(r.Window)
+	if s.last.IsZero() {
+		s.last = at
+	}
+	kept := s.matches[:0]
+	for _, match := range s.matches {
+		if at.Sub(match) < window {
+			kept = append(kept, match)
+		}
+	}
+	s.matches = kept
+	var condition bool
+	var message string
+	if r.Absent {
+		condition = at.Sub(s.last) >= window
+		message = "matching line seen again"
+		if condition {
+			message = fmt.Sprintf("no matching line for %v", at.Sub(s.last).Truncate(time.Second))
+		}
+	} else {
+		condition = len(s.matches) > r.Above
+		message = fmt.Sprintf("%d matching lines in %v (above %d)", len(s.matches), window, r.Above)
+	}
+	if condition == s.firing {
+		return nil
+	}
+	s.firing = condition
+	event := &AlertEvent{
+		Rule:    r.Name,
+		State:   Resolved,
+		At:      at,
+		Count:   len(s.matches),
+		Message: message,
+	}
+	if condition {
+		event.State = Firing
+	}
+	return event
+}
+
+// send sends the events to the sinks, without holding the mutex of the
+// alerts for a slow sink not to block the evaluations.
+func send(sinks []AlertSink, events []*AlertEvent) error {
+	var errs []string
+	for _, event := range events {
+		for _, sink := range sinks {
+			if err := sink.Send(event); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to send alerts: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// Observe evaluates the rules with a record seen at the given time and
+// sends the resulting events.
+func (a *Alerts) Observe(record *Record, at time.Time) ([]*AlertEvent, error) {
+	a.mutex.Lock()
+	var events []*AlertEvent
+	for _, state := range a.states {
+		if state.filter(record) {
+			state.matches = append(state.matches, at)
+			state.last = at
+		}
+		if event := state.evaluate(at); event != nil {
+			events = append(events, event)
+		}
+	}
+	sinks := a.sinks
+	a.mutex.Unlock()
+	return events, send(sinks, events)
+}
+
+// Tick evaluates the rules at the given time without new record, for the
+// windows to slide, and sends the resulting events.
+func (a *Alerts) Tick(at time.Time) ([]*AlertEvent, error) {
+	a.mutex.Lock()
+	var events []*AlertEvent
+	for _, state := range a.states {
+		if event := state.evaluate(at); event != nil {
+			events = append(events, event)
+		}
+	}
+	sinks := a.sinks
+	a.mutex.Unlock()
+	return events, send(sinks, events)
+}
+
+// Run evaluates the rules over the records until the end of the reader,
+// for instance a Follower, using the wall clock. The sink errors are
+// passed to the errors function, if not nil.
+func (a *Alerts) Run(records RecordReader, errors func(error)) error {
+	if errors == nil {
+		errors = func(error) {}
+	}
+	a.Tick(time.Now())
+	type next struct {
+		record *Record
+		err    error
+	}
+	input := make(chan next)
+	go func() {
+		for {
+			record, err := records.Next()
+			input <- next{record, err}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	ticker := time.NewTicker(defaultTickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case n := <-input:
+			if n.err == io.EOF {
+				return nil
+			}
+			if n.err != nil {
+				return n.err
+			}
+			if _, err := a.Observe(n.record, time.Now()); err != nil {
+				errors(err)
+			}
+		case at := <-ticker.C:
+			if _, err := a.Tick(at); err != nil {
+				errors(err)
+			}
+		}
+	}
+}
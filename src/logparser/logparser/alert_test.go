@@ -0,0 +1,191 @@
+package logparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const alertsConfigTest = `{
+  "rules": [
+    {"name": "api errors", "filter": "level=error source=api", "window": "1m", "above": 2},
+    {"name": "heartbeat", "filter": "heartbeat", "window": "5m", "absent": true}
+  ],
+  "sinks": [{"type": "webhook", "url": "%s"}]
+}`
+
+func TestAlerts(t *testing.T) {
+	var mutex sync.Mutex
+	var received []AlertEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event := AlertEvent{}
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mutex.Lock()
+		received = append(received, event)
+		mutex.Unlock()
+	}))
+	defer server.Close()
+	alerts, err := ParseAlerts([]byte(strings.Replace(alertsConfigTest, "%s", server.URL, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alerts.Sinks() != 1 {
+		t.Fatalf("expected the sink of the file, got %d", alerts.Sinks())
+	}
+	output := &bytes.Buffer{}
+	alerts.AddSink(&WriterSink{Writer: output})
+
+	start := time.Date(2016, 10, 30, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	alerts.Observe(&Record{Source: "api", Line: "heartbeat"}, at(0))
+	for i := 0; i < 3; i++ {
+		alerts.Observe(&Record{Source: "api", Line: "ERROR request failed"}, at(10+i))
+		alerts.Observe(&Record{Source: "db", Line: "ERROR request failed"}, at(10+i))
+	}
+	events, err := alerts.Tick(at(71))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].State != Resolved || events[0].Count != 1 {
+		t.Errorf("unexpected events %v", events)
+	}
+	alerts.Tick(at(299))
+	events, _ = alerts.Tick(at(300))
+	if len(events) != 1 || events[0].Rule != "heartbeat" || events[0].State != Firing {
+		t.Errorf("unexpected events %v", events)
+	}
+	events, _ = alerts.Observe(&Record{Line: "heartbeat"}, at(400))
+	if len(events) != 1 || events[0].Rule != "heartbeat" || events[0].State != Resolved {
+		t.Errorf("unexpected events %v", events)
+	}
+
+	expected := []string{
+		"2016-10-30T12:00:12Z [FIRING] api errors: 3 matching lines in 1m0s (above 2)",
+		"2016-10-30T12:01:11Z [RESOLVED] api errors: 1 matching lines in 1m0s (above 2)",
+		"2016-10-30T12:05:00Z [FIRING] heartbeat: no matching line for 5m0s",
+		"2016-10-30T12:06:40Z [RESOLVED] heartbeat: matching line seen again",
+	}
+	if output.String() != strings.Join(expected, "\n")+"\n" {
+		t.Errorf("unexpected output:\n%s", output.String())
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(received) != 4 || received[0].Rule != "api errors" || received[0].State != Firing || received[0].Count != 3 {
+		t.Errorf("unexpected webhook events %v", received)
+	}
+}
+
+func TestAlertsErrors(t *testing.T) {
+	for _, config := range []string{
+		`{"rules": [{"name": "no window", "filter": "error"}]}`,
+		`{"rules": [{"name": "bad filter", "filter": "(error", "window": "1m"}]}`,
+		`{"rules": [{"name": "bad window", "filter": "error", "window": "1 minute"}]}`,
+		`{"sinks": [{"type": "pager"}]}`,
+	} {
+		if _, err := ParseAlerts([]byte(config)); err == nil {
+			t.Errorf("expected an error for %s", config)
+		}
+	}
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	rules := []*AlertRule{{Name: "any", Window: Duration(time.Minute)}}
+	alerts, err := NewAlerts(rules, &WebhookSink{URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := alerts.Observe(&Record{Line: "line"}, time.Now()); err == nil {
+		t.Errorf("expected a webhook error")
+	}
+}
+
+func TestAlertsYAML(t *testing.T) {
+	alerts, err := ParseAlerts([]byte(`
+rules:
+  - name: heartbeat
+    filter: heartbeat
+    window: 5m
+    absent: true
+sinks:
+  - type: command
+    command: [notify-send, alert]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alerts.Sinks() != 1 || len(alerts.states) != 1 || alerts.states[0].rule.Window != Duration(5*time.Minute) ||
+		!alerts.states[0].rule.Absent {
+		t.Errorf("unexpected alerts %v", alerts.states)
+	}
+	if _, err := ParseAlerts([]byte("rules:\n  - name: no window\n    filter: error\n")); err == nil {
+		t.Errorf("expected an error without window")
+	}
+}
+
+// sinkFunc is a sink calling a function.
+type sinkFunc func(event *AlertEvent) error
+
+func (f sinkFunc) Send(event *AlertEvent) error {
+	return f(event)
+}
+
+func TestAlertsSendUnlocked(t *testing.T) {
+	rules := []*AlertRule{{Name: "any", Window: Duration(time.Minute)}}
+	alerts, err := NewAlerts(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := 0
+	alerts.AddSink(sinkFunc(func(event *AlertEvent) error {
+		done := make(chan bool)
+		go func() {
+			alerts.Tick(event.At)
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Errorf("the alerts are locked while sending")
+		}
+		sent++
+		return nil
+	}))
+	if _, err := alerts.Observe(&Record{Line: "line"}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if sent != 1 {
+		t.Errorf("expected one event sent, got %d", sent)
+	}
+}
+
+func TestCommandSink(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell available")
+	}
+	path := filepath.Join(t.TempDir(), "alert.txt")
+	sink := &CommandSink{Command: []string{"sh", "-c", `echo "$ALERT_RULE $ALERT_STATE $ALERT_COUNT" > ` + path + ` && cat >> ` + path}}
+	err := sink.Send(&AlertEvent{Rule: "errors", State: Firing, Count: 51})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(content), "errors firing 51\n{\"rule\":\"errors\",\"state\":\"firing\"") {
+		t.Errorf("unexpected command output %q", content)
+	}
+	if err := (&CommandSink{Command: []string{"sh", "-c", "exit 1"}}).Send(&AlertEvent{}); err == nil {
+		t.Errorf("expected a command error")
+	}
+}
@@ -0,0 +1,238 @@
+package logparser
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// number of leading bytes identifying the content of a file
+	fingerprintSize = 1024
+	// size of the chunks read backward to find the last complete line
+	chunkSize = 4096
+)
+
+// Checkpoint is the position reached in a log file with the aggregated
+// values, saved between runs so that every line is processed once.
+type Checkpoint struct {
+	Device uint64 `json:"device"`
+	Inode  uint64 `json:"inode"`
+	Offset int64  `json:"offset"`
+	// Fingerprint is the hash of the first FingerprintSize bytes of the
+	// file, to detect a file replaced or truncated then rewritten.
+	Fingerprint     string             `json:"fingerprint"`
+	FingerprintSize int64              `json:"fingerprint_size"`
+	Values          map[string]float64 `json:"values"`
+	Lines           int64              `json:"lines"`
+}
+
+// LoadCheckpoint loads a checkpoint, or returns an empty one if the file
+// does not exist.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	checkpoint := &Checkpoint{Values: make(map[string]float64)}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, checkpoint); err != nil {
+		return nil, err
+	}
+	if checkpoint.Values == nil {
+		checkpoint.Values = make(map[string]float64)
+	}
+	return checkpoint, nil
+}
+
+// Save writes the checkpoint atomically.
+func (c *Checkpoint) Save(path string) error {
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	temporary, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = temporary.Write(content)
+	if err == nil {
+		err = temporary.Sync()
+	}
+	if closeErr := temporary.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(temporary.Name())
+		return err
+	}
+	return os.Rename(temporary.Name(), path)
+}
+
+func fingerprint(file io.ReaderAt, size int64) (string, error) {
+	content := make([]byte, size)
+	if _, err := file.ReadAt(content, 0); err != nil && err != io.EOF {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// completeSize returns the offset following the last newline of the file
+// after the given offset, so that a line being written is not processed.
+func completeSize(file io.ReaderAt, offset, size int64) (int64, error) {
+	chunk := make([]byte, chunkSize)
+	for end := size; end > offset; {
+		start := end - chunkSize
+		if start < offset {
+			start = offset
+		}
+		n, err := file.ReadAt(chunk[:end-start], start)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if index := bytes.LastIndexByte(chunk[:n], '\n'); index >= 0 {
+			return start + int64(index) + 1, nil
+		}
+		end = start
+	}
+	return offset, nil
+}
+
+// same tells if the file is the one of the checkpoint, with the same
+// device, inode and leading bytes.
+func (c *Checkpoint) same(file *os.File, info os.FileInfo) (bool, error) {
+	device, inode := fileID(file, info)
+	if device != c.Device || inode != c.Inode {
+		return false, nil
+	}
+	if info.Size() < c.Offset || info.Size() < c.FingerprintSize {
+		return false, nil
+	}
+	sum, err := fingerprint(file, c.FingerprintSize)
+	return sum == c.Fingerprint, err
+}
+
+// rotated returns the file of the checkpoint if it has been moved in the
+// same directory as the log file, for instance by a rotation.
+func (c *Checkpoint) rotated(path string) (*os.File, os.FileInfo, error) {
+	if c.Device == 0 && c.Inode == 0 {
+		return nil, nil, nil
+	}
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(path), "*"))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, match := range matches {
+		file, err := os.Open(match)
+		if err != nil {
+			continue
+		}
+		info, err := file.Stat()
+		if err != nil || info.IsDir() {
+			file.Close()
+			continue
+		}
+		if device, inode := fileID(file, info); device != c.Device || inode != c.Inode {
+			file.Close()
+			continue
+		}
+		if ok, err := c.same(file, info); err != nil || !ok {
+			file.Close()
+			return nil, nil, err
+		}
+		return file, info, nil
+	}
+	return nil, nil, nil
+}
+
+// read processes the complete lines of the file from the given offset
+// and moves the checkpoint to the end of the last one.
+func (c *Checkpoint) read(file *os.File, info os.FileInfo, offset int64, parser *TimeParser, operand func(*Record)) error {
+	end, err := completeSize(file, offset, info.Size())
+	if err != nil {
+		return err
+	}
+	reader := NewReader(io.NewSectionReader(file, offset, end-offset), parser, offset)
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		operand(record)
+		c.Lines++
+	}
+	c.Device, c.Inode = fileID(file, info)
+	c.Offset = end
+	c.FingerprintSize = fingerprintSize
+	if end < c.FingerprintSize {
+		c.FingerprintSize = end
+	}
+	c.Fingerprint, err = fingerprint(file, c.FingerprintSize)
+	return err
+}
+
+// Process calls operand on the lines appended to the log file since the
+// checkpoint and moves the checkpoint after them. If the file has been
+// rotated, the end of the previous file is processed first if it can be
+// found in the same directory. If it has been truncated or replaced, it
+// is processed from its beginning. A line being written, without a final
+// newline, is left for the next call.
+func (c *Checkpoint) Process(path string, parser *TimeParser, operand func(*Record)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	same, err := c.same(file, info)
+	if err != nil {
+		return err
+	}
+	if same {
+		return c.read(file, info, c.Offset, parser, operand)
+	}
+	previous, previousInfo, err := c.rotated(path)
+	if err != nil {
+		return err
+	}
+	if previous != nil {
+		err = c.read(previous, previousInfo, c.Offset, parser, operand)
+		previous.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return c.read(file, info, 0, parser, operand)
+}
+
+// UpdateMapValues adds the [[value]] of the lines appended to the log file
+// since the checkpoint saved in checkpointPath to the values of the
+// checkpoint, saves it and returns the values. See GetMapValues.
+func UpdateMapValues(path, checkpointPath string) (map[string]float64, error) {
+	checkpoint, err := LoadCheckpoint(checkpointPath)
+	if err != nil {
+		return nil, err
+	}
+	err = checkpoint.Process(path, NewTimeParser(), func(record *Record) {
+		if key, value, err := GetMapValue(record.Line); err == nil {
+			checkpoint.Values[key] += value
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return checkpoint.Values, checkpoint.Save(checkpointPath)
+}
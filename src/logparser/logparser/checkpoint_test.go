@@ -0,0 +1,97 @@
+package logparser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func appendTo(t *testing.T, path, content string) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkValues(t *testing.T, values map[string]float64, expected map[string]float64) {
+	if len(values) != len(expected) {
+		t.Fatalf("unexpected values %v, expected %v", values, expected)
+	}
+	for key, value := range expected {
+		if values[key] != value {
+			t.Errorf("unexpected values %v, expected %v", values, expected)
+		}
+	}
+}
+
+func TestCheckpointResume(t *testing.T) {
+	folder := t.TempDir()
+	path := filepath.Join(folder, "app.log")
+	checkpointPath := filepath.Join(folder, "app.checkpoint")
+	appendTo(t, path, "message test 1 [[1234]]\nmessage test 2 [[12.34]]\n")
+	values, err := UpdateMapValues(path, checkpointPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, values, map[string]float64{"1234": 1234, "12.34": 12.34})
+
+	appendTo(t, path, "message test 3 [[1234]]\nmessage test 4 [[12")
+	values, err = UpdateMapValues(path, checkpointPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, values, map[string]float64{"1234": 2468, "12.34": 12.34})
+
+	appendTo(t, path, ".34]]\n")
+	values, _ = UpdateMapValues(path, checkpointPath)
+	checkValues(t, values, map[string]float64{"1234": 2468, "12.34": 24.68})
+	values, _ = UpdateMapValues(path, checkpointPath)
+	checkValues(t, values, map[string]float64{"1234": 2468, "12.34": 24.68})
+
+	checkpoint, err := LoadCheckpoint(checkpointPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(path)
+	if checkpoint.Lines != 4 || checkpoint.Offset != info.Size() {
+		t.Errorf("unexpected checkpoint %+v", checkpoint)
+	}
+}
+
+func TestCheckpointRotationAndTruncation(t *testing.T) {
+	folder := t.TempDir()
+	path := filepath.Join(folder, "app.log")
+	checkpointPath := filepath.Join(folder, "app.checkpoint")
+	appendTo(t, path, "first [[1]]\n")
+	UpdateMapValues(path, checkpointPath)
+
+	// lines written before the rotation are processed from the rotated file
+	appendTo(t, path, "second [[2]]\n")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendTo(t, path, "third [[3]]\n")
+	values, err := UpdateMapValues(path, checkpointPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValues(t, values, map[string]float64{"1": 1, "2": 2, "3": 3})
+
+	// a truncated file is processed from its beginning
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	appendTo(t, path, "[[4]]\n")
+	values, _ = UpdateMapValues(path, checkpointPath)
+	checkValues(t, values, map[string]float64{"1": 1, "2": 2, "3": 3, "4": 4})
+
+	// as well as a file rewritten beyond the checkpoint after a truncation
+	os.Truncate(path, 0)
+	appendTo(t, path, "fifth [[5]]\nsixth [[6]]\n")
+	values, _ = UpdateMapValues(path, checkpointPath)
+	checkValues(t, values, map[string]float64{"1": 1, "2": 2, "3": 3, "4": 4, "5": 5, "6": 6})
+}
@@ -0,0 +1,336 @@
+package logparser
+
+import (
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Wildcard is the token used in templates in place of variable tokens
+	Wildcard = "<*>"
+
+	defaultDepth       = 4
+	defaultSimilarity  = 0.5
+	defaultMaxChildren = 100
+	defaultWindow      = time.Minute
+)
+
+var (
+	numberRegexp = regexp.MustCompile(`^[-+]?(0x)?[0-9]+([.,:][0-9]+)*[a-zA-Z%]{0,3}$`)
+	hexRegexp    = regexp.MustCompile(`^(0x)?[0-9a-fA-F]{8,}$`)
+	uuidRegexp   = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	idRegexp     = regexp.MustCompile(`[a-zA-Z_\-]*[0-9]+[a-zA-Z0-9_\-]*`)
+)
+
+// MaskToken returns the mask of a variable token (<IP>, <NUM>, <ID>)
+// or the token itself if it looks constant.
+func MaskToken(token string) string {
+	trimmed := strings.Trim(token, "[](){},;\"'")
+	if len(trimmed) == 0 {
+		return token
+	}
+	host := trimmed
+	if h, _, err := net.SplitHostPort(trimmed); err == nil {
+		host = h
+	}
+	if net.ParseIP(host) != nil && strings.ContainsAny(host, ".:") {
+		return "<IP>"
+	}
+	if numberRegexp.MatchString(trimmed) {
+		return "<NUM>"
+	}
+	if uuidRegexp.MatchString(trimmed) || hexRegexp.MatchString(trimmed) ||
+		idRegexp.FindString(trimmed) == trimmed {
+		return "<ID>"
+	}
+	return token
+}
+
+// MaskLine splits the line into tokens and masks the variable ones.
+func MaskLine(line string) []string {
+	tokens := strings.Fields(line)
+	for i, token := range tokens {
+		tokens[i] = MaskToken(token)
+	}
+	return tokens
+}
+
+func isMasked(token string) bool {
+	return token == Wildcard || token == "<IP>" || token == "<NUM>" || token == "<ID>"
+}
+
+// Template is a cluster of log messages sharing the same constant tokens.
+type Template struct {
+	ID     int
+	Tokens []string
+	Count  int
+}
+
+// String returns the template as a single line.
+func (t *Template) String() string {
+	return strings.Join(t.Tokens, " ")
+}
+
+func (t *Template) similarity(tokens []string) (float64, int) {
+	if len(t.Tokens) != len(tokens) {
+		return 0, 0
+	}
+	same := 0
+	wildcards := 0
+	for i, token := range t.Tokens {
+		if token == Wildcard {
+			wildcards++
+			continue
+		}
+		if token == tokens[i] {
+			same++
+		}
+	}
+	if len(tokens) == 0 {
+		return 1, 0
+	}
+	return float64(same) / float64(len(tokens)), wildcards
+}
+
+func (t *Template) merge(tokens []string) {
+	for i, token := range t.Tokens {
+		if token != tokens[i] {
+			t.Tokens[i] = Wildcard
+		}
+	}
+}
+
+type node struct {
+	children  map[string]*node
+	templates []*Template
+}
+
+func makeNode() *node {
+	return &node{children: make(map[string]*node)}
+}
+
+// Drain clusters log messages into templates using a fixed depth parse
+// tree, following the Drain algorithm (He et al., ICWS 2017).
+type Drain struct {
+	depth       int
+	similarity  float64
+	maxChildren int
+	root        *node
+	templates   []*Template
+}
+
+// NewDrain creates a template miner. The depth is the depth of the parse
+// tree counting the root, the length and the leaf layers (at least 3) and
+// similarity the ratio of constant tokens a message
+// must share with a template to be part of it.
+func NewDrain(depth int, similarity float64) *Drain {
+	if depth < 3 {
+		depth = defaultDepth
+	}
+	if similarity <= 0 || similarity > 1 {
+		similarity = defaultSimilarity
+	}
+	return &Drain{
+		depth:       depth,
+		similarity:  similarity,
+		maxChildren: defaultMaxChildren,
+		root:        makeNode(),
+	}
+}
+
+func (d *Drain) child(parent *node, key string) *node {
+	if child, ok := parent.children[key]; ok {
+		return child
+	}
+	if child, ok := parent.children[Wildcard]; ok && len(parent.children) >= d.maxChildren {
+		return child
+	}
+	if len(parent.children) >= d.maxChildren-1 {
+		key = Wildcard
+		if child, ok := parent.children[key]; ok {
+			return child
+		}
+	}
+	child := makeNode()
+	parent.children[key] = child
+	return child
+}
+
+func (d *Drain) leaf(tokens []string) *node {
+	// the length layer is never grouped under a wildcard, the templates of
+	// a leaf having the same number of tokens
+	length := strconv.Itoa(len(tokens))
+	current, ok := d.root.children[length]
+	if !ok {
+		current = makeNode()
+		d.root.children[length] = current
+	}
+	for i := 0; i < d.depth-3 && i < len(tokens); i++ {
+		key := tokens[i]
+		if isMasked(key) {
+			key = Wildcard
+		}
+		current = d.child(current, key)
+	}
+	return current
+}
+
+// Add adds a log message to the miner and returns its template.
+func (d *Drain) Add(line string) *Template {
+	tokens := MaskLine(line)
+	leaf := d.leaf(tokens)
+	var best *Template
+	bestSimilarity := -1.
+	bestWildcards := -1
+	for _, template := range leaf.templates {
+		similarity, wildcards := template.similarity(tokens)
+		if similarity > bestSimilarity ||
+			(similarity == bestSimilarity && wildcards > bestWildcards) {
+			best = template
+			bestSimilarity = similarity
+			bestWildcards = wildcards
+		}
+	}
+	if best != nil && bestSimilarity >= d.similarity {
+		best.merge(tokens)
+		best.Count++
+		return best
+	}
+	template := &Template{
+		ID:     len(d.templates) + 1,
+		Tokens: tokens,
+		Count:  1,
+	}
+	leaf.templates = append(leaf.templates, template)
+	d.templates = append(d.templates, template)
+	return template
+}
+
+// Templates returns the templates sorted by decreasing count.
+func (d *Drain) Templates() []*Template {
+	templates := make([]*Template, len(d.templates))
+	copy(templates, d.templates)
+	sort.SliceStable(templates, func(i, j int) bool {
+		return templates[i].Count > templates[j].Count
+	})
+	return templates
+}
+
+// AnomalyKind tells why a template has been flagged.
+type AnomalyKind int
+
+const (
+	// NewTemplate is a template never seen before the window.
+	NewTemplate AnomalyKind = iota
+	// Spike is a template whose rate departs sharply from its baseline.
+	Spike
+)
+
+// String returns the anomaly kind name.
+func (k AnomalyKind) String() string {
+	if k == NewTemplate {
+		return "new"
+	}
+	return "spike"
+}
+
+// Anomaly is a template flagged at the end of a time window.
+type Anomaly struct {
+	Kind     AnomalyKind
+	Template *Template
+	Window   time.Time
+	Count    int
+	Baseline float64
+}
+
+// Detector counts template occurrences per time window and flags the
+// templates that are new or spiking compared to the previous windows.
+type Detector struct {
+	// Factor is the ratio over the baseline above which a count is a spike.
+	Factor float64
+	// MinCount is the minimal count in a window to be flagged as a spike.
+	MinCount int
+
+	drain   *Drain
+	window  time.Duration
+	start   time.Time
+	windows int
+	current map[*Template]int
+	totals  map[*Template]int
+	firsts  map[*Template]int
+}
+
+// NewDetector creates an anomaly detector over the given template miner
+// and time window duration, a minute if not positive.
+func NewDetector(drain *Drain, window time.Duration) *Detector {
+	if window <= 0 {
+		window = defaultWindow
+	}
+	return &Detector{
+		Factor:   3,
+		MinCount: 5,
+		drain:    drain,
+		window:   window,
+		current:  make(map[*Template]int),
+		totals:   make(map[*Template]int),
+		firsts:   make(map[*Template]int),
+	}
+}
+
+// Observe adds a log message seen at the given time. It returns the
+// anomalies of the windows closed by this message, if any.
+func (d *Detector) Observe(at time.Time, line string) []Anomaly {
+	var anomalies []Anomaly
+	if d.start.IsZero() {
+		d.start = at.Truncate(d.window)
+	}
+	for !at.Before(d.start.Add(d.window)) {
+		anomalies = append(anomalies, d.Flush()...)
+	}
+	template := d.drain.Add(line)
+	if _, ok := d.firsts[template]; !ok {
+		d.firsts[template] = d.windows
+	}
+	d.current[template]++
+	return anomalies
+}
+
+// Flush closes the current window and returns its anomalies.
+func (d *Detector) Flush() []Anomaly {
+	var anomalies []Anomaly
+	for template, count := range d.current {
+		if d.windows == 0 {
+			break
+		}
+		baseline := float64(d.totals[template]) / float64(d.windows)
+		anomaly := Anomaly{
+			Template: template,
+			Window:   d.start,
+			Count:    count,
+			Baseline: baseline,
+		}
+		if d.firsts[template] == d.windows {
+			anomaly.Kind = NewTemplate
+			anomalies = append(anomalies, anomaly)
+		} else if count >= d.MinCount && float64(count) > d.Factor*baseline {
+			anomaly.Kind = Spike
+			anomalies = append(anomalies, anomaly)
+		}
+	}
+	for template, count := range d.current {
+		d.totals[template] += count
+	}
+	sort.Slice(anomalies, func(i, j int) bool {
+		return anomalies[i].Template.ID < anomalies[j].Template.ID
+	})
+	d.current = make(map[*Template]int)
+	d.windows++
+	if !d.start.IsZero() {
+		d.start = d.start.Add(d.window)
+	}
+	return anomalies
+}
@@ -0,0 +1,106 @@
+package logparser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaskToken(t *testing.T) {
+	expected := map[string]string{
+		"connected":                            "connected",
+		"42":                                   "<NUM>",
+		"-3.5":                                 "<NUM>",
+		"120ms":                                "<NUM>",
+		"10.0.0.1":                             "<IP>",
+		"10.0.0.1:8080":                        "<IP>",
+		"::1":                                  "<IP>",
+		"user42":                               "<ID>",
+		"deadbeefcafe":                         "<ID>",
+		"123e4567-e89b-12d3-a456-426614174000": "<ID>",
+	}
+	for token, mask := range expected {
+		if got := MaskToken(token); got != mask {
+			t.Errorf("MaskToken(%q) = %q, expected %q", token, got, mask)
+		}
+	}
+}
+
+func TestDrain(t *testing.T) {
+	drain := NewDrain(4, 0.5)
+	first := drain.Add("connection from 10.0.0.1 closed after 12 ms")
+	second := drain.Add("connection from 10.0.0.2 closed after 150 ms")
+	third := drain.Add("user alice logged in")
+	fourth := drain.Add("user bob logged in")
+	other := drain.Add("disk full on /dev/sda1")
+	if first != second {
+		t.Fatalf("expected the same template, got %q and %q", first, second)
+	}
+	if first.String() != "connection from <IP> closed after <NUM> ms" {
+		t.Errorf("unexpected template %q", first)
+	}
+	if third != fourth || third.String() != "user <*> logged in" {
+		t.Errorf("unexpected template %q", fourth)
+	}
+	if other == first || other == third {
+		t.Errorf("unexpected merge of %q", other)
+	}
+	templates := drain.Templates()
+	if len(templates) != 3 || templates[0].Count != 2 || templates[2] != other {
+		t.Errorf("unexpected templates %v", templates)
+	}
+}
+
+func TestDetector(t *testing.T) {
+	detector := NewDetector(NewDrain(4, 0.5), time.Minute)
+	start := time.Date(2016, 10, 30, 12, 0, 0, 0, time.UTC)
+	var anomalies []Anomaly
+	for minute := 0; minute < 5; minute++ {
+		at := start.Add(time.Duration(minute) * time.Minute)
+		count := 2
+		if minute == 4 {
+			count = 20
+		}
+		for i := 0; i < count; i++ {
+			anomalies = append(anomalies, detector.Observe(at, fmt.Sprintf("request %d served", i))...)
+		}
+		if minute == 3 {
+			anomalies = append(anomalies, detector.Observe(at, "panic in worker 7")...)
+		}
+	}
+	anomalies = append(anomalies, detector.Flush()...)
+	if len(anomalies) != 2 {
+		t.Fatalf("expected 2 anomalies, got %v", anomalies)
+	}
+	if anomalies[0].Kind != NewTemplate || anomalies[0].Template.String() != "panic in worker <NUM>" ||
+		!anomalies[0].Window.Equal(start.Add(3*time.Minute)) {
+		t.Errorf("unexpected anomaly %+v", anomalies[0])
+	}
+	if anomalies[1].Kind != Spike || anomalies[1].Count != 20 || anomalies[1].Baseline != 2 {
+		t.Errorf("unexpected anomaly %+v", anomalies[1])
+	}
+}
+
+func TestDrainLengths(t *testing.T) {
+	drain := NewDrain(4, 0.5)
+	for length := 1; length <= 2*defaultMaxChildren; length++ {
+		line := strings.TrimSpace(strings.Repeat("word ", length))
+		if template := drain.Add(line); len(template.Tokens) != length {
+			t.Fatalf("unexpected template %q of %d tokens", template, length)
+		}
+	}
+	if templates := drain.Templates(); len(templates) != 2*defaultMaxChildren {
+		t.Errorf("expected %d templates, got %d", 2*defaultMaxChildren, len(templates))
+	}
+}
+
+func TestDetectorWindow(t *testing.T) {
+	detector := NewDetector(NewDrain(4, 0.5), 0)
+	start := time.Date(2016, 10, 30, 12, 0, 0, 0, time.UTC)
+	detector.Observe(start, "request served")
+	anomalies := detector.Observe(start.Add(90*time.Second), "panic in worker 7")
+	if len(anomalies) != 0 || !detector.start.Equal(start.Add(time.Minute)) {
+		t.Errorf("unexpected window %v, anomalies %v", detector.start, anomalies)
+	}
+}
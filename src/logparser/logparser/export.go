@@ -0,0 +1,219 @@
+package logparser
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// WriteValuesCSV writes the aggregated values as key,value CSV rows sorted
+// by key, with a header.
+func WriteValuesCSV(w io.Writer, values map[string]float64) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"key", "value"})
+	for _, key := range sortedKeys(values) {
+		writer.Write([]string{key, formatFloat(values[key])})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+type jsonValue struct {
+	Key   string  `json:"key"`
+	Value float64 `json:"value"`
+}
+
+// WriteValuesJSON writes the aggregated values as JSON lines sorted by key.
+func WriteValuesJSON(w io.Writer, values map[string]float64) error {
+	encoder := json.NewEncoder(w)
+	for _, key := range sortedKeys(values) {
+		if err := encoder.Encode(jsonValue{Key: key, Value: values[key]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MetricName turns a name into a valid Prometheus metric name.
+func MetricName(name string) string {
+	metric := []byte(name)
+	for i, c := range metric {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == ':' ||
+			c >= '0' && c <= '9' && i > 0) {
+			metric[i] = '_'
+		}
+	}
+	return string(metric)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// WriteValuesPrometheus writes the aggregated values as a gauge in the
+// Prometheus text exposition format, the keys being the values of the
+// "key" label.
+func WriteValuesPrometheus(w io.Writer, name, help string, values map[string]float64) error {
+	name = MetricName(name)
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	if err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(values) {
+		_, err = fmt.Fprintf(w, "%s{key=\"%s\"} %s\n", name, labelEscaper.Replace(key),
+			formatFloat(values[key]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordWriter writes records as CSV or JSON lines.
+type RecordWriter struct {
+	csv    *csv.Writer
+	json   *json.Encoder
+	header bool
+}
+
+type jsonRecord struct {
+	Source string    `json:"source,omitempty"`
+	Time   time.Time `json:"time"`
+	Offset int64     `json:"offset"`
+	Line   string    `json:"line"`
+}
+
+// NewRecordWriter creates a record writer in the given format, "csv" or
+// "json". The CSV header is written with the first record.
+func NewRecordWriter(w io.Writer, format string) (*RecordWriter, error) {
+	switch format {
+	case "csv":
+		return &RecordWriter{csv: csv.NewWriter(w)}, nil
+	case "json":
+		return &RecordWriter{json: json.NewEncoder(w)}, nil
+	}
+	return nil, fmt.Errorf("unknown record format: %s", format)
+}
+
+// Write writes a record.
+func (w *RecordWriter) Write(record *Record) error {
+	if w.json != nil {
+		return w.json.Encode(jsonRecord{
+			Source: record.Source,
+			Time:   record.Time,
+			Offset: record.Offset,
+			Line:   record.Line,
+		})
+	}
+	if !w.header {
+		w.header = true
+		w.csv.Write([]string{"source", "time", "offset", "line"})
+	}
+	return w.csv.Write([]string{
+		record.Source,
+		record.Time.Format(time.RFC3339Nano),
+		strconv.FormatInt(record.Offset, 10),
+		record.Line,
+	})
+}
+
+// Flush writes the buffered records, if any.
+func (w *RecordWriter) Flush() error {
+	if w.csv == nil {
+		return nil
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// Metrics are aggregated values updated while reading a log and served
+// in the Prometheus text exposition format. They are safe for concurrent
+// use.
+type Metrics struct {
+	name   string
+	help   string
+	mutex  sync.Mutex
+	values map[string]float64
+	lines  int64
+}
+
+// NewMetrics creates metrics exposed under the given name.
+func NewMetrics(name, help string) *Metrics {
+	return &Metrics{
+		name:   MetricName(name),
+		help:   help,
+		values: make(map[string]float64),
+	}
+}
+
+// Add adds the value to the given key and counts a line.
+func (m *Metrics) Add(key string, value float64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.values[key] += value
+	m.lines++
+}
+
+// Count counts a line without value.
+func (m *Metrics) Count() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.lines++
+}
+
+// Values returns a copy of the aggregated values.
+func (m *Metrics) Values() map[string]float64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	values := make(map[string]float64, len(m.values))
+	for key, value := range m.values {
+		values[key] = value
+	}
+	return values
+}
+
+// Write writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) Write(w io.Writer) error {
+	m.mutex.Lock()
+	lines := m.lines
+	m.mutex.Unlock()
+	if err := WriteValuesPrometheus(w, m.name, m.help, m.Values()); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "# HELP %s_lines_total Lines read.\n# TYPE %s_lines_total counter\n%s_lines_total %d\n",
+		m.name, m.name, m.name, lines)
+	return err
+}
+
+// ServeHTTP serves the metrics, for instance on /metrics.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.Write(w)
+}
@@ -0,0 +1,132 @@
+package logparser
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteValues(t *testing.T) {
+	values := map[string]float64{"12.34": 24.68, "1234": 1234, `a"b`: 1}
+	buffer := &bytes.Buffer{}
+	if err := WriteValuesCSV(buffer, values); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "key,value\n12.34,24.68\n1234,1234\n\"a\"\"b\",1\n"; buffer.String() != expected {
+		t.Errorf("unexpected CSV %q", buffer.String())
+	}
+	buffer.Reset()
+	if err := WriteValuesJSON(buffer, values); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buffer.String(), `{"key":"12.34","value":24.68}`+"\n") ||
+		strings.Count(buffer.String(), "\n") != 3 {
+		t.Errorf("unexpected JSON %q", buffer.String())
+	}
+	buffer.Reset()
+	if err := WriteValuesPrometheus(buffer, "log-values", "Values.", values); err != nil {
+		t.Fatal(err)
+	}
+	expected := "# HELP log_values Values.\n# TYPE log_values gauge\n" +
+		"log_values{key=\"12.34\"} 24.68\nlog_values{key=\"1234\"} 1234\nlog_values{key=\"a\\\"b\"} 1\n"
+	if buffer.String() != expected {
+		t.Errorf("unexpected Prometheus text %q", buffer.String())
+	}
+}
+
+func TestRecordWriter(t *testing.T) {
+	record := &Record{
+		Source: "api",
+		Time:   time.Date(2016, 10, 30, 12, 0, 0, 0, time.UTC),
+		Line:   "message, test",
+		Offset: 42,
+	}
+	buffer := &bytes.Buffer{}
+	writer, err := NewRecordWriter(buffer, "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Write(record)
+	writer.Write(record)
+	writer.Flush()
+	line := "api,2016-10-30T12:00:00Z,42,\"message, test\"\n"
+	if buffer.String() != "source,time,offset,line\n"+line+line {
+		t.Errorf("unexpected CSV %q", buffer.String())
+	}
+	buffer.Reset()
+	writer, err = NewRecordWriter(buffer, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Write(record)
+	expected := `{"source":"api","time":"2016-10-30T12:00:00Z","offset":42,"line":"message, test"}` + "\n"
+	if buffer.String() != expected {
+		t.Errorf("unexpected JSON %q", buffer.String())
+	}
+	if _, err := NewRecordWriter(buffer, "xml"); err == nil {
+		t.Errorf("expected an unknown format error")
+	}
+}
+
+func TestFollowMetrics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "follow.log")
+	if err := os.WriteFile(path, []byte("message test 0 [[1]]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	follower, err := Follow(path, NewTimeParser(), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics := NewMetrics("logparser_values", "Values read.")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			record, err := follower.Next()
+			if err != nil {
+				return
+			}
+			key, value, err := GetMapValue(record.Line)
+			if err != nil {
+				metrics.Count()
+				continue
+			}
+			metrics.Add(key, value)
+		}
+	}()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	file.WriteString("message test 1 [[1234]]\nmessage test 2 [[12.")
+	time.Sleep(50 * time.Millisecond)
+	file.WriteString("34]]\nno value\nmessage test 3 [[1234]]\n")
+
+	server := httptest.NewServer(metrics)
+	defer server.Close()
+	expected := "logparser_values{key=\"12.34\"} 12.34\nlogparser_values{key=\"1234\"} 2468\n" +
+		"# HELP logparser_values_lines_total Lines read.\n# TYPE logparser_values_lines_total counter\n" +
+		"logparser_values_lines_total 4\n"
+	var body string
+	for i := 0; i < 100 && !strings.HasSuffix(body, expected); i++ {
+		time.Sleep(20 * time.Millisecond)
+		response, err := server.Client().Get(server.URL + "/metrics")
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+		body = string(content)
+	}
+	if !strings.HasSuffix(body, expected) {
+		t.Errorf("unexpected metrics %q", body)
+	}
+	follower.Close()
+	<-done
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package logparser
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID returns the device and inode of the file.
+func fileID(file *os.File, info os.FileInfo) (uint64, uint64) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0
+	}
+	return uint64(stat.Dev), uint64(stat.Ino)
+}
@@ -0,0 +1,308 @@
+package logparser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Filter tells if a record matches.
+type Filter func(record *Record) bool
+
+var levelNames = map[string]string{
+	"TRACE":    "TRACE",
+	"DEBUG":    "DEBUG",
+	"INFO":     "INFO",
+	"NOTICE":   "INFO",
+	"WARN":     "WARN",
+	"WARNING":  "WARN",
+	"ERR":      "ERROR",
+	"ERROR":    "ERROR",
+	"CRIT":     "FATAL",
+	"CRITICAL": "FATAL",
+	"FATAL":    "FATAL",
+	"PANIC":    "FATAL",
+}
+
+const (
+	// maximum number of leading tokens searched for a level
+	maxLevelTokens = 6
+)
+
+// Level returns the level of the line (TRACE, DEBUG, INFO, WARN, ERROR or
+// FATAL) taken from its level field or from one of its first words, in
+// upper case or decorated like [error].
+func Level(line string) string {
+	fields := ParseFields(line)
+	for _, key := range []string{"level", "lvl", "severity"} {
+		if level, ok := levelNames[strings.ToUpper(fields[key])]; ok {
+			return level
+		}
+	}
+	tokens := strings.Fields(line)
+	for i, token := range tokens {
+		if i >= maxLevelTokens {
+			break
+		}
+		trimmed := strings.Trim(token, "[]():<>|")
+		if level, ok := levelNames[trimmed]; ok {
+			return level
+		}
+		// decorated levels such as [error] can be in lower case
+		if level, ok := levelNames[strings.ToUpper(trimmed)]; ok && trimmed != token {
+			return level
+		}
+	}
+	return ""
+}
+
+// IsError tells if the line has the ERROR or FATAL level.
+func IsError(line string) bool {
+	level := Level(line)
+	return level == "ERROR" || level == "FATAL"
+}
+
+// field returns a field of the record, the level and source fields being
+// the record level and source.
+func field(record *Record, name string) (string, bool) {
+	switch name {
+	case "level":
+		level := Level(record.Line)
+		return level, len(level) > 0
+	case "source":
+		return record.Source, len(record.Source) > 0
+	}
+	value, ok := ParseFields(record.Line)[name]
+	return value, ok
+}
+
+type filterParser struct {
+	tokens []string
+	index  int
+}
+
+// tokenize splits the expression into parentheses, quoted strings,
+// /regexps/ and words, a word possibly ending with a quoted value.
+func tokenize(expression string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(expression); {
+		c := expression[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"' || c == '/':
+			end := strings.IndexByte(expression[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated %c at %d", c, i)
+			}
+			tokens = append(tokens, expression[i:i+end+2])
+			i += end + 2
+		default:
+			start := i
+			for i < len(expression) && !strings.ContainsRune(" \t()", rune(expression[i])) {
+				if expression[i] == '"' {
+					end := strings.IndexByte(expression[i+1:], '"')
+					if end < 0 {
+						return nil, fmt.Errorf("unterminated \" at %d", i)
+					}
+					i += end + 1
+				}
+				i++
+			}
+			tokens = append(tokens, expression[start:i])
+		}
+	}
+	return tokens, nil
+}
+
+// ParseFilter compiles a filter expression. An expression is made of
+// terms combined with "and" (or just spaces), "or", "not" (or "-") and
+// parentheses. A term is either:
+//   - a word or a "quoted text" contained in the line, ignoring case,
+//   - a /regexp/ matching the line,
+//   - a comparison of a key=value field with a value: level=ERROR,
+//     user!=bob, path~^/api, status>=500, the level and source fields
+//     being the record level and source.
+//
+// An empty expression matches every record.
+func ParseFilter(expression string) (Filter, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return func(*Record) bool { return true }, nil
+	}
+	parser := &filterParser{tokens: tokens}
+	filter, err := parser.or()
+	if err != nil {
+		return nil, err
+	}
+	if parser.index < len(tokens) {
+		return nil, fmt.Errorf("unexpected %q", tokens[parser.index])
+	}
+	return filter, nil
+}
+
+func (p *filterParser) peek() string {
+	if p.index < len(p.tokens) {
+		return p.tokens[p.index]
+	}
+	return ""
+}
+
+func (p *filterParser) or() (Filter, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "or") || p.peek() == "|" {
+		p.index++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		lhs := left
+		left = func(record *Record) bool { return lhs(record) || right(record) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) and() (Filter, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		next := p.peek()
+		if next == "" || next == ")" || next == "|" || strings.EqualFold(next, "or") {
+			return left, nil
+		}
+		if strings.EqualFold(next, "and") || next == "&" {
+			p.index++
+		}
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		lhs := left
+		left = func(record *Record) bool { return lhs(record) && right(record) }
+	}
+}
+
+func (p *filterParser) unary() (Filter, error) {
+	token := p.peek()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of filter")
+	case strings.EqualFold(token, "not") || token == "-" || token == "!":
+		p.index++
+		filter, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(record *Record) bool { return !filter(record) }, nil
+	case len(token) > 1 && (token[0] == '-' || token[0] == '!') && token[1] != '=':
+		p.tokens[p.index] = token[1:]
+		filter, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(record *Record) bool { return !filter(record) }, nil
+	case token == "(":
+		p.index++
+		filter, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.index++
+		return filter, nil
+	case token == ")":
+		return nil, fmt.Errorf("unexpected )")
+	}
+	p.index++
+	return term(token)
+}
+
+var comparisonRegexp = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_.\-]*)(!=|>=|<=|=|~|>|<)(.*)$`)
+
+func unquote(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+func term(token string) (Filter, error) {
+	if len(token) >= 2 && token[0] == '/' && token[len(token)-1] == '/' {
+		expression, err := regexp.Compile(token[1 : len(token)-1])
+		if err != nil {
+			return nil, err
+		}
+		return func(record *Record) bool { return expression.MatchString(record.Line) }, nil
+	}
+	if match := comparisonRegexp.FindStringSubmatch(token); match != nil {
+		return comparison(match[1], match[2], unquote(match[3]))
+	}
+	text := strings.ToLower(unquote(token))
+	return func(record *Record) bool {
+		return strings.Contains(strings.ToLower(record.Line), text)
+	}, nil
+}
+
+func comparison(name, operator, value string) (Filter, error) {
+	if level, ok := levelNames[strings.ToUpper(value)]; ok && name == "level" {
+		value = level
+	}
+	switch operator {
+	case "=":
+		return func(record *Record) bool {
+			actual, ok := field(record, name)
+			return ok && strings.EqualFold(actual, value)
+		}, nil
+	case "!=":
+		return func(record *Record) bool {
+			actual, _ := field(record, name)
+			return !strings.EqualFold(actual, value)
+		}, nil
+	case "~":
+		expression, err := regexp.Compile(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(record *Record) bool {
+			actual, ok := field(record, name)
+			return ok && expression.MatchString(actual)
+		}, nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s%s expects a number: %s", name, operator, value)
+	}
+	return func(record *Record) bool {
+		actual, ok := field(record, name)
+		if !ok {
+			return false
+		}
+		parsed, err := strconv.ParseFloat(actual, 64)
+		if err != nil {
+			return false
+		}
+		switch operator {
+		case ">":
+			return parsed > number
+		case ">=":
+			return parsed >= number
+		case "<":
+			return parsed < number
+		}
+		return parsed <= number
+	}, nil
+}
@@ -0,0 +1,143 @@
+package logparser
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+var filterLines = []string{
+	"2016-10-30 12:00:00 INFO request served path=/api/users status=200 user=alice",
+	"2016-10-30 12:00:01 ERROR request failed path=/api/orders status=500 user=bob",
+	"2016-10-30 12:00:02 level=warning msg=\"slow request\" path=/static status=200",
+	"2016-10-30 12:00:03 [error] disk full",
+}
+
+func TestFilter(t *testing.T) {
+	expected := map[string][]int{
+		"":                    {0, 1, 2, 3},
+		"request":             {0, 1, 2},
+		"REQUEST served":      {0},
+		"\"request failed\"":  {1},
+		"/stat(us|ic)=2/":     {0, 2},
+		"level=error":         {1, 3},
+		"level=WARNING":       {2},
+		"status>=500 or disk": {1, 3},
+		"not level=error":     {0, 2},
+		"-disk -served":       {1, 2},
+		"path~^/api and (user=bob or user!=alice)": {1},
+		"status<300 & user!=alice":                 {2},
+	}
+	for expression, indexes := range expected {
+		filter, err := ParseFilter(expression)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", expression, err)
+			continue
+		}
+		var matches []int
+		for index, line := range filterLines {
+			if filter(&Record{Line: line}) {
+				matches = append(matches, index)
+			}
+		}
+		if len(matches) != len(indexes) || (len(matches) > 0 && matches[0] != indexes[0]) ||
+			(len(matches) > 1 && matches[len(matches)-1] != indexes[len(indexes)-1]) {
+			t.Errorf("%q matched %v, expected %v", expression, matches, indexes)
+		}
+	}
+	for _, expression := range []string{"(error", "error)", "\"error", "/[/", "status>abc", "not"} {
+		if _, err := ParseFilter(expression); err == nil {
+			t.Errorf("expected an error for %q", expression)
+		}
+	}
+}
+
+func makeView(t *testing.T) *View {
+	view := NewView("test.log", 40, 7)
+	parser := NewTimeParser()
+	parser.Location = time.UTC
+	for i := 0; i < 10; i++ {
+		for _, line := range filterLines {
+			at, _ := parser.Parse(line)
+			view.Add(&Record{Time: at.Add(time.Duration(i) * time.Minute), Line: line})
+		}
+	}
+	return view
+}
+
+func TestView(t *testing.T) {
+	view := makeView(t)
+	if all, matches := view.Len(); all != 40 || matches != 40 || view.Selected().Line != filterLines[3] {
+		t.Fatalf("unexpected view of %d/%d records", matches, all)
+	}
+	view.Top()
+	if !view.NextError(1) || view.Selected().Line != filterLines[1] || view.Follow {
+		t.Errorf("unexpected next error %q", view.Selected().Line)
+	}
+	view.NextError(1)
+	if view.Selected().Line != filterLines[3] {
+		t.Errorf("unexpected next error %q", view.Selected().Line)
+	}
+	view.NextError(-1)
+	if view.Selected().Line != filterLines[1] {
+		t.Errorf("unexpected previous error %q", view.Selected().Line)
+	}
+	if err := view.SetFilter("level=error and (disk"); err == nil {
+		t.Errorf("expected an incomplete filter error")
+	}
+	if _, matches := view.Len(); matches != 40 {
+		t.Errorf("expected the previous filter to be kept, got %d matches", matches)
+	}
+	if err := view.SetFilter("level=error and (disk)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, matches := view.Len(); matches != 10 || view.Selected().Line != filterLines[3] {
+		t.Errorf("unexpected filtered view of %d records", matches)
+	}
+	view.Page(1)
+	view.Move(100)
+	view.Follow = true
+	view.Add(&Record{Line: "2016-10-30 13:00:00 ERROR disk full again"})
+	if view.Selected().Line != "2016-10-30 13:00:00 ERROR disk full again" {
+		t.Errorf("unexpected followed record %q", view.Selected().Line)
+	}
+	counts, first, last := view.Histogram(10)
+	if counts[0] != 1 || counts[9] != 1 || !first.Equal(time.Date(2016, 10, 30, 12, 0, 3, 0, time.UTC)) ||
+		!last.Equal(time.Date(2016, 10, 30, 12, 9, 3, 0, time.UTC)) {
+		t.Errorf("unexpected histogram %v from %v to %v", counts, first, last)
+	}
+}
+
+func TestHistogramSpan(t *testing.T) {
+	view := NewView("long", 80, 24)
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, at := range []time.Time{start, start.AddDate(0, 0, 200), start.AddDate(0, 0, 400)} {
+		view.Add(&Record{Line: "line", Time: at})
+	}
+	counts, _, _ := view.Histogram(500)
+	if counts[0] != 1 || counts[249] != 1 || counts[499] != 1 {
+		t.Errorf("unexpected histogram over a year %v", counts)
+	}
+}
+
+func TestViewRender(t *testing.T) {
+	view := makeView(t)
+	view.Width = 100
+	view.SetFilter("status=200")
+	buffer := &bytes.Buffer{}
+	if err := view.Render(buffer); err != nil {
+		t.Fatal(err)
+	}
+	screen := buffer.String()
+	if rows := strings.Count(screen, "\r\n"); rows != view.Height-1 {
+		t.Errorf("expected %d rows, got %d", view.Height-1, rows)
+	}
+	if !strings.Contains(screen, "\x1b[36mstatus\x1b[0m=\x1b[1m200\x1b[0m") {
+		t.Errorf("expected highlighted fields in %q", screen)
+	}
+	if !strings.Contains(screen, "\x1b[32mINFO\x1b[0m") || !strings.Contains(screen, "/status=200") ||
+		!strings.Contains(screen, "20/20/40") || !strings.Contains(screen, "2016-10-30 12:00:00") {
+		t.Errorf("unexpected screen %q", screen)
+	}
+}
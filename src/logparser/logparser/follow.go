@@ -0,0 +1,82 @@
+package logparser
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	defaultFollowInterval = 250 * time.Millisecond
+)
+
+// tail is a reader waiting for new data at the end of its file instead
+// of returning io.EOF, until closed.
+type tail struct {
+	file     *os.File
+	interval time.Duration
+	done     chan struct{}
+}
+
+func (t *tail) Read(p []byte) (int, error) {
+	for {
+		n, err := t.file.Read(p)
+		if n > 0 {
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			select {
+			case <-t.done:
+				return 0, io.EOF
+			default:
+				return 0, err
+			}
+		}
+		select {
+		case <-t.done:
+			return 0, io.EOF
+		case <-time.After(t.interval):
+		}
+	}
+}
+
+// Follower reads the records appended to a file, like tail -f.
+type Follower struct {
+	*Reader
+	tail *tail
+}
+
+// Follow opens the file and reads its records from the given offset, or
+// from its end if the offset is negative, waiting for new lines at the end.
+func Follow(path string, parser *TimeParser, offset int64) (*Follower, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	whence := io.SeekStart
+	if offset < 0 {
+		offset = 0
+		whence = io.SeekEnd
+	}
+	offset, err = file.Seek(offset, whence)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	t := &tail{
+		file:     file,
+		interval: defaultFollowInterval,
+		done:     make(chan struct{}),
+	}
+	return &Follower{
+		Reader: NewReader(t, parser, offset),
+		tail:   t,
+	}, nil
+}
+
+// Close stops following the file, Next then returns io.EOF once the
+// buffered records are read.
+func (f *Follower) Close() error {
+	close(f.tail.done)
+	return f.tail.file.Close()
+}
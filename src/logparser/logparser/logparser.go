@@ -60,11 +60,20 @@ func GetMapValues(path string) (*map[string]float64, error) {
 	check(err)
 	m := make(map[string]float64)
 	for _, v := range lines {
-		val, err := GetStringValue("[[", "]]", v)
+		key, value, err := GetMapValue(v)
 		check(err)
-		intVal, err := strconv.ParseFloat(val, 64)
-		check(err)
-		m[val] += intVal
+		m[key] += value
 	}
 	return &m, nil
 }
+
+// GetMapValue returns the value located between [[ and ]] in the line,
+// as a key and as a float64.
+func GetMapValue(line string) (string, float64, error) {
+	val, err := GetStringValue("[[", "]]", line)
+	if err != nil {
+		return "", 0, err
+	}
+	floatVal, err := strconv.ParseFloat(val, 64)
+	return val, floatVal, err
+}
@@ -28,7 +28,7 @@ func checkStrings(lhs, rhs string) {
 
 func checkInt64(lhs, rhs int64) {
 	if lhs != rhs {
-		printError(errors.New(string(lhs)+" != "+string(rhs)), 2)
+		printError(errors.New(strconv.FormatInt(lhs, 10)+" != "+strconv.FormatInt(rhs, 10)), 2)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,100 @@
+package logparser
+
+import (
+	"container/heap"
+	"io"
+	"time"
+)
+
+// RecordReader is implemented by the sources of records.
+type RecordReader interface {
+	// Next returns the next record or io.EOF at the end of the source.
+	Next() (*Record, error)
+}
+
+// Source is a named source of records to merge.
+type Source struct {
+	Name    string
+	Records RecordReader
+	// Skew is added to the timestamps of the source to correct its clock.
+	Skew time.Duration
+}
+
+type pending struct {
+	record *Record
+	index  int
+}
+
+type pendingHeap []pending
+
+func (h pendingHeap) Len() int { return len(h) }
+
+func (h pendingHeap) Less(i, j int) bool {
+	if h[i].record.Time.Equal(h[j].record.Time) {
+		return h[i].index < h[j].index
+	}
+	return h[i].record.Time.Before(h[j].record.Time)
+}
+
+func (h pendingHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *pendingHeap) Push(x interface{}) { *h = append(*h, x.(pending)) }
+
+func (h *pendingHeap) Pop() interface{} {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
+
+// Merger interleaves the records of several time-sorted sources into a
+// single stream ordered by timestamp (k-way merge). Records with the same
+// timestamp keep the order of their sources.
+type Merger struct {
+	sources []*Source
+	heap    pendingHeap
+	started bool
+}
+
+// NewMerger creates a merger over the given sources.
+func NewMerger(sources ...*Source) *Merger {
+	return &Merger{
+		sources: sources,
+	}
+}
+
+func (m *Merger) read(index int) error {
+	source := m.sources[index]
+	record, err := source.Records.Next()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	record.Source = source.Name
+	record.Time = record.Time.Add(source.Skew)
+	heap.Push(&m.heap, pending{record: record, index: index})
+	return nil
+}
+
+// Next returns the next record in timestamp order, tagged with the name of
+// its source, or io.EOF when all the sources are exhausted.
+func (m *Merger) Next() (*Record, error) {
+	if !m.started {
+		m.started = true
+		for index := range m.sources {
+			if err := m.read(index); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if m.heap.Len() == 0 {
+		return nil, io.EOF
+	}
+	next := heap.Pop(&m.heap).(pending)
+	if err := m.read(next.index); err != nil {
+		return nil, err
+	}
+	return next.record, nil
+}
@@ -0,0 +1,48 @@
+package logparser
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func makeSource(name, content string, skew time.Duration) *Source {
+	parser := NewTimeParser()
+	parser.Location = time.UTC
+	return &Source{
+		Name:    name,
+		Records: NewReader(strings.NewReader(content), parser, 0),
+		Skew:    skew,
+	}
+}
+
+func TestMerger(t *testing.T) {
+	merger := NewMerger(
+		makeSource("api", "2016-10-30 12:00:01 api 1\n2016-10-30 12:00:03 api 2\n\tat api.go:42\n", 0),
+		makeSource("db", "2016-10-30 12:00:00 db 1\n2016-10-30 12:00:03 db 2\n2016-10-30 12:00:05 db 3\n", 0),
+		makeSource("web", "2016-10-30 12:00:04 web 1\n", -2*time.Second),
+		makeSource("empty", "", 0),
+	)
+	expected := []string{
+		"db: 2016-10-30 12:00:00 db 1",
+		"api: 2016-10-30 12:00:01 api 1",
+		"web: 2016-10-30 12:00:04 web 1",
+		"api: 2016-10-30 12:00:03 api 2",
+		"api: \tat api.go:42",
+		"db: 2016-10-30 12:00:03 db 2",
+		"db: 2016-10-30 12:00:05 db 3",
+	}
+	for _, line := range expected {
+		record, err := merger.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := record.Source + ": " + record.Line; got != line {
+			t.Errorf("got %q, expected %q", got, line)
+		}
+	}
+	if _, err := merger.Next(); err != io.EOF {
+		t.Errorf("expected the end of the merge, got %v", err)
+	}
+}
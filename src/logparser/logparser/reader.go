@@ -0,0 +1,72 @@
+package logparser
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// Record is a log line with its timestamp.
+type Record struct {
+	// Source is the name of the source of the line, if any.
+	Source string
+	// Time is the timestamp of the line or, for the lines without one such
+	// as stack traces, the timestamp of the previous line.
+	Time time.Time
+	Line string
+	// Offset is the byte offset of the line in its source.
+	Offset int64
+}
+
+// Reader reads records line by line.
+type Reader struct {
+	reader *bufio.Reader
+	parser *TimeParser
+	offset int64
+	last   time.Time
+}
+
+// NewReader creates a record reader parsing timestamps with the given
+// parser. The offset is the position of the reader in its source.
+func NewReader(r io.Reader, parser *TimeParser, offset int64) *Reader {
+	return &Reader{
+		reader: bufio.NewReader(r),
+		parser: parser,
+		offset: offset,
+	}
+}
+
+// Offset returns the position of the next record in the source.
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+func (r *Reader) readLine() (string, int64, error) {
+	line, err := r.reader.ReadString('\n')
+	if len(line) == 0 {
+		if err == nil {
+			err = io.EOF
+		}
+		return "", r.offset, err
+	}
+	offset := r.offset
+	r.offset += int64(len(line))
+	return strings.TrimRight(line, "\r\n"), offset, nil
+}
+
+// Next returns the next record or io.EOF at the end of the source.
+func (r *Reader) Next() (*Record, error) {
+	line, offset, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if t, err := r.parser.Parse(line); err == nil {
+		r.last = t
+	}
+	return &Record{
+		Time:   r.last,
+		Line:   line,
+		Offset: offset,
+	}, nil
+}
@@ -0,0 +1,368 @@
+package logparser
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Action is what a redaction rule does with the values it matches.
+type Action int
+
+const (
+	// Mask replaces the value with the rule mask, such as <EMAIL>.
+	Mask Action = iota
+	// Hash replaces the value with a keyed hash of it, so that redacted
+	// values can still be joined, such as <EMAIL:1f0c6a2b7d3e4f58>.
+	Hash
+	// Drop drops the whole line, or the field.
+	Drop
+)
+
+// ParseAction returns the action named mask, hash or drop.
+func ParseAction(name string) (Action, error) {
+	switch name {
+	case "mask":
+		return Mask, nil
+	case "hash":
+		return Hash, nil
+	case "drop":
+		return Drop, nil
+	}
+	return Mask, fmt.Errorf("unknown redaction action: %s", name)
+}
+
+// Rule is a redaction rule.
+type Rule struct {
+	Name string
+	// Pattern matches the values to redact. If it has a group, only the
+	// first group is redacted.
+	Pattern *regexp.Regexp
+	// Validate filters the matched values, if set.
+	Validate func(string) bool
+	// Locate returns the part of a matched value to redact, or false to
+	// keep the value, if set.
+	Locate func(string) (int, int, bool)
+	// Fields are the names of the fields whose values are always redacted.
+	Fields []string
+	Action Action
+}
+
+// NewRule creates a redaction rule from a regular expression.
+func NewRule(name, pattern string, action Action) (*Rule, error) {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &Rule{
+		Name:    name,
+		Pattern: compiled,
+		Action:  action,
+	}, nil
+}
+
+func (r *Rule) mask() string {
+	return "<" + strings.ToUpper(r.Name) + ">"
+}
+
+func validIP(value string) bool {
+	return net.ParseIP(value) != nil
+}
+
+// luhn checks the digits of a credit card number.
+func luhn(value string) bool {
+	sum := 0
+	digits := 0
+	for i := len(value) - 1; i >= 0; i-- {
+		c := value[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		digit := int(c - '0')
+		if digits%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
+
+// locateCard returns the longest, then leftmost, run of 13 to 19 digits of
+// the value passing the Luhn check, so that a card number is found next to
+// other numbers. The runs start and end at the bounds of the groups of
+// digits.
+func locateCard(value string) (int, int, bool) {
+	var positions []int
+	var starts, ends []bool
+	for i := 0; i < len(value); i++ {
+		if value[i] >= '0' && value[i] <= '9' {
+			positions = append(positions, i)
+			starts = append(starts, i == 0 || value[i-1] < '0' || value[i-1] > '9')
+			ends = append(ends, i == len(value)-1 || value[i+1] < '0' || value[i+1] > '9')
+		}
+	}
+	for length := min(19, len(positions)); length >= 13; length-- {
+		for first := 0; first+length <= len(positions); first++ {
+			last := first + length - 1
+			if !starts[first] || !ends[last] {
+				continue
+			}
+			start, end := positions[first], positions[last]+1
+			if luhn(value[start:end]) {
+				return start, end, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// BuiltinRules returns the built-in rules redacting emails, IPs, credit
+// card numbers, bearer tokens and secrets such as password=..., all with
+// the given action.
+func BuiltinRules(action Action) []*Rule {
+	rules := []*Rule{
+		{
+			Name:    "email",
+			Pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+		},
+		{
+			Name:     "ip",
+			Pattern:  regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|\b(?:[0-9a-fA-F]{0,4}:){2,7}[0-9a-fA-F]{0,4}\b`),
+			Validate: validIP,
+		},
+		{
+			Name:    "card",
+			Pattern: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+			Locate:  locateCard,
+		},
+		{
+			Name:    "bearer",
+			Pattern: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`),
+		},
+		{
+			Name:    "secret",
+			Pattern: regexp.MustCompile(`(?i)\b(?:password|passwd|secret|api_?key|token)=("[^"]*"|[^\s,;&]+)`),
+			Fields:  []string{"password", "passwd", "secret", "api_key", "apikey", "token", "authorization"},
+		},
+	}
+	for _, rule := range rules {
+		rule.Action = action
+	}
+	return rules
+}
+
+// Redactor rewrites lines and fields to mask, hash or drop sensitive
+// values, counting the redactions made by every rule. It is safe for
+// concurrent use.
+type Redactor struct {
+	rules  []*Rule
+	key    []byte
+	mutex  sync.Mutex
+	counts map[string]int
+}
+
+// NewRedactor creates a redactor applying the rules in order, the first
+// rule matching a value winning. The key is used by the Hash action.
+func NewRedactor(key []byte, rules ...*Rule) *Redactor {
+	return &Redactor{
+		rules:  rules,
+		key:    key,
+		counts: make(map[string]int),
+	}
+}
+
+func (r *Redactor) hash(rule *Rule, value string) string {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(value))
+	return "<" + strings.ToUpper(rule.Name) + ":" + hex.EncodeToString(mac.Sum(nil))[:16] + ">"
+}
+
+func (r *Redactor) replacement(rule *Rule, value string) string {
+	if rule.Action == Hash {
+		return r.hash(rule, value)
+	}
+	return rule.mask()
+}
+
+func (r *Redactor) count(rule *Rule) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.counts[rule.Name]++
+}
+
+type span struct {
+	start, end int
+	rule       *Rule
+	order      int
+}
+
+// Redact returns the redacted line, or false if the line is dropped.
+func (r *Redactor) Redact(line string) (string, bool) {
+	var spans []span
+	for order, rule := range r.rules {
+		if rule.Pattern == nil {
+			continue
+		}
+		for _, match := range rule.Pattern.FindAllStringSubmatchIndex(line, -1) {
+			start, end := match[0], match[1]
+			if len(match) > 2 && match[2] >= 0 {
+				start, end = match[2], match[3]
+			}
+			if start == end || (rule.Validate != nil && !rule.Validate(line[start:end])) {
+				continue
+			}
+			if rule.Locate != nil {
+				first, last, ok := rule.Locate(line[start:end])
+				if !ok {
+					continue
+				}
+				start, end = start+first, start+last
+			}
+			spans = append(spans, span{start: start, end: end, rule: rule, order: order})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].start == spans[j].start {
+			return spans[i].order < spans[j].order
+		}
+		return spans[i].start < spans[j].start
+	})
+	result := &strings.Builder{}
+	last := 0
+	dropped := false
+	for _, current := range spans {
+		if current.start < last {
+			continue
+		}
+		r.count(current.rule)
+		if current.rule.Action == Drop {
+			dropped = true
+		}
+		result.WriteString(line[last:current.start])
+		result.WriteString(r.replacement(current.rule, line[current.start:current.end]))
+		last = current.end
+	}
+	if dropped {
+		return "", false
+	}
+	result.WriteString(line[last:])
+	return result.String(), true
+}
+
+// RedactRecord redacts the line of the record, or returns false if the
+// record is dropped.
+func (r *Redactor) RedactRecord(record *Record) bool {
+	line, ok := r.Redact(record.Line)
+	record.Line = line
+	return ok
+}
+
+// RedactFields redacts the field values in place. The values of the fields
+// named by a rule are entirely redacted, the other values are redacted as
+// lines. Dropped fields are deleted.
+func (r *Redactor) RedactFields(fields map[string]string) {
+	for key, value := range fields {
+		var named *Rule
+		for _, rule := range r.rules {
+			for _, field := range rule.Fields {
+				if strings.EqualFold(field, key) {
+					named = rule
+				}
+			}
+			if named != nil {
+				break
+			}
+		}
+		if named != nil {
+			r.count(named)
+			if named.Action == Drop {
+				delete(fields, key)
+			} else {
+				fields[key] = r.replacement(named, value)
+			}
+			continue
+		}
+		redacted, ok := r.Redact(value)
+		if !ok {
+			delete(fields, key)
+			continue
+		}
+		fields[key] = redacted
+	}
+}
+
+// Report returns the number of redactions made by every rule.
+func (r *Redactor) Report() map[string]int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	report := make(map[string]int, len(r.rules))
+	for _, rule := range r.rules {
+		report[rule.Name] = r.counts[rule.Name]
+	}
+	return report
+}
+
+// WriteReport writes the number of redactions made by every rule, one
+// rule per line.
+func (r *Redactor) WriteReport(w io.Writer) error {
+	report := r.Report()
+	for _, rule := range r.rules {
+		if _, err := fmt.Fprintf(w, "%s: %d\n", rule.Name, report[rule.Name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type redactReader struct {
+	records  RecordReader
+	redactor *Redactor
+}
+
+func (r *redactReader) Next() (*Record, error) {
+	for {
+		record, err := r.records.Next()
+		if err != nil {
+			return nil, err
+		}
+		if r.redactor.RedactRecord(record) {
+			return record, nil
+		}
+	}
+}
+
+// Reader returns a record reader redacting the records of the given one
+// and skipping the dropped records.
+func (r *Redactor) Reader(records RecordReader) RecordReader {
+	return &redactReader{
+		records:  records,
+		redactor: r,
+	}
+}
+
+var fieldRegexp = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_.\-]*)=("(?:[^"\\]|\\.)*"|[^\s]*)`)
+
+// ParseFields returns the key=value fields of the line, the values being
+// unquoted if quoted.
+func ParseFields(line string) map[string]string {
+	fields := make(map[string]string)
+	for _, match := range fieldRegexp.FindAllStringSubmatch(line, -1) {
+		value := match[2]
+		if len(value) >= 2 && value[0] == '"' {
+			value = strings.Replace(value[1:len(value)-1], `\"`, `"`, -1)
+		}
+		fields[match[1]] = value
+	}
+	return fields
+}
@@ -0,0 +1,91 @@
+package logparser
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	redactor := NewRedactor([]byte("key"), BuiltinRules(Mask)...)
+	line := "user john.doe@example.com from 10.0.0.1 and fe80::1 paid with 4111 1111 1111 1111 " +
+		"at 12:00:00 order 1234567890123 Authorization: Bearer abc.def-ghi password=hunter2"
+	expected := "user <EMAIL> from <IP> and <IP> paid with <CARD> " +
+		"at 12:00:00 order 1234567890123 Authorization: Bearer <BEARER> password=<SECRET>"
+	redacted, ok := redactor.Redact(line)
+	if !ok || redacted != expected {
+		t.Errorf("unexpected redaction:\n%s\nexpected:\n%s", redacted, expected)
+	}
+	report := redactor.Report()
+	if report["email"] != 1 || report["ip"] != 2 || report["card"] != 1 || report["bearer"] != 1 || report["secret"] != 1 {
+		t.Errorf("unexpected report %v", report)
+	}
+	buffer := &bytes.Buffer{}
+	redactor.WriteReport(buffer)
+	if buffer.String() != "email: 1\nip: 2\ncard: 1\nbearer: 1\nsecret: 1\n" {
+		t.Errorf("unexpected report %q", buffer.String())
+	}
+}
+
+func TestRedactCard(t *testing.T) {
+	redactor := NewRedactor(nil, BuiltinRules(Mask)...)
+	expected := map[string]string{
+		"paid 4111 1111 1111 1111 2 items": "paid <CARD> 2 items",
+		"order 7 4111-1111-1111-1111 paid": "order 7 <CARD> paid",
+		"invoice 1234 5678 9012 3456 sent": "invoice 1234 5678 9012 3456 sent",
+	}
+	for line, redaction := range expected {
+		if redacted, _ := redactor.Redact(line); redacted != redaction {
+			t.Errorf("Redact(%q) = %q, expected %q", line, redacted, redaction)
+		}
+	}
+}
+
+func TestRedactHashAndDrop(t *testing.T) {
+	redactor := NewRedactor([]byte("key"), BuiltinRules(Hash)...)
+	first, _ := redactor.Redact("login alice@example.com")
+	second, _ := redactor.Redact("logout alice@example.com")
+	other, _ := NewRedactor([]byte("other"), BuiltinRules(Hash)...).Redact("login alice@example.com")
+	if !strings.HasPrefix(first, "login <EMAIL:") || len(first) != len("login <EMAIL:0123456789abcdef>") {
+		t.Fatalf("unexpected hash %q", first)
+	}
+	if strings.TrimPrefix(first, "login") != strings.TrimPrefix(second, "logout") || first == other {
+		t.Errorf("unexpected hashes %q, %q and %q", first, second, other)
+	}
+
+	custom, err := NewRule("session", `session=([0-9a-f]+)`, Drop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redactor = NewRedactor(nil, custom)
+	source := &Source{Records: NewReader(strings.NewReader("a session=beef\nb\nc session=cafe\n"), NewTimeParser(), 0)}
+	records := redactor.Reader(NewMerger(source))
+	record, err := records.Next()
+	if err != nil || record.Line != "b" {
+		t.Fatalf("unexpected record %v (%v)", record, err)
+	}
+	if _, err := records.Next(); err != io.EOF {
+		t.Errorf("expected the end of the records, got %v", err)
+	}
+	if redactor.Report()["session"] != 2 {
+		t.Errorf("unexpected report %v", redactor.Report())
+	}
+}
+
+func TestRedactFields(t *testing.T) {
+	fields := ParseFields(`level=info msg="sent to bob@example.com" password="a b" ip=10.0.0.1 empty=`)
+	if len(fields) != 5 || fields["msg"] != "sent to bob@example.com" || fields["password"] != "a b" {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+	NewRedactor(nil, BuiltinRules(Mask)...).RedactFields(fields)
+	if fields["level"] != "info" || fields["msg"] != "sent to <EMAIL>" || fields["password"] != "<SECRET>" ||
+		fields["ip"] != "<IP>" {
+		t.Errorf("unexpected fields %v", fields)
+	}
+	fields = ParseFields(`level=info ip=10.0.0.1 token=abc`)
+	NewRedactor(nil, BuiltinRules(Drop)...).RedactFields(fields)
+	if len(fields) != 1 || fields["level"] != "info" {
+		t.Errorf("unexpected fields %v", fields)
+	}
+}
@@ -0,0 +1,103 @@
+package logparser
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"time"
+)
+
+// lineStart returns the offset of the first line starting at or after
+// the given offset.
+func lineStart(r io.ReaderAt, size, offset int64) (int64, error) {
+	if offset <= 0 {
+		return 0, nil
+	}
+	reader := bufio.NewReader(io.NewSectionReader(r, offset-1, size-offset+1))
+	skipped, err := reader.ReadString('\n')
+	if err == io.EOF {
+		return size, nil
+	}
+	return offset - 1 + int64(len(skipped)), err
+}
+
+// firstStamped returns the offset and timestamp of the first stamped line
+// starting at or after the given offset. The offset is the size of the
+// file if there is none.
+func firstStamped(r io.ReaderAt, size, offset int64, parser *TimeParser) (int64, time.Time, error) {
+	start, err := lineStart(r, size, offset)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	reader := NewReader(io.NewSectionReader(r, start, size-start), parser, start)
+	for {
+		offset := reader.Offset()
+		line, _, err := reader.readLine()
+		if err == io.EOF {
+			return size, time.Time{}, nil
+		}
+		if err != nil {
+			return 0, time.Time{}, err
+		}
+		if t, err := parser.Parse(line); err == nil {
+			return offset, t, nil
+		}
+	}
+}
+
+// SeekTime returns the offset of the first line stamped at or after the
+// given time in a time-sorted source of the given size, using a binary
+// search over the byte offsets. It returns the size if there is none.
+func SeekTime(r io.ReaderAt, size int64, parser *TimeParser, at time.Time) (int64, error) {
+	low, high := int64(0), size
+	for low < high {
+		middle := low + (high-low)/2
+		offset, t, err := firstStamped(r, size, middle, parser)
+		if err != nil {
+			return 0, err
+		}
+		if offset == size || !t.Before(at) {
+			high = middle
+		} else {
+			low = offset + 1
+		}
+	}
+	offset, _, err := firstStamped(r, size, low, parser)
+	return offset, err
+}
+
+// ReadTimeRange calls operand on every record of the time-sorted file
+// stamped between from (included) and to (excluded). Only the lines
+// inside the range are read.
+func ReadTimeRange(path string, parser *TimeParser, from, to time.Time, operand func(*Record)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	offset, err := SeekTime(file, info.Size(), parser, from)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	reader := NewReader(file, parser, offset)
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !record.Time.Before(to) {
+			return nil
+		}
+		operand(record)
+	}
+}
@@ -0,0 +1,128 @@
+package logparser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeParser(t *testing.T) {
+	expected := time.Date(2016, 10, 30, 12, 56, 55, 0, time.UTC)
+	lines := []string{
+		"2016-10-30T12:56:55Z message",
+		"2016-10-30 12:56:55 message",
+		"INFO 2016/10/30 12:56:55 message",
+		"127.0.0.1 - - [30/Oct/2016:12:56:55 +0000] \"GET / HTTP/1.1\" 200",
+		"Oct 30 12:56:55 host sshd[42]: message",
+	}
+	for _, line := range lines {
+		parser := NewTimeParser()
+		parser.Location = time.UTC
+		parser.Year = 2016
+		at, err := parser.Parse(line)
+		if err != nil {
+			t.Errorf("no timestamp found in %q", line)
+			continue
+		}
+		if !at.Equal(expected) {
+			t.Errorf("parsed %v in %q, expected %v", at, line, expected)
+		}
+	}
+	parser := NewTimeParser("15:04:05.000")
+	parser.Location = time.UTC
+	at, err := parser.Parse("[12:56:55.250] message")
+	if err != nil || at.Nanosecond() != 250000000 || parser.Layout() != "15:04:05.000" {
+		t.Errorf("unexpected timestamp %v (%v)", at, err)
+	}
+	if _, err := parser.Parse("no timestamp"); err == nil {
+		t.Errorf("unexpected timestamp found")
+	}
+}
+
+func TestPlausible(t *testing.T) {
+	expected := map[[2]string]bool{
+		{"2016-10-30 12:56:55", "2006-01-02 15:04:05"}:   true,
+		{"message 12", "2006-01-02 15:04:05"}:            false,
+		{"Oct 30 12:56:55 host", "Jan _2 15:04:05"}:      true,
+		{"Octopus 30 12:56:55", "Jan _2 15:04:05"}:       true,
+		{"Connection reset", "Mon Jan _2 15:04:05 2006"}: false,
+		{" 5 12:56:55", "_2 15:04:05"}:                   true,
+		{"", "2006"}:                                     false,
+	}
+	for test, result := range expected {
+		if plausible(test[0], test[1]) != result {
+			t.Errorf("plausible(%q, %q) != %v", test[0], test[1], result)
+		}
+	}
+}
+
+func makeSortedLog(t *testing.T, start time.Time, count int) string {
+	var lines []string
+	for i := 0; i < count; i++ {
+		at := start.Add(time.Duration(i) * time.Second)
+		lines = append(lines, fmt.Sprintf("%s message %d", at.Format("2006-01-02 15:04:05"), i))
+		if i%10 == 0 {
+			lines = append(lines, "\tstack trace line")
+		}
+	}
+	path := filepath.Join(t.TempDir(), "sorted.log")
+	err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadTimeRange(t *testing.T) {
+	start := time.Date(2016, 10, 30, 14, 0, 0, 0, time.UTC)
+	path := makeSortedLog(t, start, 1000)
+	parser := NewTimeParser()
+	parser.Location = time.UTC
+	var records []*Record
+	err := ReadTimeRange(path, parser, start.Add(100*time.Second), start.Add(111*time.Second),
+		func(record *Record) {
+			records = append(records, record)
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 11 stamped lines and the stack traces of messages 100 and 110
+	if len(records) != 13 {
+		t.Fatalf("expected 13 records, got %d", len(records))
+	}
+	if records[0].Line != "2016-10-30 14:01:40 message 100" || records[1].Line != "\tstack trace line" ||
+		!records[1].Time.Equal(records[0].Time) || records[12].Line != "\tstack trace line" {
+		t.Errorf("unexpected records %q, %q, %q", records[0].Line, records[1].Line, records[12].Line)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(content[records[0].Offset:]), records[0].Line) {
+		t.Errorf("unexpected offset %d", records[0].Offset)
+	}
+
+	records = nil
+	err = ReadTimeRange(path, parser, start.Add(-time.Hour), start.Add(time.Second), func(record *Record) {
+		records = append(records, record)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Offset != 0 {
+		t.Errorf("unexpected records at the beginning %v", records)
+	}
+	records = nil
+	err = ReadTimeRange(path, parser, start.Add(time.Hour), start.Add(2*time.Hour), func(record *Record) {
+		records = append(records, record)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 0 {
+		t.Errorf("unexpected records after the end %v", records)
+	}
+}
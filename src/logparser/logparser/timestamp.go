@@ -0,0 +1,157 @@
+package logparser
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// DefaultLayouts are the timestamp layouts tried by a TimeParser when
+// none are given.
+var DefaultLayouts = []string{
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05,999999999",
+	"2006/01/02 15:04:05.999999999",
+	"02/Jan/2006:15:04:05 -0700",
+	"Mon Jan _2 15:04:05 2006",
+	"Mon Jan _2 15:04:05.999999999 2006",
+	"Jan _2 15:04:05",
+}
+
+var errNoTimestamp = errors.New("no timestamp found")
+
+const (
+	// maximum number of leading fields skipped while looking for a timestamp
+	maxTimestampFields = 3
+	// maximum difference between the length of a layout and its value
+	maxLayoutShrink = 12
+	maxLayoutGrowth = 8
+)
+
+// TimeParser extracts timestamps from log lines. The layout and its
+// position in the line are detected on the first stamped line and tried
+// first on the following ones.
+type TimeParser struct {
+	// Layouts are the candidate layouts, in time.Parse format.
+	Layouts []string
+	// Location is used for the timestamps without time zone.
+	Location *time.Location
+	// Year is used for the timestamps without year, the current one if 0.
+	Year int
+
+	layout string
+	field  int
+}
+
+// NewTimeParser creates a timestamp parser trying the given layouts or
+// DefaultLayouts if none are given.
+func NewTimeParser(layouts ...string) *TimeParser {
+	if len(layouts) == 0 {
+		layouts = DefaultLayouts
+	}
+	return &TimeParser{
+		Layouts:  layouts,
+		Location: time.Local,
+		field:    -1,
+	}
+}
+
+// Layout returns the detected layout, if any.
+func (p *TimeParser) Layout() string {
+	return p.layout
+}
+
+// starts returns the candidate timestamp positions in the line: its
+// beginning, the beginning of its first fields and after a '['.
+func starts(line string) []int {
+	positions := []int{0}
+	for i := 1; i < len(line) && len(positions) <= maxTimestampFields; i++ {
+		if line[i-1] == ' ' && line[i] != ' ' {
+			positions = append(positions, i)
+		}
+	}
+	if bracket := strings.IndexByte(line, '['); bracket >= 0 && bracket+1 < len(line) {
+		positions = append(positions, bracket+1)
+	}
+	return positions
+}
+
+// names are the abbreviations starting the day and month names.
+var names = map[string]bool{
+	"Mon": true, "Tue": true, "Wed": true, "Thu": true, "Fri": true, "Sat": true, "Sun": true,
+	"Jan": true, "Feb": true, "Mar": true, "Apr": true, "May": true, "Jun": true,
+	"Jul": true, "Aug": true, "Sep": true, "Oct": true, "Nov": true, "Dec": true,
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// plausible tells whether the value may start with a timestamp of the
+// layout, from its first characters, so that the lines without timestamp
+// are rejected without parsing.
+func plausible(value, layout string) bool {
+	if len(value) == 0 || len(layout) == 0 {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(layout, "Mon") || strings.HasPrefix(layout, "Jan"):
+		return len(value) >= 3 && names[value[:3]]
+	case layout[0] == '_':
+		return isDigit(value[0]) || value[0] == ' '
+	case isDigit(layout[0]):
+		return isDigit(value[0])
+	}
+	return true
+}
+
+func (p *TimeParser) parseAt(line string, start int, layout string) (time.Time, bool) {
+	value := line[start:]
+	if !plausible(value, layout) {
+		return time.Time{}, false
+	}
+	longest := len(layout) + maxLayoutGrowth
+	if longest > len(value) {
+		longest = len(value)
+	}
+	for n := longest; n >= len(layout)-maxLayoutShrink && n > 0; n-- {
+		if n < len(value) && isDigit(value[n]) {
+			continue
+		}
+		t, err := time.ParseInLocation(layout, value[:n], p.Location)
+		if err == nil {
+			if t.Year() == 0 {
+				year := p.Year
+				if year == 0 {
+					year = time.Now().Year()
+				}
+				t = t.AddDate(year, 0, 0)
+			}
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Parse returns the timestamp found in the line.
+func (p *TimeParser) Parse(line string) (time.Time, error) {
+	positions := starts(line)
+	if p.layout != "" && p.field < len(positions) {
+		if t, ok := p.parseAt(line, positions[p.field], p.layout); ok {
+			return t, nil
+		}
+	}
+	for field, start := range positions {
+		for _, layout := range p.Layouts {
+			if t, ok := p.parseAt(line, start, layout); ok {
+				p.layout = layout
+				p.field = field
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, errNoTimestamp
+}
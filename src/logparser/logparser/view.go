@@ -0,0 +1,323 @@
+package logparser
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// rows used by the histogram, the status and the filter lines
+	viewHeaderRows = 2
+	viewFooterRows = 2
+
+	resetColor   = "\x1b[0m"
+	reverseColor = "\x1b[7m"
+	boldColor    = "\x1b[1m"
+	keyColor     = "\x1b[36m"
+	errorColor   = "\x1b[31m"
+	warnColor    = "\x1b[33m"
+	infoColor    = "\x1b[32m"
+	debugColor   = "\x1b[34m"
+	clearLine    = "\x1b[K"
+)
+
+var (
+	sparks      = []rune(" ▁▂▃▄▅▆▇█")
+	levelColors = map[string]string{"ERROR": errorColor, "FATAL": errorColor, "WARN": warnColor, "INFO": infoColor, "DEBUG": debugColor, "TRACE": debugColor}
+	levelRegexp = regexp.MustCompile(`\b(TRACE|DEBUG|INFO|NOTICE|WARN|WARNING|ERR|ERROR|CRIT|CRITICAL|FATAL|PANIC)\b`)
+)
+
+// View is a scrollable view over records, filtered with a filter
+// expression (see ParseFilter). It renders as a terminal screen with a
+// histogram of the records over time, the records with their fields and
+// levels highlighted, a status line and the filter line.
+type View struct {
+	// Name is displayed in the status line.
+	Name string
+	// Follow keeps the last record selected when records are added.
+	Follow bool
+	Width  int
+	Height int
+
+	records    []*Record
+	matches    []int
+	cursor     int
+	top        int
+	filter     Filter
+	expression string
+	err        error
+	message    string
+}
+
+// NewView creates a view of the given size, following the new records.
+func NewView(name string, width, height int) *View {
+	filter, _ := ParseFilter("")
+	return &View{
+		Name:   name,
+		Follow: true,
+		Width:  width,
+		Height: height,
+		filter: filter,
+	}
+}
+
+// Add adds a record to the view.
+func (v *View) Add(record *Record) {
+	v.records = append(v.records, record)
+	if v.filter(record) {
+		v.matches = append(v.matches, len(v.records)-1)
+		if v.Follow {
+			v.Bottom()
+		}
+	}
+}
+
+// Len returns the number of records and of records matching the filter.
+func (v *View) Len() (int, int) {
+	return len(v.records), len(v.matches)
+}
+
+// Filter returns the filter expression and its error, if invalid.
+func (v *View) Filter() (string, error) {
+	return v.expression, v.err
+}
+
+// SetFilter filters the records with the expression. If it is invalid,
+// for instance while being typed, the previous filter is kept and the
+// error returned.
+func (v *View) SetFilter(expression string) error {
+	v.expression = expression
+	filter, err := ParseFilter(expression)
+	v.err = err
+	if err != nil {
+		return err
+	}
+	selected := -1
+	if v.cursor < len(v.matches) {
+		selected = v.matches[v.cursor]
+	}
+	v.filter = filter
+	v.matches = v.matches[:0]
+	v.cursor = 0
+	for index, record := range v.records {
+		if filter(record) {
+			if index <= selected {
+				v.cursor = len(v.matches)
+			}
+			v.matches = append(v.matches, index)
+		}
+	}
+	if v.Follow {
+		v.Bottom()
+	}
+	v.scroll()
+	return nil
+}
+
+// Selected returns the selected record, if any.
+func (v *View) Selected() *Record {
+	if v.cursor < len(v.matches) {
+		return v.records[v.matches[v.cursor]]
+	}
+	return nil
+}
+
+func (v *View) rows() int {
+	rows := v.Height - viewHeaderRows - viewFooterRows
+	if rows < 1 {
+		return 1
+	}
+	return rows
+}
+
+// scroll keeps the cursor on the screen.
+func (v *View) scroll() {
+	if v.cursor < v.top {
+		v.top = v.cursor
+	}
+	if v.cursor >= v.top+v.rows() {
+		v.top = v.cursor - v.rows() + 1
+	}
+	if v.top < 0 {
+		v.top = 0
+	}
+}
+
+// Move moves the selection by the given number of records, a negative
+// one moving up and stopping the follow mode.
+func (v *View) Move(delta int) {
+	v.cursor += delta
+	if v.cursor >= len(v.matches) {
+		v.cursor = len(v.matches) - 1
+	}
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+	if delta < 0 {
+		v.Follow = false
+	}
+	v.message = ""
+	v.scroll()
+}
+
+// Page moves the selection by the given number of screens.
+func (v *View) Page(delta int) {
+	v.Move(delta * v.rows())
+}
+
+// Top selects the first record and stops the follow mode.
+func (v *View) Top() {
+	v.Follow = false
+	v.cursor = 0
+	v.scroll()
+}
+
+// Bottom selects the last record.
+func (v *View) Bottom() {
+	v.cursor = len(v.matches) - 1
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+	v.top = v.cursor - v.rows() + 1
+	v.scroll()
+}
+
+// NextError selects the next error after the selected record, in the
+// given direction. It returns false if there is none.
+func (v *View) NextError(direction int) bool {
+	if direction == 0 {
+		direction = 1
+	}
+	for i := v.cursor + direction; i >= 0 && i < len(v.matches); i += direction {
+		if IsError(v.records[v.matches[i]].Line) {
+			v.Follow = false
+			v.cursor = i
+			v.scroll()
+			v.message = ""
+			return true
+		}
+	}
+	v.message = "no more errors"
+	return false
+}
+
+// Histogram returns the number of matching records in buckets of equal
+// durations between the first and the last stamped matching records.
+func (v *View) Histogram(buckets int) ([]int, time.Time, time.Time) {
+	counts := make([]int, buckets)
+	var first, last time.Time
+	for _, index := range v.matches {
+		at := v.records[index].Time
+		if at.IsZero() {
+			continue
+		}
+		if first.IsZero() || at.Before(first) {
+			first = at
+		}
+		if at.After(last) {
+			last = at
+		}
+	}
+	if first.IsZero() || buckets == 0 {
+		return counts, first, last
+	}
+	span := last.Sub(first)
+	for _, index := range v.matches {
+		at := v.records[index].Time
+		if at.IsZero() {
+			continue
+		}
+		bucket := 0
+		if span > 0 {
+			// in float64, the span in nanoseconds times the buckets
+			// overflowing an int64 over weeks
+			bucket = int(float64(at.Sub(first)) / float64(span) * float64(buckets-1))
+		}
+		counts[min(max(bucket, 0), buckets-1)]++
+	}
+	return counts, first, last
+}
+
+func sparkline(counts []int) string {
+	highest := 0
+	for _, count := range counts {
+		if count > highest {
+			highest = count
+		}
+	}
+	line := make([]rune, len(counts))
+	for i, count := range counts {
+		level := 0
+		if count > 0 {
+			level = 1 + count*(len(sparks)-2)/highest
+		}
+		line[i] = sparks[level]
+	}
+	return string(line)
+}
+
+// truncate cuts the line to the given number of runes, expanding tabs.
+func truncate(line string, width int) string {
+	line = strings.Replace(line, "\t", "    ", -1)
+	if utf8.RuneCountInString(line) <= width {
+		return line
+	}
+	runes := []rune(line)
+	return string(runes[:width])
+}
+
+// highlight colors the fields and the levels of the line.
+func highlight(line string) string {
+	line = fieldRegexp.ReplaceAllString(line, keyColor+"$1"+resetColor+"="+boldColor+"$2"+resetColor)
+	return levelRegexp.ReplaceAllStringFunc(line, func(level string) string {
+		return levelColors[levelNames[level]] + level + resetColor
+	})
+}
+
+// Render writes the whole screen.
+func (v *View) Render(w io.Writer) error {
+	screen := &strings.Builder{}
+	screen.WriteString("\x1b[H")
+	counts, first, last := v.Histogram(v.Width)
+	screen.WriteString(infoColor + sparkline(counts) + resetColor + clearLine + "\r\n")
+	axis := ""
+	if !first.IsZero() {
+		from := first.Format("2006-01-02 15:04:05")
+		to := last.Format("15:04:05")
+		axis = from + strings.Repeat(" ", max(1, v.Width-len(from)-len(to))) + to
+	}
+	screen.WriteString(truncate(axis, v.Width) + clearLine + "\r\n")
+	for row := 0; row < v.rows(); row++ {
+		index := v.top + row
+		if index < len(v.matches) {
+			line := highlight(truncate(v.records[v.matches[index]].Line, v.Width))
+			if index == v.cursor {
+				line = reverseColor + strings.Replace(line, resetColor, resetColor+reverseColor, -1) + resetColor
+			}
+			screen.WriteString(line)
+		}
+		screen.WriteString(clearLine + "\r\n")
+	}
+	mode := ""
+	if v.Follow {
+		mode = " | following"
+	}
+	status := fmt.Sprintf(" %s | %d/%d/%d%s | /:filter n/N:next/previous error f:follow q:quit",
+		v.Name, v.cursor+1, len(v.matches), len(v.records), mode)
+	if len(v.message) > 0 {
+		status += " | " + v.message
+	}
+	status = truncate(status, v.Width)
+	screen.WriteString(reverseColor + status + strings.Repeat(" ", max(0, v.Width-utf8.RuneCountInString(status))) + resetColor + "\r\n")
+	prompt := "/" + v.expression
+	if v.err != nil {
+		prompt += "  " + errorColor + truncate(v.err.Error(), max(0, v.Width-len(prompt)-2)) + resetColor
+	}
+	screen.WriteString(prompt + clearLine)
+	_, err := io.WriteString(w, screen.String())
+	return err
+}
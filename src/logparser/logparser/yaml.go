@@ -0,0 +1,313 @@
+package logparser
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// yamlLine is a significant line of a YAML document, without its
+// indentation and its comment.
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	index int
+}
+
+// parseYAML parses the subset of YAML used by the configuration files into
+// maps, slices and scalars: block mappings and sequences, flow sequences
+// and mappings, quoted and plain scalars and comments. Anchors, tags,
+// block scalars and multiple documents are not supported.
+func parseYAML(content []byte) (interface{}, error) {
+	parser := &yamlParser{}
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(stripYAMLComment(line), " \t\r")
+		text := strings.TrimLeft(line, " ")
+		if len(text) == 0 || (i == 0 && text == "---") {
+			continue
+		}
+		if text[0] == '\t' {
+			return nil, fmt.Errorf("yaml: line %d: tabs are not allowed in indentation", i+1)
+		}
+		parser.lines = append(parser.lines, yamlLine{number: i + 1, indent: len(line) - len(text), text: text})
+	}
+	if len(parser.lines) == 0 {
+		return nil, nil
+	}
+	value, err := parser.parseNode(parser.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if parser.index < len(parser.lines) {
+		return nil, fmt.Errorf("yaml: line %d: unexpected indentation", parser.lines[parser.index].number)
+	}
+	return value, nil
+}
+
+// stripYAMLComment removes the comment of a line, starting with a # at
+// the beginning of the line or after a space, outside the quotes.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func isYAMLItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// parseNode parses the mapping or the sequence starting at the current
+// line, at the given indentation.
+func (p *yamlParser) parseNode(indent int) (interface{}, error) {
+	if isYAMLItem(p.lines[p.index].text) {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+func (p *yamlParser) parseSequence(indent int) (interface{}, error) {
+	items := []interface{}{}
+	for p.index < len(p.lines) && p.lines[p.index].indent == indent && isYAMLItem(p.lines[p.index].text) {
+		line := p.lines[p.index]
+		rest := strings.TrimLeft(line.text[1:], " ")
+		if len(rest) == 0 {
+			p.index++
+			item, err := p.parseChild(indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+		if _, _, ok := splitYAMLKey(rest); ok || isYAMLItem(rest) {
+			// the item is a node starting on the line of the dash,
+			// indented as its first character
+			p.lines[p.index] = yamlLine{number: line.number, indent: indent + len(line.text) - len(rest), text: rest}
+			item, err := p.parseNode(p.lines[p.index].indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+		item, err := parseYAMLScalar(rest, line.number)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		p.index++
+	}
+	return items, nil
+}
+
+func (p *yamlParser) parseMapping(indent int) (interface{}, error) {
+	mapping := map[string]interface{}{}
+	for p.index < len(p.lines) && p.lines[p.index].indent == indent {
+		line := p.lines[p.index]
+		key, value, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("yaml: line %d: expected a key and a value: %s", line.number, line.text)
+		}
+		if _, ok := mapping[key]; ok {
+			return nil, fmt.Errorf("yaml: line %d: duplicate key %q", line.number, key)
+		}
+		p.index++
+		if len(value) == 0 {
+			child, err := p.parseChild(indent)
+			if err != nil {
+				return nil, err
+			}
+			mapping[key] = child
+			continue
+		}
+		scalar, err := parseYAMLScalar(value, line.number)
+		if err != nil {
+			return nil, err
+		}
+		mapping[key] = scalar
+	}
+	return mapping, nil
+}
+
+// parseChild parses the node following a key or a dash without value,
+// more indented than it or, for a sequence, at the same indentation.
+func (p *yamlParser) parseChild(indent int) (interface{}, error) {
+	if p.index >= len(p.lines) {
+		return nil, nil
+	}
+	next := p.lines[p.index]
+	if next.indent > indent || (next.indent == indent && isYAMLItem(next.text)) {
+		return p.parseNode(next.indent)
+	}
+	return nil, nil
+}
+
+// splitYAMLKey splits a "key: value" text, the key being plain or quoted.
+func splitYAMLKey(text string) (string, string, bool) {
+	end := -1
+	if len(text) > 0 && (text[0] == '"' || text[0] == '\'') {
+		end = quotedEnd(text)
+		if end < 0 || end+1 >= len(text) || text[end+1] != ':' {
+			return "", "", false
+		}
+		end++
+	} else if strings.HasSuffix(text, ":") {
+		end = len(text) - 1
+		if index := strings.Index(text, ": "); index >= 0 {
+			end = index
+		}
+	} else {
+		end = strings.Index(text, ": ")
+	}
+	if end <= 0 || text[0] == '[' || text[0] == '{' || isYAMLItem(text) {
+		return "", "", false
+	}
+	if end+1 < len(text) && text[end+1] != ' ' {
+		return "", "", false
+	}
+	key := strings.TrimSpace(text[:end])
+	if key[0] == '"' || key[0] == '\'' {
+		unquoted, err := parseYAMLScalar(key, 0)
+		if err != nil {
+			return "", "", false
+		}
+		key = unquoted.(string)
+	}
+	return key, strings.TrimSpace(text[end+1:]), true
+}
+
+// quotedEnd returns the index of the quote closing the quoted text, or -1.
+func quotedEnd(text string) int {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case text[i] == quote && quote == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// splitYAMLFlow splits the items of a flow sequence or mapping on the
+// commas outside the quotes and the nested collections.
+func splitYAMLFlow(text string, number int) ([]string, error) {
+	var items []string
+	depth, start := 0, 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '"', '\'':
+			end := quotedEnd(text[i:])
+			if end < 0 {
+				return nil, fmt.Errorf("yaml: line %d: unterminated string", number)
+			}
+			i += end
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, strings.TrimSpace(text[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(text[start:]); len(last) > 0 || len(items) > 0 {
+		items = append(items, last)
+	}
+	return items, nil
+}
+
+// parseYAMLScalar parses a scalar or a flow collection.
+func parseYAMLScalar(text string, number int) (interface{}, error) {
+	switch text[0] {
+	case '"':
+		value, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, fmt.Errorf("yaml: line %d: invalid string %s", number, text)
+		}
+		return value, nil
+	case '\'':
+		if quotedEnd(text) != len(text)-1 {
+			return nil, fmt.Errorf("yaml: line %d: invalid string %s", number, text)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	case '[', '{':
+		closing := map[byte]byte{'[': ']', '{': '}'}[text[0]]
+		if text[len(text)-1] != closing {
+			return nil, fmt.Errorf("yaml: line %d: unterminated collection %s", number, text)
+		}
+		items, err := splitYAMLFlow(text[1:len(text)-1], number)
+		if err != nil {
+			return nil, err
+		}
+		if text[0] == '[' {
+			sequence := []interface{}{}
+			for _, item := range items {
+				if len(item) == 0 {
+					return nil, fmt.Errorf("yaml: line %d: empty item in %s", number, text)
+				}
+				value, err := parseYAMLScalar(item, number)
+				if err != nil {
+					return nil, err
+				}
+				sequence = append(sequence, value)
+			}
+			return sequence, nil
+		}
+		mapping := map[string]interface{}{}
+		for _, item := range items {
+			key, value, ok := splitYAMLKey(item)
+			if !ok || len(value) == 0 {
+				return nil, fmt.Errorf("yaml: line %d: invalid mapping item %s", number, item)
+			}
+			if mapping[key], err = parseYAMLScalar(value, number); err != nil {
+				return nil, err
+			}
+		}
+		return mapping, nil
+	case '|', '>', '&', '*', '!':
+		return nil, fmt.Errorf("yaml: line %d: unsupported value %s", number, text)
+	}
+	switch text {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	}
+	if !strings.ContainsAny(text[:1], "+-.0123456789") {
+		return text, nil
+	}
+	if value, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return value, nil
+	}
+	if value, err := strconv.ParseFloat(text, 64); err == nil && !math.IsInf(value, 0) {
+		return value, nil
+	}
+	return text, nil
+}
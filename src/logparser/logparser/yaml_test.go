@@ -0,0 +1,52 @@
+package logparser
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	content := `---
+# alerts
+rules:
+  - name: "api errors" # quoted
+    filter: level=error source=api
+    window: 1m
+    above: 50
+  - name: 'it''s #1'
+    absent: true
+    ratio: 0.5
+sinks:
+- type: webhook
+  url: http://localhost:9093/alerts
+- {type: command, command: [notify-send, "log alert"]}
+empty:
+`
+	value, err := parseYAML([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"empty":null,"rules":[{"above":50,"filter":"level=error source=api","name":"api errors","window":"1m"},` +
+		`{"absent":true,"name":"it's #1","ratio":0.5}],"sinks":[{"type":"webhook","url":"http://localhost:9093/alerts"},` +
+		`{"command":["notify-send","log alert"],"type":"command"}]}`
+	if string(result) != expected {
+		t.Errorf("unexpected value %s", result)
+	}
+	for _, invalid := range []string{
+		"key: value\n  other: value",
+		"key: value\nno key",
+		"key: 1\nkey: 2",
+		"key: [a, b",
+		"key: \"unterminated",
+		"key: |\n  text",
+		"key:\n\t- tab",
+	} {
+		if _, err := parseYAML([]byte(invalid)); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
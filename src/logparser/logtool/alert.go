@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"logparser/logparser"
+	"os"
+)
+
+func runAlert(args []string) error {
+	flags := flag.NewFlagSet("alert", flag.ExitOnError)
+	rules := flags.String("rules", "", "JSON or YAML file with the alert rules and sinks")
+	webhook := flags.String("webhook", "", "also post the alert events to this URL")
+	fromStart := flags.Bool("from-start", false, "read the file from its beginning instead of its end")
+	layouts := flags.String("layout", "", "comma separated timestamp layouts, auto-detected by default")
+	flags.Parse(args)
+	if len(*rules) == 0 || flags.NArg() != 1 {
+		return fmt.Errorf("you must specify the rules (-rules) and one file to follow, or - for stdin")
+	}
+	alerts, err := logparser.LoadAlerts(*rules)
+	if err != nil {
+		return err
+	}
+	if alerts.Sinks() == 0 {
+		alerts.AddSink(&logparser.WriterSink{Writer: os.Stdout})
+	}
+	if len(*webhook) > 0 {
+		alerts.AddSink(&logparser.WebhookSink{URL: *webhook})
+	}
+	var records logparser.RecordReader
+	if flags.Arg(0) == "-" {
+		records = logparser.NewReader(os.Stdin, makeParser(*layouts), 0)
+	} else {
+		offset := int64(-1)
+		if *fromStart {
+			offset = 0
+		}
+		follower, err := logparser.Follow(flags.Arg(0), makeParser(*layouts), offset)
+		if err != nil {
+			return err
+		}
+		defer follower.Close()
+		name, _ := sourceArg(flags.Arg(0))
+		records = &namedReader{records: follower, name: name}
+	}
+	log.Println("evaluating alert rules from", *rules)
+	return alerts.Run(records, func(err error) {
+		log.Println(err)
+	})
+}
+
+// namedReader sets the source of the records, for the source field of
+// the filters.
+type namedReader struct {
+	records logparser.RecordReader
+	name    string
+}
+
+func (r *namedReader) Next() (*logparser.Record, error) {
+	record, err := r.records.Next()
+	if err == nil {
+		record.Source = r.name
+	}
+	return record, err
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"logparser/logparser"
+	"net/http"
+	"os"
+	"regexp"
+)
+
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "csv", "output format: csv, json or prom")
+	records := flags.Bool("records", false, "export the records instead of the [[value]] aggregates")
+	match := flags.String("match", "", "only export the lines matching this regular expression")
+	listen := flags.String("listen", "", "follow the file and serve the aggregates on http://ADDRESS/metrics")
+	name := flags.String("name", "logparser_values", "Prometheus metric name")
+	layouts := flags.String("layout", "", "comma separated timestamp layouts, auto-detected by default")
+	checkpointPath := flags.String("checkpoint", "", "only read the lines appended since the last run saved in this checkpoint file")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("you must specify one file to export")
+	}
+	var filter *regexp.Regexp
+	if len(*match) > 0 {
+		var err error
+		filter, err = regexp.Compile(*match)
+		if err != nil {
+			return err
+		}
+	}
+	if len(*listen) > 0 {
+		return serveMetrics(flags.Arg(0), *listen, *name, filter, makeParser(*layouts))
+	}
+	output := bufio.NewWriter(os.Stdout)
+	var writer *logparser.RecordWriter
+	if *records {
+		var err error
+		writer, err = logparser.NewRecordWriter(output, *format)
+		if err != nil {
+			return err
+		}
+	} else if *format != "csv" && *format != "json" && *format != "prom" {
+		return fmt.Errorf("unknown format: %s", *format)
+	}
+	checkpoint := &logparser.Checkpoint{Values: make(map[string]float64)}
+	if len(*checkpointPath) > 0 {
+		var err error
+		checkpoint, err = logparser.LoadCheckpoint(*checkpointPath)
+		if err != nil {
+			return err
+		}
+	}
+	values := checkpoint.Values
+	var writeErr error
+	process := func(record *logparser.Record) {
+		if filter != nil && !filter.MatchString(record.Line) {
+			return
+		}
+		if writer != nil {
+			if writeErr == nil {
+				writeErr = writer.Write(record)
+			}
+		} else if key, value, err := logparser.GetMapValue(record.Line); err == nil {
+			values[key] += value
+		}
+	}
+	if len(*checkpointPath) == 0 {
+		if err := readFile(flags.Arg(0), makeParser(*layouts), process); err != nil {
+			return err
+		}
+	} else if err := checkpoint.Process(flags.Arg(0), makeParser(*layouts), process); err != nil {
+		return err
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	if err := writeValues(output, writer, *format, *name, values); err != nil {
+		return err
+	}
+	if err := output.Flush(); err != nil {
+		return err
+	}
+	// the checkpoint is saved once exported, so that the lines are read
+	// again if the export failed
+	if len(*checkpointPath) > 0 {
+		return checkpoint.Save(*checkpointPath)
+	}
+	return nil
+}
+
+// writeValues flushes the records of the writer, if any, or writes the
+// aggregated values in the format.
+func writeValues(output io.Writer, writer *logparser.RecordWriter, format, name string, values map[string]float64) error {
+	if writer != nil {
+		return writer.Flush()
+	}
+	switch format {
+	case "csv":
+		return logparser.WriteValuesCSV(output, values)
+	case "json":
+		return logparser.WriteValuesJSON(output, values)
+	case "prom":
+		return logparser.WriteValuesPrometheus(output, name, "Sum of the [[value]] log values.", values)
+	}
+	return fmt.Errorf("unknown format: %s", format)
+}
+
+// readFile calls operand on every record of the file.
+func readFile(path string, parser *logparser.TimeParser, operand func(*logparser.Record)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader := logparser.NewReader(file, parser, 0)
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		operand(record)
+	}
+}
+
+// serveMetrics follows the file from its beginning and serves the
+// aggregates updated on every new line.
+func serveMetrics(path, address, name string, filter *regexp.Regexp, parser *logparser.TimeParser) error {
+	follower, err := logparser.Follow(path, parser, 0)
+	if err != nil {
+		return err
+	}
+	defer follower.Close()
+	metrics := logparser.NewMetrics(name, "Sum of the [[value]] log values.")
+	go func() {
+		for {
+			record, err := follower.Next()
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			if filter != nil && !filter.MatchString(record.Line) {
+				continue
+			}
+			if key, value, err := logparser.GetMapValue(record.Line); err == nil {
+				metrics.Add(key, value)
+			} else {
+				metrics.Count()
+			}
+		}
+	}()
+	http.Handle("/metrics", metrics)
+	log.Printf("serving metrics on http://%s/metrics", address)
+	return http.ListenAndServe(address, nil)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"merge":  {"merge [OPTIONS] [NAME=]FILE...", runMerge},
+	"export": {"export [OPTIONS] FILE", runExport},
+	"redact": {"redact [OPTIONS] FILE|-", runRedact},
+	"tui":    {"tui [OPTIONS] FILE|-", runTUI},
+	"alert":  {"alert -rules RULES [OPTIONS] FILE|-", runAlert},
+}
+
+func usage() {
+	// http://patorjk.com/software/taag/#p=display&f=Big
+	fmt.Fprintf(os.Stderr, ""+
+		`logtool COMMAND [OPTIONS]
+
+---------------------------------------
+  _                 _              _ 
+ | |               | |            | |
+ | |     ___   __ _| |_ ___   ___ | |
+ | |    / _ \ / _`+"`"+` | __/ _ \ / _ \| |
+ | |___| (_) | (_| | || (_) | (_) | |
+ |______\___/ \__, |\__\___/ \___/|_|
+               __/ |                 
+              |___/                  
+---------------------------------------
+
+Usage:
+
+  logtool merge -skew db=-2s api=api.log db=db.log
+
+starts "logtool.exe"
+ - merging the api.log and db.log files by timestamp
+ - with the db timestamps shifted by 2 seconds backward.
+
+Commands:
+`)
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  logtool %s\n", commands[name].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nUse \"logtool COMMAND -h\" for the options of a command.\n")
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	name := flag.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		log.Printf("unknown command: %s", name)
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(flag.Args()[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"logparser/logparser"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// skews maps source names to clock skew offsets, set with name=duration.
+type skews map[string]time.Duration
+
+func (s skews) String() string {
+	var values []string
+	for name, skew := range s {
+		values = append(values, name+"="+skew.String())
+	}
+	return strings.Join(values, ",")
+}
+
+func (s skews) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid skew, expected name=duration: %s", value)
+	}
+	skew, err := time.ParseDuration(parts[1])
+	if err != nil {
+		return err
+	}
+	s[parts[0]] = skew
+	return nil
+}
+
+// sourceArg splits a [NAME=]FILE argument, the name defaulting to the
+// base name of the file.
+func sourceArg(arg string) (string, string) {
+	parts := strings.SplitN(arg, "=", 2)
+	if len(parts) == 2 && len(parts[0]) > 0 {
+		return parts[0], parts[1]
+	}
+	name := filepath.Base(arg)
+	return strings.TrimSuffix(name, filepath.Ext(name)), arg
+}
+
+// makeParser returns a timestamp parser with the given comma separated
+// layouts or with auto-detection if empty.
+func makeParser(layouts string) *logparser.TimeParser {
+	if len(layouts) == 0 {
+		return logparser.NewTimeParser()
+	}
+	return logparser.NewTimeParser(strings.Split(layouts, ",")...)
+}
+
+func runMerge(args []string) error {
+	flags := flag.NewFlagSet("merge", flag.ExitOnError)
+	offsets := skews{}
+	flags.Var(offsets, "skew", "clock skew of a source as name=duration, can be repeated")
+	layouts := flags.String("layout", "", "comma separated timestamp layouts, auto-detected by default")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		return fmt.Errorf("you must specify at least one file to merge")
+	}
+	var sources []*logparser.Source
+	for _, arg := range flags.Args() {
+		name, path := sourceArg(arg)
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		sources = append(sources, &logparser.Source{
+			Name:    name,
+			Records: logparser.NewReader(file, makeParser(*layouts), 0),
+			Skew:    offsets[name],
+		})
+	}
+	output := bufio.NewWriter(os.Stdout)
+	defer output.Flush()
+	merger := logparser.NewMerger(sources...)
+	for {
+		record, err := merger.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(output, "[%s] %s\n", record.Source, record.Line)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"logparser/logparser"
+	"os"
+	"strings"
+)
+
+// patterns are custom redaction patterns, set with name=regexp.
+type patterns []string
+
+func (p *patterns) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *patterns) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("invalid rule, expected name=regexp: %s", value)
+	}
+	*p = append(*p, value)
+	return nil
+}
+
+func runRedact(args []string) error {
+	flags := flag.NewFlagSet("redact", flag.ExitOnError)
+	name := flags.String("action", "mask", "redaction action: mask, hash or drop")
+	key := flags.String("key", os.Getenv("LOGTOOL_REDACT_KEY"), "hash key, defaults to $LOGTOOL_REDACT_KEY")
+	builtin := flags.Bool("builtin", true, "use the built-in rules (email, ip, card, bearer, secret)")
+	custom := patterns{}
+	flags.Var(&custom, "rule", "custom rule as name=regexp, can be repeated")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("you must specify one file to redact, or - for stdin")
+	}
+	action, err := logparser.ParseAction(*name)
+	if err != nil {
+		return err
+	}
+	if action == logparser.Hash && len(*key) == 0 {
+		return fmt.Errorf("the hash action requires a key (-key)")
+	}
+	var rules []*logparser.Rule
+	if *builtin {
+		rules = logparser.BuiltinRules(action)
+	}
+	for _, value := range custom {
+		parts := strings.SplitN(value, "=", 2)
+		rule, err := logparser.NewRule(parts[0], parts[1], action)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, rule)
+	}
+	input := io.Reader(os.Stdin)
+	if flags.Arg(0) != "-" {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+	redactor := logparser.NewRedactor([]byte(*key), rules...)
+	records := redactor.Reader(logparser.NewReader(input, logparser.NewTimeParser(), 0))
+	output := bufio.NewWriter(os.Stdout)
+	for {
+		record, err := records.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(output, record.Line)
+	}
+	if err := output.Flush(); err != nil {
+		return err
+	}
+	return redactor.WriteReport(os.Stderr)
+}
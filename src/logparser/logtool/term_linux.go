@@ -0,0 +1,73 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// terminal is the controlling terminal in raw mode, read for the keys
+// even when the logs are read from stdin.
+type terminal struct {
+	tty   *os.File
+	state syscall.Termios
+}
+
+func ioctl(fd uintptr, request uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func openTerminal() (*terminal, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	t := &terminal{tty: tty}
+	if err := ioctl(tty.Fd(), syscall.TCGETS, unsafe.Pointer(&t.state)); err != nil {
+		tty.Close()
+		return nil, err
+	}
+	raw := t.state
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(tty.Fd(), syscall.TCSETS, unsafe.Pointer(&raw)); err != nil {
+		tty.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *terminal) size() (int, int) {
+	var size struct {
+		rows, cols, x, y uint16
+	}
+	if err := ioctl(t.tty.Fd(), syscall.TIOCGWINSZ, unsafe.Pointer(&size)); err != nil || size.cols == 0 {
+		return 80, 24
+	}
+	return int(size.cols), int(size.rows)
+}
+
+// resized returns the channel notified when the terminal is resized.
+func (t *terminal) resized() <-chan os.Signal {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGWINCH)
+	return signals
+}
+
+func (t *terminal) close() error {
+	ioctl(t.tty.Fd(), syscall.TCSETS, unsafe.Pointer(&t.state))
+	return t.tty.Close()
+}
@@ -0,0 +1,28 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+type terminal struct {
+	tty *os.File
+}
+
+func openTerminal() (*terminal, error) {
+	return nil, errors.New("the tui command is only supported on Linux")
+}
+
+func (t *terminal) size() (int, int) {
+	return 80, 24
+}
+
+func (t *terminal) resized() <-chan os.Signal {
+	return nil
+}
+
+func (t *terminal) close() error {
+	return nil
+}
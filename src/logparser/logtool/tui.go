@@ -0,0 +1,197 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"logparser/logparser"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	refreshInterval = 50 * time.Millisecond
+	maxBatch        = 4096
+)
+
+// readKeys decodes the keys typed on the terminal: the printable
+// characters as themselves and the special keys by name (up, down, pgup,
+// pgdown, home, end, enter, backspace, esc, ctrl-c).
+func readKeys(tty io.Reader, keys chan<- string) {
+	sequences := map[string]string{
+		"\x1b[A": "up", "\x1b[B": "down", "\x1b[5~": "pgup", "\x1b[6~": "pgdown",
+		"\x1b[H": "home", "\x1b[1~": "home", "\x1bOH": "home",
+		"\x1b[F": "end", "\x1b[4~": "end", "\x1bOF": "end",
+	}
+	buffer := make([]byte, 64)
+	for {
+		n, err := tty.Read(buffer)
+		if err != nil {
+			close(keys)
+			return
+		}
+		input := string(buffer[:n])
+		for len(input) > 0 {
+			key := ""
+			for sequence, name := range sequences {
+				if len(input) >= len(sequence) && input[:len(sequence)] == sequence {
+					key = name
+					input = input[len(sequence):]
+					break
+				}
+			}
+			if len(key) == 0 {
+				c := input[0]
+				input = input[1:]
+				switch c {
+				case 3:
+					key = "ctrl-c"
+				case '\r', '\n':
+					key = "enter"
+				case 8, 127:
+					key = "backspace"
+				case 0x1b:
+					key = "esc"
+				default:
+					key = string(c)
+				}
+			}
+			keys <- key
+		}
+	}
+}
+
+// readRecords sends the records read until the end of the source.
+func readRecords(records logparser.RecordReader, output chan<- *logparser.Record) {
+	defer close(output)
+	for {
+		record, err := records.Next()
+		if err != nil {
+			return
+		}
+		output <- record
+	}
+}
+
+func runTUI(args []string) error {
+	flags := flag.NewFlagSet("tui", flag.ExitOnError)
+	expression := flags.String("filter", "", "initial filter expression")
+	layouts := flags.String("layout", "", "comma separated timestamp layouts, auto-detected by default")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("you must specify one file to explore, or - for stdin")
+	}
+	name := flags.Arg(0)
+	var source logparser.RecordReader
+	if name == "-" {
+		name = "stdin"
+		source = logparser.NewReader(os.Stdin, makeParser(*layouts), 0)
+	} else {
+		follower, err := logparser.Follow(name, makeParser(*layouts), 0)
+		if err != nil {
+			return err
+		}
+		defer follower.Close()
+		name = filepath.Base(name)
+		source = follower
+	}
+	term, err := openTerminal()
+	if err != nil {
+		return err
+	}
+	defer term.close()
+	// alternate screen without cursor
+	fmt.Fprint(term.tty, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(term.tty, "\x1b[?25h\x1b[?1049l")
+
+	width, height := term.size()
+	view := logparser.NewView(name, width, height)
+	if err := view.SetFilter(*expression); err != nil {
+		return err
+	}
+	records := make(chan *logparser.Record, maxBatch)
+	go readRecords(source, records)
+	keys := make(chan string)
+	go readKeys(term.tty, keys)
+	resized := term.resized()
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	editing := false
+	dirty := true
+	for {
+		select {
+		case record, ok := <-records:
+			if !ok {
+				records = nil
+				continue
+			}
+			view.Add(record)
+			dirty = true
+		case <-resized:
+			view.Width, view.Height = term.size()
+			view.Move(0)
+			dirty = true
+		case key, ok := <-keys:
+			if !ok || key == "ctrl-c" || (!editing && key == "q") {
+				return nil
+			}
+			editing = handleKey(view, key, editing)
+			dirty = true
+		case <-ticker.C:
+			if dirty {
+				view.Render(term.tty)
+				dirty = false
+			}
+		}
+	}
+}
+
+// handleKey updates the view with the key and returns whether the filter
+// is being edited.
+func handleKey(view *logparser.View, key string, editing bool) bool {
+	if editing {
+		expression, _ := view.Filter()
+		switch key {
+		case "enter", "esc":
+			return false
+		case "backspace":
+			if len(expression) > 0 {
+				view.SetFilter(expression[:len(expression)-1])
+			}
+		default:
+			if len(key) == 1 {
+				view.SetFilter(expression + key)
+			}
+		}
+		return true
+	}
+	switch key {
+	case "/":
+		return true
+	case "up", "k":
+		view.Move(-1)
+	case "down", "j":
+		view.Move(1)
+	case "pgup", "b":
+		view.Page(-1)
+	case "pgdown", " ":
+		view.Page(1)
+	case "home", "g":
+		view.Top()
+	case "end", "G":
+		view.Follow = true
+		view.Bottom()
+	case "f":
+		view.Follow = !view.Follow
+		if view.Follow {
+			view.Bottom()
+		}
+	case "n":
+		view.NextError(1)
+	case "N":
+		view.NextError(-1)
+	}
+	return false
+}
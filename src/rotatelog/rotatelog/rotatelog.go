@@ -0,0 +1,403 @@
+package rotatelog
+
+import (
+	"compress/compress"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	gzipExt          = ".gz"
+	zipExt           = ".zip"
+)
+
+// Compression is the compression applied to the rotated files.
+type Compression int
+
+const (
+	// None keeps the rotated files as they are.
+	None Compression = iota
+	// Gzip compresses the rotated files with gzip.
+	Gzip
+	// Zip archives the rotated files with the compress package.
+	Zip
+)
+
+// Hourly is a schedule rotating the files at every full hour.
+func Hourly(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, t.Hour()+1, 0, 0, 0, t.Location())
+}
+
+// Daily is a schedule rotating the files at midnight.
+func Daily(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, t.Location())
+}
+
+// Writer is an io.Writer writing to a file rotated by size, by age or on
+// a schedule. The rotated files are renamed with their rotation time in
+// UTC, for instance app-2016-10-30T12-56-55.000.log, and optionally
+// compressed. It is safe for concurrent use, for instance as the output
+// of a log.Logger.
+type Writer struct {
+	// Filename is the file to write to.
+	Filename string
+	// MaxSize is the size in bytes above which the file is rotated.
+	MaxSize int64
+	// MaxFileAge is the duration after which the file is rotated, counted
+	// from its opening.
+	MaxFileAge time.Duration
+	// Schedule returns the next rotation time after the given time, see
+	// Hourly and Daily.
+	Schedule func(time.Time) time.Time
+	// MaxBackups is the maximum number of rotated files to keep.
+	MaxBackups int
+	// MaxAge is the maximum age of the rotated files to keep.
+	MaxAge time.Duration
+	// Compression is the compression of the rotated files.
+	Compression Compression
+
+	mutex  sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+	next   time.Time
+	now    func() time.Time
+	// cleanups are the rotated files compressed then cleaned up in order
+	// by a single goroutine, started by the first rotation.
+	cleanups chan cleanup
+	pending  sync.WaitGroup
+	// err is the first error of the compressions and removals, returned
+	// by Close.
+	err     error
+	signals chan os.Signal
+}
+
+// cleanup is a rotated file to compress, if renamed, before removing the
+// old backups.
+type cleanup struct {
+	backup      string
+	renamed     bool
+	compression Compression
+}
+
+// NewWriter creates a rotating writer to the given file, which is opened
+// on the first write. Without any limit set, the file is never rotated.
+func NewWriter(filename string) *Writer {
+	return &Writer{
+		Filename: filename,
+		now:      time.Now,
+	}
+}
+
+func (w *Writer) open() error {
+	err := os.MkdirAll(filepath.Dir(w.Filename), 0755)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(w.Filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	w.opened = w.now()
+	if w.Schedule != nil {
+		w.next = w.Schedule(w.opened)
+	}
+	return nil
+}
+
+func (w *Writer) close() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *Writer) shouldRotate(length int) bool {
+	if w.MaxSize > 0 && w.size > 0 && w.size+int64(length) > w.MaxSize {
+		return true
+	}
+	now := w.now()
+	if w.MaxFileAge > 0 && now.Sub(w.opened) >= w.MaxFileAge {
+		return true
+	}
+	return w.Schedule != nil && !now.Before(w.next)
+}
+
+// Write writes to the file, rotating it before if needed.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if w.shouldRotate(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *Writer) split() (string, string) {
+	ext := filepath.Ext(w.Filename)
+	return strings.TrimSuffix(w.Filename, ext) + "-", ext
+}
+
+func (w *Writer) backupName(at time.Time) string {
+	prefix, ext := w.split()
+	for {
+		name := prefix + at.UTC().Format(backupTimeFormat) + ext
+		_, err := os.Stat(name)
+		_, errGzip := os.Stat(name + gzipExt)
+		_, errZip := os.Stat(name + zipExt)
+		if os.IsNotExist(err) && os.IsNotExist(errGzip) && os.IsNotExist(errZip) {
+			return name
+		}
+		at = at.Add(time.Millisecond)
+	}
+}
+
+func (w *Writer) rotate() error {
+	if err := w.close(); err != nil {
+		return err
+	}
+	backup := w.backupName(w.now())
+	err := os.Rename(w.Filename, backup)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if w.cleanups == nil {
+		w.cleanups = make(chan cleanup, 16)
+		w.pending.Add(1)
+		go w.clean(w.cleanups)
+	}
+	w.cleanups <- cleanup{backup: backup, renamed: err == nil, compression: w.Compression}
+	return w.open()
+}
+
+// clean compresses the rotated files and removes the old backups, one
+// rotation after the other, until the channel is closed.
+func (w *Writer) clean(cleanups chan cleanup) {
+	defer w.pending.Done()
+	for c := range cleanups {
+		if c.renamed {
+			w.fail(compressFile(c.backup, c.compression))
+		}
+		until, _ := w.backupTime(c.backup)
+		w.fail(w.removeBackups(until))
+	}
+}
+
+// fail records the error if it is the first one.
+func (w *Writer) fail(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// Rotate rotates the file immediately.
+func (w *Writer) Rotate() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	return w.rotate()
+}
+
+// Reopen closes and reopens the file, for instance after it has been
+// moved by an external tool. It does nothing if the file is not open, such
+// as after Close.
+func (w *Writer) Reopen() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	if err := w.close(); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+// ReopenOnSIGHUP reopens the file every time the process receives SIGHUP,
+// until the writer is closed.
+func (w *Writer) ReopenOnSIGHUP() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.signals != nil {
+		return
+	}
+	w.signals = make(chan os.Signal, 1)
+	signal.Notify(w.signals, syscall.SIGHUP)
+	go func(signals chan os.Signal) {
+		for range signals {
+			w.Reopen()
+		}
+	}(w.signals)
+}
+
+// Close closes the file and waits for the pending compressions. It
+// returns the first error of the compressions and removals of the rotated
+// files since the last call, if the file closed without error.
+func (w *Writer) Close() error {
+	w.mutex.Lock()
+	if w.signals != nil {
+		signal.Stop(w.signals)
+		close(w.signals)
+		w.signals = nil
+	}
+	err := w.close()
+	if w.cleanups != nil {
+		close(w.cleanups)
+		w.cleanups = nil
+	}
+	w.pending.Wait()
+	if err == nil {
+		err = w.err
+	}
+	w.err = nil
+	w.mutex.Unlock()
+	return err
+}
+
+func compressFile(path string, compression Compression) error {
+	switch compression {
+	case Gzip:
+		if err := gzipFile(path); err != nil {
+			return err
+		}
+	case Zip:
+		if _, err := compress.ZipFile(path); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+	return os.Remove(path)
+}
+
+func gzipFile(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	target, err := os.Create(path + gzipExt)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+	writer := gzip.NewWriter(target)
+	writer.Name = filepath.Base(path)
+	if _, err = io.Copy(writer, source); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+type backup struct {
+	path string
+	at   time.Time
+}
+
+// Backups returns the rotated files, newest first.
+func (w *Writer) Backups() ([]string, error) {
+	backups, err := w.backups()
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, backup := range backups {
+		paths = append(paths, backup.path)
+	}
+	return paths, nil
+}
+
+// backupTime returns the rotation time of a rotated file, or false if the
+// path isn't one.
+func (w *Writer) backupTime(path string) (time.Time, bool) {
+	prefix, ext := w.split()
+	name := strings.TrimPrefix(path, prefix)
+	name = strings.TrimSuffix(strings.TrimSuffix(name, gzipExt), zipExt)
+	if !strings.HasSuffix(name, ext) {
+		return time.Time{}, false
+	}
+	at, err := time.Parse(backupTimeFormat, strings.TrimSuffix(name, ext))
+	return at, err == nil
+}
+
+func (w *Writer) backups() ([]backup, error) {
+	prefix, _ := w.split()
+	matches, err := filepath.Glob(prefix + "*")
+	if err != nil {
+		return nil, err
+	}
+	var backups []backup
+	for _, path := range matches {
+		if at, ok := w.backupTime(path); ok {
+			backups = append(backups, backup{path: path, at: at})
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].at.After(backups[j].at)
+	})
+	return backups, nil
+}
+
+// removeBackups removes the rotated files exceeding MaxBackups or MaxAge,
+// up to the one rotated at the given time, the next ones being still
+// compressed.
+func (w *Writer) removeBackups(until time.Time) error {
+	if w.MaxBackups <= 0 && w.MaxAge <= 0 {
+		return nil
+	}
+	backups, err := w.backups()
+	if err != nil {
+		return err
+	}
+	var errs []string
+	now := w.now()
+	for i, backup := range backups {
+		expired := w.MaxAge > 0 && now.Sub(backup.at) > w.MaxAge
+		if backup.at.After(until) {
+			continue
+		}
+		if (w.MaxBackups > 0 && i >= w.MaxBackups) || expired {
+			if err := os.Remove(backup.path); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to remove backups: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
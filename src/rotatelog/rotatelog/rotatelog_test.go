@@ -0,0 +1,229 @@
+package rotatelog
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type clock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *clock) get() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *clock) add(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func makeWriter(t *testing.T) (*Writer, *clock) {
+	c := &clock{now: time.Date(2016, 10, 30, 12, 56, 55, 0, time.UTC)}
+	w := NewWriter(filepath.Join(t.TempDir(), "logs", "app.log"))
+	w.now = c.get
+	return w, c
+}
+
+func checkContent(t *testing.T, path, expected string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != expected {
+		t.Errorf("unexpected content in %s: %q instead of %q", path, content, expected)
+	}
+}
+
+func TestRotateBySize(t *testing.T) {
+	w, c := makeWriter(t)
+	w.MaxSize = 10
+	w.MaxBackups = 2
+	for _, data := range []string{"12345", "67890", "abcde", "fghij", "klmno", "pqrst"} {
+		if _, err := w.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		c.add(time.Second)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := w.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 || filepath.Base(backups[0]) != "app-2016-10-30T12-56-59.000.log" {
+		t.Fatalf("unexpected backups %v", backups)
+	}
+	checkContent(t, w.Filename, "klmnopqrst")
+	checkContent(t, backups[0], "abcdefghij")
+	checkContent(t, backups[1], "1234567890")
+}
+
+func TestRotateByAgeAndSchedule(t *testing.T) {
+	w, c := makeWriter(t)
+	w.MaxFileAge = time.Hour
+	w.MaxAge = 30 * time.Minute
+	w.Write([]byte("first"))
+	c.add(time.Hour)
+	w.Write([]byte("second"))
+	c.add(59 * time.Minute)
+	w.Write([]byte("third"))
+	c.add(time.Minute)
+	w.Write([]byte("fourth"))
+	w.Close()
+	backups, _ := w.Backups()
+	if len(backups) != 1 {
+		t.Fatalf("unexpected backups %v", backups)
+	}
+	checkContent(t, backups[0], "secondthird")
+	checkContent(t, w.Filename, "fourth")
+
+	w, c = makeWriter(t)
+	w.Schedule = Hourly
+	w.Write([]byte("first"))
+	c.add(3 * time.Minute)
+	w.Write([]byte("second"))
+	c.add(2 * time.Minute)
+	w.Write([]byte("third"))
+	w.Close()
+	backups, _ = w.Backups()
+	if len(backups) != 1 || filepath.Base(backups[0]) != "app-2016-10-30T13-01-55.000.log" {
+		t.Fatalf("unexpected backups %v", backups)
+	}
+	checkContent(t, w.Filename, "third")
+	if next := Daily(c.get()); !next.Equal(time.Date(2016, 10, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected daily rotation at %v", next)
+	}
+}
+
+func TestCompression(t *testing.T) {
+	w, _ := makeWriter(t)
+	w.Compression = Gzip
+	w.Write([]byte("gzipped"))
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	w.Compression = Zip
+	w.Write([]byte("zipped"))
+	w.Rotate()
+	w.Close()
+	backups, _ := w.Backups()
+	if len(backups) != 2 || !strings.HasSuffix(backups[0], ".log.zip") || !strings.HasSuffix(backups[1], ".log.gz") {
+		t.Fatalf("unexpected backups %v", backups)
+	}
+	file, err := os.Open(backups[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := io.ReadAll(reader)
+	if string(content) != "gzipped" {
+		t.Errorf("unexpected gzip content %q", content)
+	}
+	archive, err := zip.OpenReader(backups[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	if len(archive.File) != 1 || archive.File[0].Name != strings.TrimSuffix(filepath.Base(backups[0]), ".zip") {
+		t.Fatalf("unexpected zip content %v", archive.File)
+	}
+}
+
+func TestConcurrentLogger(t *testing.T) {
+	w, _ := makeWriter(t)
+	w.MaxSize = 100
+	logger := log.New(w, "", 0)
+	var group sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for j := 0; j < 50; j++ {
+				logger.Println("concurrent line")
+			}
+		}()
+	}
+	group.Wait()
+	w.Close()
+	backups, _ := w.Backups()
+	lines := 0
+	for _, path := range append(backups, w.Filename) {
+		content, _ := os.ReadFile(path)
+		for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+			if line != "concurrent line" {
+				t.Fatalf("unexpected line %q in %s", line, path)
+			}
+			lines++
+		}
+	}
+	if lines != 400 {
+		t.Errorf("expected 400 lines, got %d", lines)
+	}
+}
+
+func TestCleanupError(t *testing.T) {
+	w, _ := makeWriter(t)
+	w.MaxBackups = 1
+	stuck := filepath.Join(filepath.Dir(w.Filename), "app-2000-01-01T00-00-00.000.log")
+	if err := os.MkdirAll(filepath.Join(stuck, "file"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("first"))
+	w.Rotate()
+	if err := w.Close(); err == nil || !strings.Contains(err.Error(), "failed to remove backups") {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("unexpected error %v after the first close", err)
+	}
+}
+
+func TestReopenAfterClose(t *testing.T) {
+	w, _ := makeWriter(t)
+	w.Write([]byte("before"))
+	w.Close()
+	if err := w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	if w.file != nil {
+		t.Errorf("file reopened after close")
+	}
+}
+
+func TestCompressionOrder(t *testing.T) {
+	w, c := makeWriter(t)
+	w.Compression = Gzip
+	w.MaxBackups = 1
+	for i := 0; i < 20; i++ {
+		w.Write([]byte("line"))
+		if err := w.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+		c.add(time.Second)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	backups, _ := w.Backups()
+	if len(backups) != 1 || !strings.HasSuffix(backups[0], ".log.gz") {
+		t.Errorf("unexpected backups %v", backups)
+	}
+}
@@ -0,0 +1,33 @@
+//go:build !windows
+
+package rotatelog
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReopenOnSIGHUP(t *testing.T) {
+	w, _ := makeWriter(t)
+	w.ReopenOnSIGHUP()
+	defer w.Close()
+	w.Write([]byte("before"))
+	moved := w.Filename + ".1"
+	if err := os.Rename(w.Filename, moved); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(w.Filename); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	w.Write([]byte("after"))
+	checkContent(t, moved, "before")
+	checkContent(t, w.Filename, "after")
+}